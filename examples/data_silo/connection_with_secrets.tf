@@ -0,0 +1,24 @@
+variable "dd_api_key" { sensitive = true }
+variable "dd_app_key" { sensitive = true }
+
+resource "transcend_data_silo" "datadog" {
+  type            = "datadog"
+  skip_connecting = true
+}
+
+resource "transcend_data_silo_connection" "datadog" {
+  data_silo_id = transcend_data_silo.datadog.id
+
+  secret_context {
+    name  = "apiKey"
+    value = var.dd_api_key
+  }
+  secret_context {
+    name  = "applicationKey"
+    value = var.dd_app_key
+  }
+  plaintext_context {
+    name  = "queryTemplate"
+    value = "service:programmatic-remote-seeding AND @email:{{identifier}}"
+  }
+}
@@ -0,0 +1,13 @@
+resource "transcend_data_silo" "segment" {
+  type        = "segment"
+  identifiers = ["email"]
+}
+
+resource "transcend_data_silo" "postgres" {
+  type        = "server"
+  url         = "https://postgres-gateway.example.com/transcend"
+  identifiers = ["email", "coreIdentifier"]
+
+  # Only delete from Postgres once Segment has processed the request
+  depended_on_data_silo_ids = [transcend_data_silo.segment.id]
+}
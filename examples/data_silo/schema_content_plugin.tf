@@ -7,6 +7,12 @@ resource "transcend_data_silo" "aws" {
     type                       = "FULL_SCAN"
     schedule_frequency_minutes = 1440 # 1 day
     schedule_start_at          = "2022-09-06T17:51:13.000Z"
+
+    scan_plugin_config {
+      plugin_type = "CONTENT_CLASSIFICATION"
+      classifiers = ["EMAIL", "PHONE"]
+      sample_size = 1000
+    }
   }
 
   # ...other fields...
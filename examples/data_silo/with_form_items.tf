@@ -0,0 +1,12 @@
+variable "dd_api_key" { sensitive = true }
+variable "dd_app_key" { sensitive = true }
+
+resource "transcend_data_silo" "datadog" {
+  type = "datadog"
+
+  form_items = {
+    apiKey         = var.dd_api_key
+    applicationKey = var.dd_app_key
+    queryTemplate  = "service:programmatic-remote-seeding AND @email:{{identifier}}"
+  }
+}
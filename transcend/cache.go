@@ -0,0 +1,211 @@
+package transcend
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
+
+	graphql "github.com/hasura/go-graphql-client"
+)
+
+// Prefixes of the keys of the cached lookups
+const (
+	sombraCacheKey       = "sombra:"
+	catalogCacheKey      = "catalog:"
+	identifierCacheKey   = "identifier:"
+	pluginsCacheKey      = "plugins:"
+	sombraDHCacheKey     = "sombra-dh:"
+	dependenciesCacheKey = "dependencies:"
+)
+
+// cacheInvalidations lists the cached lookups that each mutation sent by the provider can change. None of the
+// resources of the provider change sombras, the integration catalog or identifiers, so those stay cached for
+// the lifetime of the provider.
+var cacheInvalidations = map[string][]string{
+	"UpdateDataSilos":      {pluginsCacheKey, dependenciesCacheKey},
+	"ReconnectDataSilo":    {pluginsCacheKey},
+	"DeleteDataSilos":      {pluginsCacheKey, dependenciesCacheKey},
+	"UpdateDataSiloPlugin": {pluginsCacheKey},
+}
+
+// lookupCache memoizes the lookups that many resources repeat during a single plan or apply. Concurrent
+// lookups of the same key share a single request to the backend.
+type lookupCache struct {
+	mu sync.Mutex
+	// Lookups that are in flight are kept here too. Invalidating one removes it, so its result is not cached.
+	entries map[string]*lookupCacheEntry
+}
+
+type lookupCacheEntry struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func newLookupCache() *lookupCache {
+	return &lookupCache{entries: map[string]*lookupCacheEntry{}}
+}
+
+// get returns the cached value for the key, calling fetch if there is none. Failed lookups are not cached.
+func (c *lookupCache) get(ctx context.Context, key string, fetch func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	for {
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		if !ok {
+			entry = &lookupCacheEntry{done: make(chan struct{})}
+			c.entries[key] = entry
+			c.mu.Unlock()
+
+			entry.value, entry.err = fetch(ctx)
+
+			c.mu.Lock()
+			if entry.err != nil && c.entries[key] == entry {
+				delete(c.entries, key)
+			}
+			c.mu.Unlock()
+			close(entry.done)
+			return entry.value, entry.err
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// The request was shared with a lookup whose context ended first, so try again with this one
+		if entry.err != nil && ctx.Err() == nil && (errors.Is(entry.err, context.Canceled) || errors.Is(entry.err, context.DeadlineExceeded)) {
+			continue
+		}
+		return entry.value, entry.err
+	}
+}
+
+// invalidate drops the cached lookups whose keys start with any of the prefixes.
+func (c *lookupCache) invalidate(prefixes ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				delete(c.entries, key)
+				break
+			}
+		}
+	}
+}
+
+// invalidateAfterMutation drops the lookups that the mutation may have changed.
+func (c *lookupCache) invalidateAfterMutation(operationType string, operation string) {
+	if operationType != "mutation" {
+		return
+	}
+	if prefixes, ok := cacheInvalidations[operation]; ok {
+		c.invalidate(prefixes...)
+	}
+}
+
+// sombraURL looks up the customer facing URL of a sombra, or of the organization's primary sombra when sombraID is empty.
+func (c *Client) sombraURL(ctx context.Context, sombraID string) (string, error) {
+	value, err := c.cache.get(ctx, sombraCacheKey+sombraID, func(ctx context.Context) (interface{}, error) {
+		if sombraID == "" {
+			var queryPrimarySombra struct {
+				Organization struct {
+					Sombra struct {
+						CustomerUrl  graphql.String `graphql:"customerUrl"`
+						HostedMethod graphql.String `graphql:"hostedMethod"`
+					} `graphql:"sombra"`
+				} `graphql:"organization"`
+			}
+			err := c.graphql.Query(ctx, &queryPrimarySombra, map[string]interface{}{}, graphql.OperationName("SombraUrlQuery"))
+			return string(queryPrimarySombra.Organization.Sombra.CustomerUrl), err
+		}
+
+		var queryBySombraId struct {
+			Sombras []types.SombraOutput `graphql:"sombras(filterBy: {ids: [$sombra_id]})"`
+		}
+		err := c.graphql.Query(ctx, &queryBySombraId, map[string]interface{}{"sombra_id": sombraID}, graphql.OperationName("SombraUrlQuery"))
+		if err != nil {
+			return "", err
+		}
+		if len(queryBySombraId.Sombras) == 0 {
+			return "", errors.New("no sombra found with id " + sombraID)
+		}
+		return string(queryBySombraId.Sombras[0].CustomerUrl), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// catalog looks up the integration metadata of a data silo type.
+func (c *Client) catalog(ctx context.Context, integrationName string) (types.Catalog, error) {
+	value, err := c.cache.get(ctx, catalogCacheKey+integrationName, func(ctx context.Context) (interface{}, error) {
+		var catalogQuery struct {
+			Catalog struct {
+				Catalog types.Catalog `json:"catalog"`
+			} `graphql:"catalog(input: { integrationName: $integrationName })"`
+		}
+		err := c.graphql.Query(ctx, &catalogQuery, map[string]interface{}{
+			"integrationName": graphql.String(integrationName),
+		}, graphql.OperationName("catalog"))
+		return catalogQuery.Catalog.Catalog, err
+	})
+	if err != nil {
+		return types.Catalog{}, err
+	}
+	return value.(types.Catalog), nil
+}
+
+// identifiersByText looks up the identifiers matching a text.
+func (c *Client) identifiersByText(ctx context.Context, text string) ([]types.Identifier, error) {
+	value, err := c.cache.get(ctx, identifierCacheKey+text, func(ctx context.Context) (interface{}, error) {
+		var query struct {
+			Identifiers struct {
+				Nodes []types.Identifier
+			} `graphql:"identifiers(filterBy: { text: $text })"`
+		}
+		err := c.graphql.Query(ctx, &query, map[string]interface{}{
+			"text": graphql.String(text),
+		}, graphql.OperationName("Identifiers"))
+		return query.Identifiers.Nodes, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]types.Identifier), nil
+}
+
+// dataSiloPlugins looks up every plugin of a data silo, shared between the data silo and its plugin resources.
+func (c *Client) dataSiloPlugins(ctx context.Context, dataSiloID string) ([]types.Plugin, error) {
+	value, err := c.cache.get(ctx, pluginsCacheKey+dataSiloID, func(ctx context.Context) (interface{}, error) {
+		var pluginQuery struct {
+			Plugins struct {
+				Plugins []types.Plugin
+			} `graphql:"plugins(filterBy: { dataSiloId: $dataSiloId })"`
+		}
+		err := c.graphql.Query(ctx, &pluginQuery, map[string]interface{}{
+			"dataSiloId": graphql.String(dataSiloID),
+		}, graphql.OperationName("Plugins"))
+		return pluginQuery.Plugins.Plugins, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]types.Plugin), nil
+}
+
+// pluginsOfType keeps the plugins of the given type.
+func pluginsOfType(plugins []types.Plugin, pluginType types.PluginType) []types.Plugin {
+	var matching []types.Plugin
+	for _, plugin := range plugins {
+		if plugin.Type == pluginType {
+			matching = append(matching, plugin)
+		}
+	}
+	return matching
+}
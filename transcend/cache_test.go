@@ -0,0 +1,169 @@
+package transcend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	graphql "github.com/hasura/go-graphql-client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCacheCoalescesConcurrentLookups(t *testing.T) {
+	cache := newLookupCache()
+	var fetches int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (interface{}, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return "https://sombra.example.com", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := cache.get(context.Background(), sombraCacheKey, fetch)
+			assert.Nil(t, err)
+			assert.Equal(t, "https://sombra.example.com", value)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	value, err := cache.get(context.Background(), sombraCacheKey, fetch)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://sombra.example.com", value)
+	assert.Equal(t, int32(1), fetches)
+}
+
+func TestLookupCacheDoesNotCacheErrors(t *testing.T) {
+	cache := newLookupCache()
+	fetches := 0
+	fetch := func(ctx context.Context) (interface{}, error) {
+		fetches++
+		if fetches == 1 {
+			return nil, errors.New("backend unavailable")
+		}
+		return "datadog", nil
+	}
+
+	_, err := cache.get(context.Background(), catalogCacheKey+"datadog", fetch)
+	assert.EqualError(t, err, "backend unavailable")
+	value, err := cache.get(context.Background(), catalogCacheKey+"datadog", fetch)
+	assert.Nil(t, err)
+	assert.Equal(t, "datadog", value)
+	assert.Equal(t, 2, fetches)
+}
+
+func TestLookupCacheRetriesLookupsSharedWithACanceledCaller(t *testing.T) {
+	cache := newLookupCache()
+	started := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go cache.get(ctx, identifierCacheKey+"email", func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	<-started
+
+	done := make(chan interface{})
+	go func() {
+		value, err := cache.get(context.Background(), identifierCacheKey+"email", func(ctx context.Context) (interface{}, error) {
+			return "identifier-email", nil
+		})
+		assert.Nil(t, err)
+		done <- value
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	assert.Equal(t, "identifier-email", <-done)
+}
+
+func TestLookupCacheInvalidation(t *testing.T) {
+	cache := newLookupCache()
+	fetches := 0
+	fetch := func(ctx context.Context) (interface{}, error) {
+		fetches++
+		return fetches, nil
+	}
+
+	cache.get(context.Background(), pluginsCacheKey+"silo-1", fetch)
+	cache.get(context.Background(), catalogCacheKey+"datadog", fetch)
+	cache.invalidateAfterMutation("query", "UpdateDataSiloPlugin")
+	cache.invalidateAfterMutation("mutation", "UpdateApiKey")
+	value, _ := cache.get(context.Background(), pluginsCacheKey+"silo-1", fetch)
+	assert.Equal(t, 1, value, "queries and unrelated mutations keep the cache")
+
+	cache.invalidateAfterMutation("mutation", "UpdateDataSiloPlugin")
+	value, _ = cache.get(context.Background(), pluginsCacheKey+"silo-1", fetch)
+	assert.Equal(t, 3, value, "the plugins are fetched again after a plugin update")
+	value, _ = cache.get(context.Background(), catalogCacheKey+"datadog", fetch)
+	assert.Equal(t, 2, value, "the catalog is not changed by a plugin update")
+}
+
+func TestLookupCacheDropsLookupsInFlightDuringInvalidation(t *testing.T) {
+	cache := newLookupCache()
+	fetches := 0
+	value, _ := cache.get(context.Background(), pluginsCacheKey+"silo-1", func(ctx context.Context) (interface{}, error) {
+		fetches++
+		// The plugins are updated while they are being read, so this answer may be stale
+		cache.invalidate(pluginsCacheKey)
+		return fetches, nil
+	})
+	assert.Equal(t, 1, value)
+
+	value, _ = cache.get(context.Background(), pluginsCacheKey+"silo-1", func(ctx context.Context) (interface{}, error) {
+		fetches++
+		return fetches, nil
+	})
+	assert.Equal(t, 2, value)
+}
+
+func TestClientInvalidatesPluginsAfterPluginUpdates(t *testing.T) {
+	var pluginQueries int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, operation := graphQLOperation(r)
+		if operation == "Plugins" {
+			atomic.AddInt32(&pluginQueries, 1)
+			w.Write([]byte(`{"data":{"plugins":{"plugins":[{"id":"plugin-1","type":"SCHEMA_DISCOVERY"}]}}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"updateDataSiloPlugin":{"plugin":{"id":"plugin-1"}}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithConfig(ClientConfig{
+		URL:      server.URL,
+		APIToken: "api-key",
+		Retry:    DefaultRetryConfig(),
+	})
+
+	for i := 0; i < 3; i++ {
+		plugins, err := client.dataSiloPlugins(context.Background(), "silo-1")
+		assert.Nil(t, err)
+		assert.Len(t, pluginsOfType(plugins, "SCHEMA_DISCOVERY"), 1)
+		assert.Len(t, pluginsOfType(plugins, "CONTENT_CLASSIFICATION"), 0)
+	}
+	assert.Equal(t, int32(1), pluginQueries)
+
+	var mutation struct {
+		UpdateDataSiloPlugin struct {
+			Plugin struct {
+				ID graphql.String
+			}
+		} `graphql:"updateDataSiloPlugin(input: { id: \"plugin-1\" })"`
+	}
+	assert.Nil(t, client.graphql.Mutate(context.Background(), &mutation, nil, graphql.OperationName("UpdateDataSiloPlugin")))
+
+	_, err := client.dataSiloPlugins(context.Background(), "silo-1")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), pluginQueries)
+}
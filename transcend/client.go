@@ -7,48 +7,152 @@ import (
 )
 
 type backendTransport struct {
-	apiToken string
+	apiKey    CredentialSource
+	retry     RetryConfig
+	base      http.RoundTripper
+	scheduler *scheduler
+	cache     *lookupCache
 }
 
 func (t *backendTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.Header.Add("Authorization", "Bearer "+t.apiToken)
-	return http.DefaultTransport.RoundTrip(req)
+	operationType, operation := graphQLOperation(req)
+	// Invalidating before the mutation too drops the lookups that are in flight while it runs, so that a result
+	// read before the mutation landed is not cached, see lookupCache.entries
+	t.cache.invalidateAfterMutation(operationType, operation)
+	defer t.cache.invalidateAfterMutation(operationType, operation)
+
+	apiKey, err := t.apiKey.Key(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	setHeaders := func(header http.Header) {
+		header.Set("Authorization", "Bearer "+apiKey)
+	}
+	return logRoundTrip(req, describeGraphQLExchange, setHeaders, func(req *http.Request) (*http.Response, error) {
+		base := scheduledTransport{base: t.base, scheduler: t.scheduler, operation: operation}
+		return roundTripWithRetries(req, base, t.retry, isGraphQLQuery, func(attemptReq *http.Request) {
+			setHeaders(attemptReq.Header)
+		})
+	})
 }
 
 type sombraTransport struct {
-	apiToken    string
-	internalKey string
+	apiKey      CredentialSource
+	internalKey CredentialSource
+	retry       RetryConfig
+	base        http.RoundTripper
+	scheduler   *scheduler
 }
 
 func (t *sombraTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	req.Header.Add("Authorization", "Bearer "+t.apiToken)
-	if t.internalKey != "" {
-		req.Header.Add("x-sombra-authorization", "Bearer "+t.internalKey)
+	apiKey, err := t.apiKey.Key(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	internalKey := ""
+	if t.internalKey != nil {
+		if internalKey, err = t.internalKey.Key(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+	setHeaders := func(header http.Header) {
+		header.Set("Authorization", "Bearer "+apiKey)
+		if internalKey != "" {
+			header.Set("x-sombra-authorization", "Bearer "+internalKey)
+		}
 	}
-	return http.DefaultTransport.RoundTrip(req)
+	return logRoundTrip(req, describeSombraExchange, setHeaders, func(req *http.Request) (*http.Response, error) {
+		base := scheduledTransport{base: t.base, scheduler: t.scheduler, operation: req.URL.Path}
+		return roundTripWithRetries(req, base, t.retry, alwaysReplayable, func(attemptReq *http.Request) {
+			setHeaders(attemptReq.Header)
+		})
+	})
 }
 
-
 type Client struct {
-   graphql         *graphql.Client
-   sombraClient    *http.Client
-   url             string
-   internalSombraUrl string
+	graphql               *graphql.Client
+	cache                 *lookupCache
+	dependencies          *dependencyGraph
+	pluginOwnership       *pluginOwnership
+	sombraClient          *http.Client
+	url                   string
+	internalSombraUrl     string
+	saasContextEncryption string
+	sombraPublicKey       []byte
 }
 
+// ClientConfig holds everything needed to talk to the Transcend backend and sombra.
+type ClientConfig struct {
+	URL               string
+	APIToken          string
+	InternalKey       string
+	InternalSombraURL string
+	Retry             RetryConfig
+	// Sources for the API keys that take precedence over APIToken and InternalKey, e.g. a key_command
+	APIKeySource      CredentialSource
+	InternalKeySource CredentialSource
+	// The transports used to reach the backend and sombra. Defaults to http.DefaultTransport.
+	BackendTransport http.RoundTripper
+	SombraTransport  http.RoundTripper
+	// Limits on the requests in flight. The zero value sends every request right away.
+	Scheduler SchedulerConfig
+	// Whether SaaS contexts are encrypted end to end with sombra: "auto" (the default), "required" or "disabled"
+	SaasContextEncryption string
+	// The X25519 public key of sombra to encrypt SaaS contexts with, instead of the one that sombra serves
+	SombraPublicKey []byte
+}
 
 func NewClient(url, apiToken string, internalKey string) *Client {
-   return NewClientWithSombraUrl(url, apiToken, internalKey, "")
+	return NewClientWithSombraUrl(url, apiToken, internalKey, "")
 }
 
 func NewClientWithSombraUrl(url, apiToken string, internalKey string, internalSombraUrl string) *Client {
-   backendClient := &http.Client{Transport: &backendTransport{apiToken: apiToken}}
-   sombraClient := &http.Client{Transport: &sombraTransport{apiToken: apiToken, internalKey: internalKey}}
-
-   return &Client{
-	   graphql:      graphql.NewClient(url, backendClient),
-	   sombraClient: sombraClient,
-	   url:          url,
-	   internalSombraUrl: internalSombraUrl,
-   }
+	return NewClientWithConfig(ClientConfig{
+		URL:               url,
+		APIToken:          apiToken,
+		InternalKey:       internalKey,
+		InternalSombraURL: internalSombraUrl,
+		Retry:             DefaultRetryConfig(),
+	})
+}
+
+func NewClientWithConfig(config ClientConfig) *Client {
+	if config.BackendTransport == nil {
+		config.BackendTransport = http.DefaultTransport
+	}
+	if config.SombraTransport == nil {
+		config.SombraTransport = http.DefaultTransport
+	}
+	if config.APIKeySource == nil {
+		config.APIKeySource = StaticCredential(config.APIToken)
+	}
+	if config.SaasContextEncryption == "" {
+		config.SaasContextEncryption = saasContextEncryptionAuto
+	}
+	if config.InternalKeySource == nil && config.InternalKey != "" {
+		config.InternalKeySource = StaticCredential(config.InternalKey)
+	}
+	var limiter *tokenBucket
+	if config.Scheduler.MaxRequestsPerSecond > 0 {
+		limiter = newTokenBucket(config.Scheduler.MaxRequestsPerSecond)
+	}
+	backendScheduler := newScheduler(config.Scheduler.MaxConcurrentRequests, limiter)
+	sombraScheduler := newScheduler(config.Scheduler.MaxConcurrentSombraRequests, nil)
+
+	cache := newLookupCache()
+
+	backendClient := &http.Client{Transport: &backendTransport{apiKey: config.APIKeySource, retry: config.Retry, base: config.BackendTransport, scheduler: backendScheduler, cache: cache}}
+	sombraClient := &http.Client{Transport: &sombraTransport{apiKey: config.APIKeySource, internalKey: config.InternalKeySource, retry: config.Retry, base: config.SombraTransport, scheduler: sombraScheduler}}
+
+	return &Client{
+		graphql:               graphql.NewClient(config.URL, backendClient),
+		cache:                 cache,
+		dependencies:          newDependencyGraph(),
+		pluginOwnership:       newPluginOwnership(),
+		sombraClient:          sombraClient,
+		url:                   config.URL,
+		internalSombraUrl:     config.InternalSombraURL,
+		saasContextEncryption: config.SaasContextEncryption,
+		sombraPublicKey:       config.SombraPublicKey,
+	}
 }
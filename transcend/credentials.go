@@ -0,0 +1,155 @@
+package transcend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// Credentials from a key_command are refreshed this long before they expire, or halfway through
+// their lifetime for credentials that live shorter than twice this window.
+const credentialRefreshWindow = 5 * time.Minute
+
+// CredentialSource provides an API key, fetching a fresh one whenever the previous one is about to expire.
+type CredentialSource interface {
+	Key(ctx context.Context) (string, error)
+}
+
+// StaticCredential is an API key set directly in the provider configuration.
+type StaticCredential string
+
+func (c StaticCredential) Key(ctx context.Context) (string, error) {
+	return string(c), nil
+}
+
+// FileCredential reads the API key from a file, re-reading it whenever the file changes so that
+// keys rotated by an agent (e.g. Vault agent) are picked up during long applies.
+type FileCredential struct {
+	path string
+
+	mu      sync.Mutex
+	key     string
+	modTime time.Time
+}
+
+func NewFileCredential(path string) *FileCredential {
+	return &FileCredential{path: path}
+}
+
+func (c *FileCredential) Key(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return "", fmt.Errorf("could not read API key file: %w", err)
+	}
+	if c.key != "" && info.ModTime().Equal(c.modTime) {
+		return c.key, nil
+	}
+	contents, err := os.ReadFile(c.path)
+	if err != nil {
+		return "", fmt.Errorf("could not read API key file: %w", err)
+	}
+	key := strings.TrimSpace(string(contents))
+	if key == "" {
+		return "", fmt.Errorf("API key file %s is empty", c.path)
+	}
+	c.key = key
+	c.modTime = info.ModTime()
+	return c.key, nil
+}
+
+// CommandCredential runs a credential process that prints a JSON object to stdout:
+//
+//	{"key": "<api key>", "expires_at": "2022-09-06T17:51:13Z"}
+//
+// expires_at is optional. When it is set, the command is run again shortly before the key expires.
+type CommandCredential struct {
+	command []string
+	now     func() time.Time
+
+	mu        sync.Mutex
+	key       string
+	refreshAt time.Time
+}
+
+type commandCredentialOutput struct {
+	Key       string    `json:"key"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func NewCommandCredential(command []string) *CommandCredential {
+	return &CommandCredential{command: command, now: time.Now}
+}
+
+func (c *CommandCredential) Key(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.key != "" && (c.refreshAt.IsZero() || c.now().Before(c.refreshAt)) {
+		return c.key, nil
+	}
+	if len(c.command) == 0 {
+		return "", errors.New("the API key command is empty")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.command[0], c.command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("API key command %q failed: %w: %s", c.command[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	var output commandCredentialOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return "", fmt.Errorf("API key command %q did not print a JSON object with a \"key\": %w", c.command[0], err)
+	}
+	if output.Key == "" {
+		return "", fmt.Errorf("API key command %q did not return a \"key\"", c.command[0])
+	}
+
+	now := c.now()
+	c.key = output.Key
+	c.refreshAt = time.Time{}
+	if !output.ExpiresAt.IsZero() {
+		if !output.ExpiresAt.After(now) {
+			return "", fmt.Errorf("API key command %q returned a key that expired at %s", c.command[0], output.ExpiresAt.Format(time.RFC3339))
+		}
+		refreshBefore := credentialRefreshWindow
+		if lifetime := output.ExpiresAt.Sub(now); lifetime < 2*credentialRefreshWindow {
+			refreshBefore = lifetime / 2
+		}
+		c.refreshAt = output.ExpiresAt.Add(-refreshBefore)
+	}
+	return c.key, nil
+}
+
+// credentialSourceFromConfig picks the credential source configured with the given attributes,
+// preferring a command over a file over a literal key. It returns nil when none is set.
+func credentialSourceFromConfig(d *schema.ResourceData, keyAttribute, fileAttribute, commandAttribute string) CredentialSource {
+	if rawCommand := d.Get(commandAttribute).([]interface{}); len(rawCommand) > 0 {
+		command := make([]string, len(rawCommand))
+		for i, arg := range rawCommand {
+			command[i], _ = arg.(string)
+		}
+		return NewCommandCredential(command)
+	}
+	if path := d.Get(fileAttribute).(string); path != "" {
+		return NewFileCredential(path)
+	}
+	if key := d.Get(keyAttribute).(string); key != "" {
+		return StaticCredential(key)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package transcend
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCredentialScript creates a credential process that counts its invocations in a file next to it
+func writeCredentialScript(t *testing.T, output string) (command []string, invocations func() int) {
+	dir := t.TempDir()
+	countFile := filepath.Join(dir, "count")
+	script := filepath.Join(dir, "credential.sh")
+	contents := fmt.Sprintf("#!/bin/sh\necho x >> %q\ncat <<'EOF'\n%s\nEOF\n", countFile, output)
+	assert.Nil(t, os.WriteFile(script, []byte(contents), 0700))
+
+	return []string{"sh", script}, func() int {
+		data, _ := os.ReadFile(countFile)
+		return len(data) / 2
+	}
+}
+
+func TestFileCredentialIsReadAgainWhenChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	assert.Nil(t, os.WriteFile(path, []byte("first-key\n"), 0600))
+	credential := NewFileCredential(path)
+
+	key, err := credential.Key(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "first-key", key)
+
+	assert.Nil(t, os.WriteFile(path, []byte("second-key"), 0600))
+	later := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(path, later, later))
+	key, err = credential.Key(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "second-key", key)
+
+	assert.Nil(t, os.WriteFile(path, []byte(""), 0600))
+	assert.Nil(t, os.Chtimes(path, later.Add(time.Minute), later.Add(time.Minute)))
+	_, err = credential.Key(context.Background())
+	assert.NotNil(t, err)
+}
+
+func TestCommandCredentialIsRefreshedBeforeExpiry(t *testing.T) {
+	now := time.Date(2022, 9, 6, 17, 0, 0, 0, time.UTC)
+	command, invocations := writeCredentialScript(t, `{"key": "short-lived-key", "expires_at": "2022-09-06T18:00:00Z"}`)
+	credential := NewCommandCredential(command)
+	credential.now = func() time.Time { return now }
+
+	key, err := credential.Key(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "short-lived-key", key)
+	assert.Equal(t, 1, invocations())
+
+	now = now.Add(50 * time.Minute)
+	_, err = credential.Key(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, invocations(), "the key is cached until it is close to expiring")
+
+	now = now.Add(6 * time.Minute)
+	_, err = credential.Key(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, invocations(), "the command runs again within the refresh window")
+}
+
+func TestCommandCredentialWithoutExpiryIsCached(t *testing.T) {
+	command, invocations := writeCredentialScript(t, `{"key": "long-lived-key"}`)
+	credential := NewCommandCredential(command)
+
+	for i := 0; i < 3; i++ {
+		key, err := credential.Key(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, "long-lived-key", key)
+	}
+	assert.Equal(t, 1, invocations())
+}
+
+func TestCommandCredentialErrors(t *testing.T) {
+	_, err := NewCommandCredential([]string{"sh", "-c", "echo vault is sealed >&2; exit 2"}).Key(context.Background())
+	assert.Regexp(t, "vault is sealed", err)
+
+	_, err = NewCommandCredential([]string{"sh", "-c", "echo not-json"}).Key(context.Background())
+	assert.Regexp(t, "JSON", err)
+
+	_, err = NewCommandCredential([]string{"sh", "-c", `echo '{"key": "old", "expires_at": "2000-01-01T00:00:00Z"}'`}).Key(context.Background())
+	assert.Regexp(t, "expired", err)
+}
+
+func TestUnitProviderKeyCommand(t *testing.T) {
+	backend := newFakeBackend(t)
+	command, _ := writeCredentialScript(t, `{"key": "key-from-command"}`)
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "transcend" {
+  url         = %q
+  key_command = [%q, %q]
+}
+
+data "transcend_identifier" "email" {
+  text = "email"
+}
+`, backend.server.URL, command[0], command[1]),
+				Check: func(*terraform.State) error {
+					backend.mu.Lock()
+					defer backend.mu.Unlock()
+					for _, authorization := range backend.authorizations {
+						if authorization != "Bearer key-from-command" {
+							return fmt.Errorf("unexpected Authorization header %q", authorization)
+						}
+					}
+					if len(backend.authorizations) == 0 {
+						return fmt.Errorf("no request reached the backend")
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestUnitProviderKeyCommandFailure(t *testing.T) {
+	backend := newFakeBackend(t)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "transcend" {
+  url         = %q
+  key_command = ["sh", "-c", "exit 1"]
+}
+
+data "transcend_identifier" "email" {
+  text = "email"
+}
+`, backend.server.URL),
+				ExpectError: regexp.MustCompile("Unable to load the key of the provider"),
+			},
+		},
+	})
+}
@@ -0,0 +1,179 @@
+package transcend
+
+import (
+	"context"
+
+	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	graphql "github.com/hasura/go-graphql-client"
+)
+
+// The form values shared by transcend_data_silo and transcend_data_silo_connection
+func plaintextContextSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeSet,
+		Optional:    true,
+		Description: "This is where you put non-secretive values that go in the form when connecting a data silo",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "The name of the plaintext input",
+				},
+				"value": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "The value of the plaintext input",
+				},
+			},
+		},
+	}
+}
+
+func secretContextSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeSet,
+		Optional:    true,
+		Description: "This is where you put values that go in the form when connecting a data silo. In general, most form values are secret context.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "The name of the input",
+				},
+				"value": {
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "The value of the input in plaintext",
+					Sensitive:   true,
+				},
+			},
+		},
+	}
+}
+
+// dataSiloCredentials is everything needed to connect a data silo, whichever resource holds the form values
+type dataSiloCredentials struct {
+	dataSiloID      string
+	integrationName string
+	// The sombra that encrypts the secrets, or the primary sombra when empty
+	sombraID         string
+	plaintextContext []types.PlaintextContextInput
+	secretContext    map[string]string
+	// Form values routed to the plaintext or secret context by the catalog of the integration
+	formItems              map[string]interface{}
+	allowedIdentifierPaths []types.AllowedIdentifierPath
+}
+
+// registeredSaasContext is a SaaS context presigned by sombra, ready to be passed to reconnectDataSilo
+type registeredSaasContext struct {
+	presigned string
+	// Lets sombra decrypt the presigned context when it was encrypted end to end, empty otherwise
+	dhEncrypted string
+}
+
+// registerDataSiloCredentials has sombra encrypt the secret context of the data silo into a presigned SaaS
+// context, and returns it with the form items that are sent as plaintext context.
+func registerDataSiloCredentials(ctx context.Context, client *Client, credentials dataSiloCredentials) (*registeredSaasContext, []types.PlaintextContextInput, diag.Diagnostics) {
+	// Presign the SaaS context if the integration has secrets
+	// For Internal Transcend Folks, see: https://docs.google.com/document/d/1PURNdW7VI9r9kwDM4fud9Hx_58vZbhMhB8OPEYxl8O4/view#
+	var diags diag.Diagnostics
+
+	// Allow override of sombra URL from provider settings
+	var sombraCustomerUrl string
+	if client.internalSombraUrl != "" {
+		sombraCustomerUrl = client.internalSombraUrl
+	} else {
+		// Lookup the sombra URL to talk to
+		var err error
+		sombraCustomerUrl, err = client.sombraURL(ctx, credentials.sombraID)
+		if err != nil {
+			diags = append(diags, graphQLErrorDiagnostics("Error Finding sombra URL", err)...)
+			return nil, nil, diags
+		}
+	}
+
+	// Lookup the saas context metadata
+	catalog, err := client.catalog(ctx, credentials.integrationName)
+	if err != nil {
+		diags = append(diags, graphQLErrorDiagnostics("Error Finding saas context metadata", err)...)
+		return nil, nil, diags
+	}
+	// Route the form items to the plaintext context or the secret map
+	err = types.ValidateFormItems(credentials.integrationName, catalog, credentials.formItems, true)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       "Invalid form items",
+			Detail:        err.Error(),
+			AttributePath: cty.GetAttrPath("form_items"),
+		})
+		return nil, nil, diags
+	}
+	formItemPlaintext, formItemSecrets := types.SplitFormItems(catalog, credentials.formItems)
+
+	// Have sombra encrypt the secret map and parse the resulting saas context
+	allowedBaseHosts := catalog.IntegrationConfig.ConfiguredBaseHosts.PROD
+	jsonBody, err := types.ConstructSecretMapString(credentials.secretContext, allowedBaseHosts, catalog.PlaintextInformation, credentials.allowedIdentifierPaths, formItemSecrets)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Error encoding secret map to create saas context",
+			Detail:   "Error when updating data silo: " + err.Error(),
+		})
+		return nil, nil, diags
+	}
+	saasContext, dhEncrypted, err := client.registerSaasContext(ctx, sombraCustomerUrl, jsonBody)
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Error creating SaaS context for the secret values",
+			Detail:   "Error when updating data silo: " + err.Error(),
+		})
+		return nil, nil, diags
+	}
+
+	return &registeredSaasContext{presigned: saasContext, dhEncrypted: dhEncrypted}, formItemPlaintext, diags
+}
+
+// connectDataSilo tests the credentials of the data silo, saving the form fields on success. The secrets are only
+// registered with sombra when registerSecrets is set.
+func connectDataSilo(ctx context.Context, client *Client, credentials dataSiloCredentials, registerSecrets bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	input := types.ReconnectDataSiloInput{
+		DataSiloId:       graphql.String(credentials.dataSiloID),
+		PlaintextContext: credentials.plaintextContext,
+	}
+	// This is not needed when no encrypted saas contexts are provided
+	dhEncrypted := ""
+	if registerSecrets {
+		saasContext, formItemPlaintext, diags := registerDataSiloCredentials(ctx, client, credentials)
+		if diags.HasError() {
+			return diags
+		}
+		input.PlaintextContext = append(input.PlaintextContext, formItemPlaintext...)
+		input.PresignedSaasContext = graphql.String(saasContext.presigned)
+		dhEncrypted = saasContext.dhEncrypted
+	}
+
+	var connectMutation struct {
+		ReconnectDataSilo struct {
+			DataSilo types.DataSilo
+		} `graphql:"reconnectDataSilo(input: $input, dhEncrypted: $dhEncrypted)"`
+	}
+	connectVars := map[string]interface{}{
+		"input":       input,
+		"dhEncrypted": graphql.String(dhEncrypted),
+	}
+	err := client.graphql.Mutate(ctx, &connectMutation, connectVars, graphql.OperationName("ReconnectDataSilo"))
+	if err != nil {
+		diags = append(diags, graphQLErrorDiagnostics("Error connecting data silos", err)...)
+	}
+	return diags
+}
@@ -0,0 +1,194 @@
+package transcend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	graphql "github.com/hasura/go-graphql-client"
+)
+
+// dataSiloDependencies is a data silo along with the data silos that it waits on during deletion requests
+type dataSiloDependencies struct {
+	ID    string
+	Title string
+	// The IDs of the data silos that this data silo depends on
+	DependsOn []string
+}
+
+// dependencyGraph checks that the dependencies between data silos stay acyclic. The dependencies planned by the
+// resources of the current run take precedence over those stored in the backend, so that two resources that
+// would form a cycle together are caught even though neither is applied yet. Planned dependencies are dropped
+// once applied, as the backend has them from then on.
+type dependencyGraph struct {
+	mu sync.Mutex
+	// The dependencies that replace those of a data silo, from its depended_on_data_silo_ids
+	replaced map[string][]string
+	// The dependencies added to a data silo by transcend_data_silo_dependency resources
+	added map[string]map[string]bool
+	// Serialize the updates of the dependencies of each data silo, as every update replaces all of them
+	locks map[string]*sync.Mutex
+}
+
+func newDependencyGraph() *dependencyGraph {
+	return &dependencyGraph{
+		replaced: map[string][]string{},
+		added:    map[string]map[string]bool{},
+		locks:    map[string]*sync.Mutex{},
+	}
+}
+
+// plan records the dependencies that a data silo will have once the run is applied.
+func (g *dependencyGraph) plan(dataSiloID string, dependsOn []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.replaced[dataSiloID] = dependsOn
+}
+
+// planEdge records a dependency that will be added to a data silo once the run is applied.
+func (g *dependencyGraph) planEdge(dataSiloID string, dependsOnID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.added[dataSiloID] == nil {
+		g.added[dataSiloID] = map[string]bool{}
+	}
+	g.added[dataSiloID][dependsOnID] = true
+}
+
+// forget drops the planned dependencies of a data silo once they are applied, or failed to, so that the
+// dependencies stored in the backend are used again.
+func (g *dependencyGraph) forget(dataSiloID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.replaced, dataSiloID)
+}
+
+// forgetEdge drops a planned dependency once it is applied, or failed to.
+func (g *dependencyGraph) forgetEdge(dataSiloID string, dependsOnID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.added[dataSiloID], dependsOnID)
+	if len(g.added[dataSiloID]) == 0 {
+		delete(g.added, dataSiloID)
+	}
+}
+
+// lock waits for the other updates of the dependencies of a data silo, returning the function that unlocks it.
+func (g *dependencyGraph) lock(dataSiloID string) func() {
+	g.mu.Lock()
+	lock, ok := g.locks[dataSiloID]
+	if !ok {
+		lock = &sync.Mutex{}
+		g.locks[dataSiloID] = lock
+	}
+	g.mu.Unlock()
+	lock.Lock()
+	return lock.Unlock
+}
+
+// nextDependencies returns the dependencies that a data silo will have once the run is applied.
+func (c *Client) nextDependencies(ctx context.Context, dataSiloID string) ([]string, error) {
+	c.dependencies.mu.Lock()
+	dependsOn, replaced := c.dependencies.replaced[dataSiloID]
+	next := append([]string{}, dependsOn...)
+	for id := range c.dependencies.added[dataSiloID] {
+		next = append(next, id)
+	}
+	c.dependencies.mu.Unlock()
+
+	if !replaced {
+		dependencies, err := c.dataSiloDependencies(ctx, dataSiloID)
+		if err != nil {
+			return nil, err
+		}
+		next = append(next, dependencies.DependsOn...)
+	}
+	sort.Strings(next)
+	return next, nil
+}
+
+// dataSiloDependencies looks up the data silos that a data silo depends on.
+func (c *Client) dataSiloDependencies(ctx context.Context, dataSiloID string) (dataSiloDependencies, error) {
+	value, err := c.cache.get(ctx, dependenciesCacheKey+dataSiloID, func(ctx context.Context) (interface{}, error) {
+		var query struct {
+			DataSilo struct {
+				ID                 graphql.String
+				Title              graphql.String
+				DependentDataSilos []struct {
+					ID graphql.String
+				}
+			} `graphql:"dataSilo(id: $id)"`
+		}
+		err := c.graphql.Query(ctx, &query, map[string]interface{}{
+			"id": graphql.String(dataSiloID),
+		}, graphql.OperationName("DataSiloDependencies"))
+		if err != nil {
+			return nil, err
+		}
+		dependencies := dataSiloDependencies{
+			ID:    string(query.DataSilo.ID),
+			Title: string(query.DataSilo.Title),
+		}
+		for _, dependency := range query.DataSilo.DependentDataSilos {
+			dependencies.DependsOn = append(dependencies.DependsOn, string(dependency.ID))
+		}
+		return dependencies, nil
+	})
+	if err != nil {
+		return dataSiloDependencies{}, err
+	}
+	return value.(dataSiloDependencies), nil
+}
+
+// findDependencyCycle returns the data silos that would form a cycle, starting and ending with dataSiloID, if
+// dataSiloID were to depend on dependsOn. It returns nil if there would be no cycle.
+func (c *Client) findDependencyCycle(ctx context.Context, dataSiloID string, dependsOn []string) ([]string, error) {
+	visited := map[string]bool{}
+	var walk func(id string, path []string) ([]string, error)
+	walk = func(id string, path []string) ([]string, error) {
+		path = append(path, id)
+		if id == dataSiloID {
+			return path, nil
+		}
+		if visited[id] {
+			return nil, nil
+		}
+		visited[id] = true
+
+		next, err := c.nextDependencies(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, nextID := range next {
+			cycle, err := walk(nextID, path)
+			if cycle != nil || err != nil {
+				return cycle, err
+			}
+		}
+		return nil, nil
+	}
+
+	sorted := append([]string{}, dependsOn...)
+	sort.Strings(sorted)
+	for _, id := range sorted {
+		cycle, err := walk(id, []string{dataSiloID})
+		if cycle != nil || err != nil {
+			return cycle, err
+		}
+	}
+	return nil, nil
+}
+
+// describeDependencyCycle names the data silos of a cycle, e.g. "Postgres (silo-1) -> Segment (silo-2) -> Postgres (silo-1)".
+func (c *Client) describeDependencyCycle(ctx context.Context, cycle []string) string {
+	names := make([]string, len(cycle))
+	for i, id := range cycle {
+		names[i] = id
+		if dependencies, err := c.dataSiloDependencies(ctx, id); err == nil && dependencies.Title != "" {
+			names[i] = fmt.Sprintf("%s (%s)", dependencies.Title, id)
+		}
+	}
+	return strings.Join(names, " -> ")
+}
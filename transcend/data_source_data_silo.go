@@ -53,6 +53,56 @@ func dataSourceDataSilo() *schema.Resource {
 				Description: "The emails of the owners of the data silo",
 				Computed:    true,
 			},
+			"notes": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Any notes about the data silo",
+			},
+			"data_retention_note": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Notes about how long the vendor retains data",
+			},
+			"data_processing_agreement_link": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The URL of the data processing agreement signed with the vendor",
+			},
+			"contact_name": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The name of the contact at the vendor",
+			},
+			"contact_email": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The email address of the contact at the vendor",
+			},
+			"data_processing_agreement_status": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The status of the data processing agreement with the vendor",
+			},
+			"recommended_for_consent": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the data silo is recommended for consent management",
+			},
+			"recommended_for_privacy": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the data silo is recommended for privacy requests",
+			},
+			"has_personal_data": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the data silo stores personal data",
+			},
+			"deprecation_state": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Whether the data silo is being deprecated",
+			},
 		},
 	}
 }
@@ -93,13 +143,9 @@ func dataSourceDataSiloRead(ctx context.Context, d *schema.ResourceData, m inter
 		"filterByInput": filters,
 	}
 
-	err := client.graphql.Query(context.Background(), &query, vars, graphql.OperationName("DataSilos"))
+	err := client.graphql.Query(ctx, &query, vars, graphql.OperationName("DataSilos"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error finding data silo",
-			Detail:   "Error when finding data silo: " + err.Error(),
-		})
+		diags = append(diags, graphQLErrorDiagnostics("Error finding data silo", err)...)
 		return diags
 	}
 	if len(query.DataSilos.Nodes) == 0 {
@@ -125,6 +171,16 @@ func dataSourceDataSiloRead(ctx context.Context, d *schema.ResourceData, m inter
 	d.Set("title", dataSilo.Title)
 	d.Set("link", dataSilo.Link)
 	d.Set("description", dataSilo.Description)
+	d.Set("notes", dataSilo.Notes)
+	d.Set("data_retention_note", dataSilo.DataRetentionNote)
+	d.Set("data_processing_agreement_link", dataSilo.DataProcessingAgreementLink)
+	d.Set("contact_name", dataSilo.ContactName)
+	d.Set("contact_email", dataSilo.ContactEmail)
+	d.Set("data_processing_agreement_status", dataSilo.DataProcessingAgreementStatus)
+	d.Set("recommended_for_consent", dataSilo.RecommendedForConsent)
+	d.Set("recommended_for_privacy", dataSilo.RecommendedForPrivacy)
+	d.Set("has_personal_data", dataSilo.HasPersonalData)
+	d.Set("deprecation_state", dataSilo.DeprecationState)
 
 	owners := dataSilo.Owners
 	ownerEmails := make([]interface{}, len(owners))
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,3 +28,38 @@ func TestCanLookupDataSilo(t *testing.T) {
 	assert.NotEmpty(t, terraform.Output(t, options, "dataSiloOwners"))
 	assert.Contains(t, terraform.Output(t, options, "dataSiloOwners"), "david@transcend.io")
 }
+
+func TestUnitDataSiloDataSource(t *testing.T) {
+	backend := newFakeBackend(t)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type              = "server"
+  title             = "Lookup me up"
+  description       = "A server silo"
+  owner_emails      = ["david@transcend.io"]
+  skip_connecting   = true
+  contact_email     = "jane@example.com"
+  deprecation_state = "PENDING_DEPRECATION"
+}
+
+data "transcend_data_silo" "silo" {
+  title = transcend_data_silo.silo.title
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.transcend_data_silo.silo", "id", "transcend_data_silo.silo", "id"),
+					resource.TestCheckResourceAttrPair("data.transcend_data_silo.silo", "link", "transcend_data_silo.silo", "link"),
+					resource.TestCheckResourceAttr("data.transcend_data_silo.silo", "description", "A server silo"),
+					resource.TestCheckResourceAttr("data.transcend_data_silo.silo", "owner_emails.0", "david@transcend.io"),
+					resource.TestCheckResourceAttr("data.transcend_data_silo.silo", "contact_email", "jane@example.com"),
+					resource.TestCheckResourceAttr("data.transcend_data_silo.silo", "deprecation_state", "PENDING_DEPRECATION"),
+					resource.TestCheckResourceAttr("data.transcend_data_silo.silo", "has_personal_data", "true"),
+				),
+			},
+		},
+	})
+}
@@ -59,13 +59,9 @@ func dataSourceDataSilosRead(ctx context.Context, d *schema.ResourceData, m inte
 		"filterByInput": filters,
 	}
 
-	err := client.graphql.Query(context.Background(), &query, vars, graphql.OperationName("DataSilos"))
+	err := client.graphql.Query(ctx, &query, vars, graphql.OperationName("DataSilos"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error finding data silo",
-			Detail:   "Error when finding data silo: " + err.Error(),
-		})
+		diags = append(diags, graphQLErrorDiagnostics("Error finding data silo", err)...)
 		return diags
 	}
 
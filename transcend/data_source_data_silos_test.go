@@ -0,0 +1,39 @@
+package transcend
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestUnitDataSilosDataSource(t *testing.T) {
+	backend := newFakeBackend(t)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "servers" {
+  count           = 2
+  type            = "server"
+  title           = "server ${count.index}"
+  skip_connecting = true
+}
+
+resource "transcend_data_silo" "other" {
+  type            = "amazonS3"
+  skip_connecting = true
+}
+
+data "transcend_data_silos" "servers" {
+  type       = "server"
+  depends_on = [transcend_data_silo.servers, transcend_data_silo.other]
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.transcend_data_silos.servers", "ids.#", "2"),
+				),
+			},
+		},
+	})
+}
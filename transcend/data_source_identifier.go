@@ -5,8 +5,6 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	graphql "github.com/hasura/go-graphql-client"
-	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 )
 
 func dataSourceIdentifier() *schema.Resource {
@@ -35,26 +33,12 @@ func dataSourceIdentifierRead(ctx context.Context, d *schema.ResourceData, m int
 
 	var diags diag.Diagnostics
 
-	var query struct {
-		Identifiers struct {
-			Nodes []types.Identifier
-		} `graphql:"identifiers(filterBy: { text: $text })"`
-	}
-
-	vars := map[string]interface{}{
-		"text": graphql.String(d.Get("text").(string)),
-	}
-
-	err := client.graphql.Query(context.Background(), &query, vars, graphql.OperationName("Identifiers"))
+	identifiers, err := client.identifiersByText(ctx, d.Get("text").(string))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error finding identifier with text " + d.Get("text").(string),
-			Detail:   "Error when finding identifier: " + err.Error(),
-		})
+		diags = append(diags, graphQLErrorDiagnostics("Error finding identifier with text "+d.Get("text").(string), err)...)
 		return diags
 	}
-	if len(query.Identifiers.Nodes) == 0 {
+	if len(identifiers) == 0 {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Error finding identifier with text " + d.Get("text").(string),
@@ -62,7 +46,7 @@ func dataSourceIdentifierRead(ctx context.Context, d *schema.ResourceData, m int
 		})
 		return diags
 	}
-	if len(query.Identifiers.Nodes) > 1 {
+	if len(identifiers) > 1 {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Error finding identifier with text " + d.Get("text").(string),
@@ -71,7 +55,7 @@ func dataSourceIdentifierRead(ctx context.Context, d *schema.ResourceData, m int
 		return diags
 	}
 
-	identifier := query.Identifiers.Nodes[0]
+	identifier := identifiers[0]
 	d.Set("id", identifier.ID)
 	d.Set("name", identifier.Name)
 	d.SetId(string(identifier.ID))
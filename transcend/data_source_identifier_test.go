@@ -0,0 +1,36 @@
+package transcend
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestUnitIdentifierDataSource(t *testing.T) {
+	backend := newFakeBackend(t)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+data "transcend_identifier" "email" {
+  text = "email"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.transcend_identifier.email", "id", "identifier-email"),
+					resource.TestCheckResourceAttr("data.transcend_identifier.email", "name", "email"),
+				),
+			},
+			{
+				Config: backend.providerConfig() + `
+data "transcend_identifier" "missing" {
+  text = "fingerprint"
+}
+`,
+				ExpectError: regexp.MustCompile("Found 0 identifiers for given text"),
+			},
+		},
+	})
+}
@@ -39,13 +39,9 @@ func dataSourceSombraRead(ctx context.Context, d *schema.ResourceData, m interfa
 		"url": graphql.String(d.Get("url").(string)),
 	}
 
-	err := client.graphql.Query(context.Background(), &query, vars, graphql.OperationName("Sombras"))
+	err := client.graphql.Query(ctx, &query, vars, graphql.OperationName("Sombras"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error finding sombra with url " + d.Get("url").(string),
-			Detail:   "Error when finding sombra: " + err.Error(),
-		})
+		diags = append(diags, graphQLErrorDiagnostics("Error finding sombra with url "+d.Get("url").(string), err)...)
 		return diags
 	}
 	if len(query.Sombras) == 0 {
@@ -0,0 +1,27 @@
+package transcend
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestUnitSombraDataSource(t *testing.T) {
+	backend := newFakeBackend(t)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + fmt.Sprintf(`
+data "transcend_sombra" "sombra" {
+  url = %q
+}
+`, backend.server.URL),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("data.transcend_sombra.sombra", "id", "sombra-primary"),
+				),
+			},
+		},
+	})
+}
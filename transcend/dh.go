@@ -0,0 +1,280 @@
+package transcend
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// The ways SaaS contexts can be registered with sombra
+const (
+	// Encrypt the SaaS context end to end when sombra supports it
+	saasContextEncryptionAuto = "auto"
+	// Fail instead of sending SaaS contexts that only TLS protects
+	saasContextEncryptionRequired = "required"
+	// Always send SaaS contexts the legacy way
+	saasContextEncryptionDisabled = "disabled"
+)
+
+// The routes and message format below have not been checked against a recording of sombra and the admin
+// dashboard, only against the fake in the tests. With saas_context_encryption set to "auto", the legacy flow is
+// only used when sombra answers that it has no public key route. Any other failure to fetch the key, like a
+// network, TLS or proxy error, fails the connection instead, as it could just as well be an attempt to have the
+// SaaS context sent without end to end encryption.
+const (
+	sombraDHPublicKeyPath = "/v1/dh/public-key"
+	sombraRegisterSaas    = "/v1/register-saas"
+
+	dhKeyInfo = "transcend-saas-context"
+	// Bound to the ciphertexts so that a request cannot be replayed as a response
+	dhRequestData  = "register-saas-request"
+	dhResponseData = "register-saas-response"
+)
+
+// sombraDHUnsupportedError is returned when sombra answers that it does not serve a public key for encrypting
+// SaaS contexts
+type sombraDHUnsupportedError struct {
+	statusCode int
+}
+
+func (e *sombraDHUnsupportedError) Error() string {
+	return fmt.Sprintf("received a %d response from sombra when fetching its public key", e.statusCode)
+}
+
+// dhSession holds the key agreed between the provider and sombra for registering one SaaS context
+type dhSession struct {
+	publicKey []byte
+	aead      cipher.AEAD
+}
+
+// newDHSession generates an ephemeral key pair and derives the key shared with the holder of sombraPublicKey.
+func newDHSession(sombraPublicKey []byte) (*dhSession, error) {
+	privateKey := make([]byte, curve25519.ScalarSize)
+	if _, err := io.ReadFull(rand.Reader, privateKey); err != nil {
+		return nil, err
+	}
+	publicKey, err := curve25519.X25519(privateKey, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := dhAEAD(privateKey, sombraPublicKey, publicKey, sombraPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sombra public key: %w", err)
+	}
+	return &dhSession{publicKey: publicKey, aead: aead}, nil
+}
+
+// dhAEAD derives the cipher shared by the provider and sombra from either side's private key and the other side's
+// public key.
+func dhAEAD(privateKey []byte, peerPublicKey []byte, providerPublicKey []byte, sombraPublicKey []byte) (cipher.AEAD, error) {
+	sharedSecret, err := curve25519.X25519(privateKey, peerPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	salt := append(append([]byte{}, providerPublicKey...), sombraPublicKey...)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, salt, []byte(dhKeyInfo)), key); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *dhSession) seal(plaintext []byte, additionalData string) (nonce []byte, ciphertext []byte, err error) {
+	nonce = make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, nil, err
+	}
+	return nonce, s.aead.Seal(nil, nonce, plaintext, []byte(additionalData)), nil
+}
+
+func (s *dhSession) open(nonce []byte, ciphertext []byte, additionalData string) ([]byte, error) {
+	if len(nonce) != s.aead.NonceSize() {
+		return nil, errors.New("invalid nonce")
+	}
+	return s.aead.Open(nil, nonce, ciphertext, []byte(additionalData))
+}
+
+// dhEncryptedMessage is a payload encrypted with the key of a dhSession
+type dhEncryptedMessage struct {
+	PublicKey  string `json:"publicKey,omitempty"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext,omitempty"`
+}
+
+// parseSombraPublicKey decodes a base64 encoded X25519 public key of sombra.
+func parseSombraPublicKey(encoded string) ([]byte, error) {
+	publicKey, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(publicKey) != curve25519.PointSize {
+		return nil, errors.New("expected a base64 encoded X25519 public key")
+	}
+	return publicKey, nil
+}
+
+// sombraDHPublicKey fetches the public key sombra uses to agree on the key of encrypted SaaS contexts. Nothing
+// authenticates the key beyond the TLS connection it is fetched over, which is why it can be pinned with the
+// sombra_public_key of the provider instead.
+func (c *Client) sombraDHPublicKey(ctx context.Context, sombraURL string) ([]byte, error) {
+	value, err := c.cache.get(ctx, sombraDHCacheKey+sombraURL, func(ctx context.Context) (interface{}, error) {
+		endpoint, err := url.JoinPath(sombraURL, sombraDHPublicKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		response, err := c.sombraClient.Do(request)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+		// Sombras that predate the route answer that it does not exist
+		if response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusNotImplemented {
+			return nil, &sombraDHUnsupportedError{statusCode: response.StatusCode}
+		}
+		if response.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("received a %d response from sombra when fetching its public key", response.StatusCode)
+		}
+
+		var body struct {
+			PublicKey string `json:"publicKey"`
+		}
+		if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("could not read the public key of sombra: %w", err)
+		}
+		publicKey, err := parseSombraPublicKey(body.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("sombra returned an invalid public key: %w", err)
+		}
+		return publicKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.([]byte), nil
+}
+
+// registerSaasContext has sombra encrypt the secrets of a SaaS context, returning the presigned SaaS context to pass
+// to reconnectDataSilo along with its dhEncrypted argument. When sombra supports it, the SaaS context is encrypted
+// with a key agreed with sombra, so that neither the secrets nor the presigned context can be read by anything
+// in between, including the backend.
+func (c *Client) registerSaasContext(ctx context.Context, sombraURL string, contextJSON []byte) (presignedSaasContext string, dhEncrypted string, err error) {
+	sombraPublicKey := c.sombraPublicKey
+	if c.saasContextEncryption == saasContextEncryptionDisabled {
+		sombraPublicKey = nil
+	} else if sombraPublicKey == nil {
+		sombraPublicKey, err = c.sombraDHPublicKey(ctx, sombraURL)
+		var unsupported *sombraDHUnsupportedError
+		switch {
+		case err == nil:
+		case errors.As(err, &unsupported) && c.saasContextEncryption == saasContextEncryptionAuto:
+			tflog.Warn(ctx, "Registering the SaaS context without end to end encryption, as sombra does not support it", map[string]interface{}{
+				"sombra_url": sombraURL,
+				"error":      err.Error(),
+			})
+		case errors.As(err, &unsupported):
+			return "", "", fmt.Errorf("the sombra at %s does not support encrypted SaaS contexts, which saas_context_encryption requires: %w. Upgrade sombra or set saas_context_encryption to \"auto\"", sombraURL, err)
+		default:
+			return "", "", fmt.Errorf("could not fetch the public key of the sombra at %s to encrypt the SaaS context: %w. Set saas_context_encryption to \"disabled\" to send it without end to end encryption", sombraURL, err)
+		}
+	}
+	if sombraPublicKey == nil {
+		presigned, err := c.postSaasContext(ctx, sombraURL, contextJSON)
+		return string(presigned), "", err
+	}
+
+	session, err := newDHSession(sombraPublicKey)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, ciphertext, err := session.seal(contextJSON, dhRequestData)
+	if err != nil {
+		return "", "", err
+	}
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"dhEncrypted": dhEncryptedMessage{
+			PublicKey:  base64.StdEncoding.EncodeToString(session.publicKey),
+			Nonce:      base64.StdEncoding.EncodeToString(nonce),
+			Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+		},
+	})
+	if err != nil {
+		return "", "", err
+	}
+	responseBody, err := c.postSaasContext(ctx, sombraURL, requestBody)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Only the holder of the private key matching sombraPublicKey can produce a response that decrypts with the
+	// agreed key. This authenticates sombra as far as that key is, i.e. by TLS unless sombra_public_key is set.
+	var response dhEncryptedMessage
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		return "", "", fmt.Errorf("could not read the encrypted SaaS context returned by sombra: %w", err)
+	}
+	responseNonce, nonceErr := base64.StdEncoding.DecodeString(response.Nonce)
+	responseCiphertext, ciphertextErr := base64.StdEncoding.DecodeString(response.Ciphertext)
+	if nonceErr != nil || ciphertextErr != nil {
+		return "", "", errors.New("could not read the encrypted SaaS context returned by sombra")
+	}
+	presigned, err := session.open(responseNonce, responseCiphertext, dhResponseData)
+	if err != nil || len(presigned) == 0 {
+		return "", "", errors.New("could not verify the SaaS context returned by sombra: it was not encrypted with the agreed key")
+	}
+
+	dhEncryptedJSON, err := json.Marshal(dhEncryptedMessage{
+		PublicKey: base64.StdEncoding.EncodeToString(session.publicKey),
+		Nonce:     response.Nonce,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return response.Ciphertext, string(dhEncryptedJSON), nil
+}
+
+func (c *Client) postSaasContext(ctx context.Context, sombraURL string, body []byte) ([]byte, error) {
+	endpoint, err := url.JoinPath(sombraURL, sombraRegisterSaas)
+	if err != nil {
+		return nil, fmt.Errorf("could not construct the sombra url for the register saas route: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := c.sombraClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received a %d response from sombra. Is the internal sombra down?", response.StatusCode)
+	}
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the response of sombra: %w", err)
+	}
+	if strings.Contains(string(responseBody), "Client error") {
+		return nil, errors.New(string(responseBody))
+	}
+	return responseBody, nil
+}
@@ -0,0 +1,36 @@
+package transcend
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/curve25519"
+)
+
+func TestDHSessionAgreesWithSombra(t *testing.T) {
+	sombraPrivateKey := make([]byte, curve25519.ScalarSize)
+	rand.Read(sombraPrivateKey)
+	sombraPublicKey, _ := curve25519.X25519(sombraPrivateKey, curve25519.Basepoint)
+
+	provider, err := newDHSession(sombraPublicKey)
+	assert.Nil(t, err)
+	sombraAEAD, err := dhAEAD(sombraPrivateKey, provider.publicKey, provider.publicKey, sombraPublicKey)
+	assert.Nil(t, err)
+	sombra := &dhSession{publicKey: provider.publicKey, aead: sombraAEAD}
+
+	nonce, ciphertext, err := provider.seal([]byte(`{"secretMap":{"apiKey":"dd-api-key"}}`), dhRequestData)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(ciphertext), "dd-api-key")
+	plaintext, err := sombra.open(nonce, ciphertext, dhRequestData)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"secretMap":{"apiKey":"dd-api-key"}}`, string(plaintext))
+
+	_, err = sombra.open(nonce, ciphertext, dhResponseData)
+	assert.Error(t, err, "a request should not be accepted as a response")
+
+	other, err := newDHSession(sombraPublicKey)
+	assert.Nil(t, err)
+	_, err = other.open(nonce, ciphertext, dhRequestData)
+	assert.Error(t, err, "every session should agree on a different key")
+}
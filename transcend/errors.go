@@ -0,0 +1,256 @@
+package transcend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/go-cty/cty"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	graphql "github.com/hasura/go-graphql-client"
+)
+
+// GraphQLErrorClass groups backend errors by how the provider should react to them.
+type GraphQLErrorClass string
+
+const (
+	ErrorClassNotFound         GraphQLErrorClass = "not-found"
+	ErrorClassPermissionDenied GraphQLErrorClass = "permission-denied"
+	ErrorClassValidation       GraphQLErrorClass = "validation"
+	ErrorClassRateLimited      GraphQLErrorClass = "rate-limited"
+	ErrorClassUnknown          GraphQLErrorClass = "unknown"
+)
+
+// Maps the `extensions.code` values returned by the backend (and by the GraphQL server itself)
+// to the class of error they represent.
+var graphQLErrorCodeClasses = map[string]GraphQLErrorClass{
+	"NOT_FOUND":                 ErrorClassNotFound,
+	"RESOURCE_NOT_FOUND":        ErrorClassNotFound,
+	"FORBIDDEN":                 ErrorClassPermissionDenied,
+	"UNAUTHENTICATED":           ErrorClassPermissionDenied,
+	"UNAUTHORIZED":              ErrorClassPermissionDenied,
+	"PERMISSION_DENIED":         ErrorClassPermissionDenied,
+	"BAD_USER_INPUT":            ErrorClassValidation,
+	"GRAPHQL_VALIDATION_FAILED": ErrorClassValidation,
+	"VALIDATION_ERROR":          ErrorClassValidation,
+	"INVALID_INPUT":             ErrorClassValidation,
+	"RATE_LIMITED":              ErrorClassRateLimited,
+	"TOO_MANY_REQUESTS":         ErrorClassRateLimited,
+}
+
+var httpStatusClasses = map[int]GraphQLErrorClass{
+	400: ErrorClassValidation,
+	401: ErrorClassPermissionDenied,
+	403: ErrorClassPermissionDenied,
+	404: ErrorClassNotFound,
+	422: ErrorClassValidation,
+	429: ErrorClassRateLimited,
+}
+
+// GraphQLError is a single error returned by the backend, classified from its extensions.
+type GraphQLError struct {
+	Class   GraphQLErrorClass
+	Code    string
+	Message string
+	// The input field the backend blamed for the error, e.g. "notifyEmailAddress", if any
+	Field string
+}
+
+func (e *GraphQLError) Error() string {
+	return fmt.Sprintf("%s error: %s", e.Class, e.Message)
+}
+
+// AttributePath converts the input field blamed by the backend into the matching Terraform attribute.
+func (e *GraphQLError) AttributePath() cty.Path {
+	if e.Field == "" {
+		return nil
+	}
+	return cty.GetAttrPath(e.attributeName())
+}
+
+func (e *GraphQLError) attributeName() string {
+	segments := strings.Split(e.Field, ".")
+	return toSnakeCase(segments[len(segments)-1])
+}
+
+// classifyGraphQLErrors converts an error returned by the GraphQL client into typed errors.
+func classifyGraphQLErrors(err error) []*GraphQLError {
+	if err == nil {
+		return nil
+	}
+	var errs graphql.Errors
+	if !errors.As(err, &errs) {
+		return []*GraphQLError{{Class: ErrorClassUnknown, Message: err.Error()}}
+	}
+
+	classified := []*GraphQLError{}
+	for _, graphQLErr := range errs {
+		classified = append(classified, classifyGraphQLError(graphQLErr)...)
+	}
+	return classified
+}
+
+func classifyGraphQLError(err graphql.Error) []*GraphQLError {
+	code := extensionString(err.Extensions, "code")
+
+	// The client reports non-200 responses as a request error with the status and body in the message.
+	// The body usually still holds the backend's GraphQL errors, which are more useful than the status.
+	if code == graphql.ErrRequestError {
+		if classified := classifyHTTPError(err.Message); classified != nil {
+			return classified
+		}
+	}
+
+	classified := &GraphQLError{
+		Class:   ErrorClassUnknown,
+		Code:    code,
+		Message: err.Message,
+		Field:   extensionField(err.Extensions),
+	}
+	if class, ok := graphQLErrorCodeClasses[strings.ToUpper(code)]; ok {
+		classified.Class = class
+	} else if class, ok := httpStatusClasses[extensionStatus(err.Extensions)]; ok {
+		classified.Class = class
+	}
+	return []*GraphQLError{classified}
+}
+
+func classifyHTTPError(message string) []*GraphQLError {
+	statusText, quotedBody, found := strings.Cut(message, "; body: ")
+	if !found {
+		return nil
+	}
+	status, err := strconv.Atoi(strings.SplitN(statusText, " ", 2)[0])
+	if err != nil {
+		return nil
+	}
+
+	var response struct {
+		Errors graphql.Errors `json:"errors"`
+	}
+	if body, err := strconv.Unquote(quotedBody); err == nil && json.Unmarshal([]byte(body), &response) == nil && len(response.Errors) > 0 {
+		classified := []*GraphQLError{}
+		for _, graphQLErr := range response.Errors {
+			for _, typedErr := range classifyGraphQLError(graphQLErr) {
+				if class, ok := httpStatusClasses[status]; ok && typedErr.Class == ErrorClassUnknown {
+					typedErr.Class = class
+				}
+				classified = append(classified, typedErr)
+			}
+		}
+		return classified
+	}
+
+	class, ok := httpStatusClasses[status]
+	if !ok {
+		class = ErrorClassUnknown
+	}
+	return []*GraphQLError{{Class: class, Code: graphql.ErrRequestError, Message: message}}
+}
+
+// isNotFoundError is true when every error returned by the backend says that the requested object does not exist.
+func isNotFoundError(err error) bool {
+	classified := classifyGraphQLErrors(err)
+	if len(classified) == 0 {
+		return false
+	}
+	for _, typedErr := range classified {
+		if typedErr.Class != ErrorClassNotFound {
+			return false
+		}
+	}
+	return true
+}
+
+// graphQLErrorDiagnostics builds one diagnostic per backend error, labelled with the error's class
+// and pointing at the offending attribute when the backend names one.
+func graphQLErrorDiagnostics(summary string, err error) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, typedErr := range classifyGraphQLErrors(err) {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       summary,
+			Detail:        typedErr.Error(),
+			AttributePath: typedErr.AttributePath(),
+		})
+	}
+	return diags
+}
+
+func extensionString(extensions map[string]interface{}, key string) string {
+	value, _ := extensions[key].(string)
+	return value
+}
+
+// The backend names the offending input either as a single field or as a list of invalid arguments
+func extensionField(extensions map[string]interface{}) string {
+	if field := extensionString(extensions, "field"); field != "" {
+		return field
+	}
+	for _, key := range []string{"fields", "invalidArgs"} {
+		if fields, ok := extensions[key].([]interface{}); ok && len(fields) > 0 {
+			if field, ok := fields[0].(string); ok {
+				return field
+			}
+		}
+	}
+	return ""
+}
+
+func extensionStatus(extensions map[string]interface{}) int {
+	if httpExtension, ok := extensions["http"].(map[string]interface{}); ok {
+		if status, ok := httpExtension["status"].(float64); ok {
+			return int(status)
+		}
+	}
+	if status, ok := extensions["statusCode"].(float64); ok {
+		return int(status)
+	}
+	return 0
+}
+
+func toSnakeCase(name string) string {
+	var builder strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				builder.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// readErrorDiagnostics handles an error returned while refreshing a resource. Objects that were deleted
+// outside of Terraform are removed from the state so that the next plan proposes to recreate them.
+func readErrorDiagnostics(ctx context.Context, d *schema.ResourceData, summary string, err error) diag.Diagnostics {
+	if isNotFoundError(err) {
+		tflog.Warn(ctx, summary+": the object no longer exists, removing it from state", map[string]interface{}{"id": d.Id()})
+		d.SetId("")
+		return nil
+	}
+	return graphQLErrorDiagnostics(summary, err)
+}
+
+// frameworkErrorDiagnostics is graphQLErrorDiagnostics for the resources served by terraform-plugin-framework.
+func frameworkErrorDiagnostics(summary string, err error) fwdiag.Diagnostics {
+	var diags fwdiag.Diagnostics
+	for _, typedErr := range classifyGraphQLErrors(err) {
+		if typedErr.Field == "" {
+			diags.AddError(summary, typedErr.Error())
+			continue
+		}
+		diags.AddAttributeError(path.Root(typedErr.attributeName()), summary, typedErr.Error())
+	}
+	return diags
+}
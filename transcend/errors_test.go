@@ -0,0 +1,99 @@
+package transcend
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	graphql "github.com/hasura/go-graphql-client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyGraphQLErrors(t *testing.T) {
+	classified := classifyGraphQLErrors(graphql.Errors{
+		{Message: "Data silo not found", Extensions: map[string]interface{}{"code": "NOT_FOUND"}},
+		{Message: "Missing scope", Extensions: map[string]interface{}{"code": "FORBIDDEN"}},
+		{Message: "Invalid email", Extensions: map[string]interface{}{"code": "BAD_USER_INPUT", "field": "input.notifyEmailAddress"}},
+		{Message: "Slow down", Extensions: map[string]interface{}{"http": map[string]interface{}{"status": float64(429)}}},
+		{Message: "Something broke"},
+	})
+
+	assert.Len(t, classified, 5)
+	assert.Equal(t, ErrorClassNotFound, classified[0].Class)
+	assert.Equal(t, ErrorClassPermissionDenied, classified[1].Class)
+	assert.Equal(t, ErrorClassValidation, classified[2].Class)
+	assert.Equal(t, cty.GetAttrPath("notify_email_address"), classified[2].AttributePath())
+	assert.Equal(t, ErrorClassRateLimited, classified[3].Class)
+	assert.Equal(t, ErrorClassUnknown, classified[4].Class)
+	assert.Nil(t, classified[4].AttributePath())
+}
+
+func TestClassifyNon200Responses(t *testing.T) {
+	withBody := classifyGraphQLErrors(graphql.Errors{{
+		Message:    `400 Bad Request; body: "{\"errors\":[{\"message\":\"Bad title\",\"extensions\":{\"invalidArgs\":[\"title\"]}}]}"`,
+		Extensions: map[string]interface{}{"code": graphql.ErrRequestError},
+	}})
+	assert.Len(t, withBody, 1)
+	assert.Equal(t, ErrorClassValidation, withBody[0].Class)
+	assert.Equal(t, "Bad title", withBody[0].Message)
+	assert.Equal(t, cty.GetAttrPath("title"), withBody[0].AttributePath())
+
+	withoutBody := classifyGraphQLErrors(graphql.Errors{{
+		Message:    `403 Forbidden; body: "nope"`,
+		Extensions: map[string]interface{}{"code": graphql.ErrRequestError},
+	}})
+	assert.Len(t, withoutBody, 1)
+	assert.Equal(t, ErrorClassPermissionDenied, withoutBody[0].Class)
+}
+
+func TestIsNotFoundError(t *testing.T) {
+	notFound := graphql.Errors{{Message: "gone", Extensions: map[string]interface{}{"code": "NOT_FOUND"}}}
+	assert.True(t, isNotFoundError(notFound))
+	assert.False(t, isNotFoundError(append(notFound, graphql.Error{Message: "boom"})))
+	assert.False(t, isNotFoundError(errors.New("connection reset")))
+	assert.False(t, isNotFoundError(nil))
+}
+
+func TestUnitErrorDiagnosticsCarryClassAndAttribute(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.setFailure("CreateApiKey", &fakeGraphQLError{
+		Message:    "Title is already in use",
+		Extensions: map[string]interface{}{"code": "BAD_USER_INPUT", "field": "title"},
+	})
+	backend.setFailure("CreateEnricher", &fakeGraphQLError{
+		Message:    "API key is missing the manageDataMap scope",
+		Extensions: map[string]interface{}{"code": "FORBIDDEN"},
+	})
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_api_key" "key" {
+  title  = "taken"
+  scopes = ["connectDataSilos"]
+}
+`,
+				// The attribute path makes terraform point at the offending line of configuration
+				ExpectError: regexp.MustCompile(`(?s)title\s+= "taken".*validation error: Title is already in use`),
+			},
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_enricher" "enricher" {
+  title              = "forbidden"
+  description        = "looks up phone numbers"
+  url                = "https://example.acme.com/transcend-enrichment-webhook"
+  type               = "SERVER"
+  input_identifier   = "identifier-email"
+  output_identifiers = ["identifier-phone"]
+  actions            = ["ACCESS"]
+}
+`,
+				ExpectError: regexp.MustCompile("permission-denied error: API key is missing the manageDataMap scope"),
+			},
+		},
+	})
+}
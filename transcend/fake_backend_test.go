@@ -0,0 +1,1185 @@
+package transcend
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"golang.org/x/crypto/curve25519"
+)
+
+// fakeBackend is an in-memory stand in for the Transcend GraphQL API and sombra.
+// It only implements the operations that the provider sends, and only returns
+// the fields that each operation selects, just like the real backend.
+type fakeBackend struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu     sync.Mutex
+	nextID int
+
+	dataSilos             map[string]map[string]interface{}
+	plugins               map[string]map[string]interface{}
+	discoClassScanConfigs map[string]map[string]interface{}
+	dataPoints            map[string]map[string]interface{}
+	subDataPoints         map[string][]interface{}
+	enrichers             map[string]map[string]interface{}
+	apiKeys               map[string]map[string]interface{}
+	identifiers           []map[string]interface{}
+	sombras               []map[string]interface{}
+	catalogs              map[string]map[string]interface{}
+
+	// Artificial latency added before answering an operation, keyed by operation name
+	delays map[string]time.Duration
+	// Errors returned instead of resolving an operation, keyed by operation name
+	failures map[string]*fakeGraphQLError
+
+	// Every operation name received, in order
+	operations []string
+	// The Authorization header of every request, in order
+	authorizations []string
+	// The bodies of every request made to sombra's register-saas route, decrypted when they were encrypted
+	registeredSaasContexts []map[string]interface{}
+
+	// The key pair sombra uses for encrypted SaaS contexts, and the sessions agreed with the provider
+	sombraPrivateKey []byte
+	sombraPublicKey  []byte
+	dhSessions       map[string]*dhSession
+	// The status that the public key route of sombra answers with instead of the key, like a sombra without
+	// support for encrypted SaaS contexts or a proxy in front of it would
+	dhPublicKeyStatus int
+	// Whether the public key route drops the connection instead of answering
+	dhPublicKeyHangUp bool
+	// Makes sombra's encrypted responses fail verification, as if they were tampered with on the way
+	tamperDHResponses bool
+	// The connection states that a data silo goes through after it is connected, one per read of the data silo.
+	// It is connected right away when empty.
+	connectionStates []string
+	// The error reported by the backend once a data silo reaches an error state
+	connectionError string
+	// The types of the plugins that every new data silo comes with
+	pluginTypes []string
+	// The number of lookups of the plugins that a run of a plugin takes to finish, and the error it fails with
+	pluginRunPolls int
+	pluginRunError string
+	// The number of plugin runs that finished
+	pluginRuns int
+}
+
+type fakeResolver func(f *fakeBackend, args map[string]interface{}) (interface{}, error)
+
+var fakeResolvers = map[string]fakeResolver{
+	"organization":               (*fakeBackend).resolveOrganization,
+	"sombras":                    (*fakeBackend).resolveSombras,
+	"catalog":                    (*fakeBackend).resolveCatalog,
+	"identifiers":                (*fakeBackend).resolveIdentifiers,
+	"dataSilo":                   (*fakeBackend).resolveDataSilo,
+	"dataSilos":                  (*fakeBackend).resolveDataSilos,
+	"createDataSilos":            (*fakeBackend).resolveCreateDataSilos,
+	"updateDataSilos":            (*fakeBackend).resolveUpdateDataSilos,
+	"deleteDataSilos":            (*fakeBackend).resolveDeleteDataSilos,
+	"reconnectDataSilo":          (*fakeBackend).resolveReconnectDataSilo,
+	"plugins":                    (*fakeBackend).resolvePlugins,
+	"updateDataSiloPlugin":       (*fakeBackend).resolveUpdateDataSiloPlugin,
+	"discoClassScanConfig":       (*fakeBackend).resolveDiscoClassScanConfig,
+	"updateDiscoClassScanConfig": (*fakeBackend).resolveUpdateDiscoClassScanConfig,
+	"dataPoints":                 (*fakeBackend).resolveDataPoints,
+	"subDataPoints":              (*fakeBackend).resolveSubDataPoints,
+	"updateOrCreateDataPoint":    (*fakeBackend).resolveUpdateOrCreateDataPoint,
+	"deleteDataPoints":           (*fakeBackend).resolveDeleteDataPoints,
+	"enricher":                   (*fakeBackend).resolveEnricher,
+	"createEnricher":             (*fakeBackend).resolveCreateEnricher,
+	"updateEnricher":             (*fakeBackend).resolveUpdateEnricher,
+	"deleteEnricher":             (*fakeBackend).resolveDeleteEnricher,
+	"apiKey":                     (*fakeBackend).resolveAPIKey,
+	"createApiKey":               (*fakeBackend).resolveCreateAPIKey,
+	"updateApiKey":               (*fakeBackend).resolveUpdateAPIKey,
+	"deleteApiKey":               (*fakeBackend).resolveDeleteAPIKey,
+}
+
+var fakePluginTypes = []string{"SCHEMA_DISCOVERY", "CONTENT_CLASSIFICATION", "DATA_SILO_DISCOVERY"}
+
+func newFakeBackend(t *testing.T) *fakeBackend {
+	f := &fakeBackend{
+		t:                     t,
+		dataSilos:             map[string]map[string]interface{}{},
+		plugins:               map[string]map[string]interface{}{},
+		discoClassScanConfigs: map[string]map[string]interface{}{},
+		dataPoints:            map[string]map[string]interface{}{},
+		subDataPoints:         map[string][]interface{}{},
+		enrichers:             map[string]map[string]interface{}{},
+		apiKeys:               map[string]map[string]interface{}{},
+		catalogs:              map[string]map[string]interface{}{},
+		delays:                map[string]time.Duration{},
+		failures:              map[string]*fakeGraphQLError{},
+		dhSessions:            map[string]*dhSession{},
+		pluginTypes:           fakePluginTypes,
+	}
+
+	f.sombraPrivateKey = make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(f.sombraPrivateKey); err != nil {
+		t.Fatal(err)
+	}
+	f.sombraPublicKey, _ = curve25519.X25519(f.sombraPrivateKey, curve25519.Basepoint)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", f.handleGraphQL)
+	mux.HandleFunc("/v1/register-saas", f.handleRegisterSaas)
+	mux.HandleFunc("/v1/dh/public-key", f.handleDHPublicKey)
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+
+	f.identifiers = []map[string]interface{}{
+		{"id": "identifier-email", "name": "email"},
+		{"id": "identifier-phone", "name": "phone"},
+		{"id": "identifier-core", "name": "coreIdentifier"},
+	}
+	f.sombras = []map[string]interface{}{
+		{"id": "sombra-primary", "url": f.server.URL, "customerUrl": f.server.URL, "hostedMethod": "TRANSCEND_HOSTED"},
+	}
+	return f
+}
+
+// providerConfig returns the provider block pointing the provider at this fake
+func (f *fakeBackend) providerConfig() string {
+	return fmt.Sprintf(`
+provider "transcend" {
+  url = %q
+  key = "fake-api-key"
+}
+`, f.server.URL)
+}
+
+// setCatalog registers the catalog entry returned for the given integration name.
+func (f *fakeBackend) setCatalog(integrationName string, catalog map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.catalogs[integrationName] = catalog
+}
+
+// setDelay makes the fake wait before answering the named operation, or until the
+// caller gives up on the request.
+func (f *fakeBackend) setDelay(operationName string, delay time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delays[operationName] = delay
+}
+
+// setFailure makes every later request for the named operation fail with the given error.
+func (f *fakeBackend) setFailure(operationName string, err *fakeGraphQLError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures[operationName] = err
+}
+
+// clearFailure makes the named operation succeed again.
+func (f *fakeBackend) clearFailure(operationName string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.failures, operationName)
+}
+
+// deleteOutsideTerraform removes an object from the fake, like a user deleting it in the admin dashboard.
+func (f *fakeBackend) deleteOutsideTerraform(store map[string]map[string]interface{}, id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(store, id)
+}
+
+// deleteDataSiloOutsideTerraform removes a data silo and its plugins from the fake.
+func (f *fakeBackend) deleteDataSiloOutsideTerraform(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.dataSilos, id)
+	for pluginID, plugin := range f.plugins {
+		if fakeString(fakeMap(plugin["dataSilo"])["id"]) == id {
+			delete(f.plugins, pluginID)
+		}
+	}
+}
+
+// getDataSilo returns a copy of a data silo, as the handlers keep changing the stored one.
+func (f *fakeBackend) getDataSilo(id string) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	silo, ok := f.dataSilos[id]
+	if !ok {
+		return nil
+	}
+	return fakeCopy(silo).(map[string]interface{})
+}
+
+// getDataSiloIDs returns the IDs of every data silo of the fake.
+func (f *fakeBackend) getDataSiloIDs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := make([]string, 0, len(f.dataSilos))
+	for id := range f.dataSilos {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// updateDataSilo changes a data silo, like a user editing it in the admin dashboard.
+func (f *fakeBackend) updateDataSilo(id string, update func(silo map[string]interface{})) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	update(f.dataSilos[id])
+}
+
+// getRegisteredSaasContexts returns a copy of the SaaS contexts registered with sombra so far.
+func (f *fakeBackend) getRegisteredSaasContexts() []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	contexts := make([]map[string]interface{}, len(f.registeredSaasContexts))
+	for i, context := range f.registeredSaasContexts {
+		contexts[i] = fakeCopy(context).(map[string]interface{})
+	}
+	return contexts
+}
+
+// fakeCopy deeply copies the maps and lists of a stored object.
+func fakeCopy(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			copied[key] = fakeCopy(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, item := range value {
+			copied[i] = fakeCopy(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
+
+func (f *fakeBackend) countOperations(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, operation := range f.operations {
+		if operation == name {
+			count++
+		}
+	}
+	return count
+}
+
+// checkDestroyed verifies that every resource that can be deleted was removed from the fake
+func (f *fakeBackend) checkDestroyed(s *terraform.State) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	stores := map[string]map[string]map[string]interface{}{
+		"transcend_api_key":    f.apiKeys,
+		"transcend_enricher":   f.enrichers,
+		"transcend_data_point": f.dataPoints,
+		"transcend_data_silo":  f.dataSilos,
+	}
+	for _, rs := range s.RootModule().Resources {
+		store, ok := stores[rs.Type]
+		if !ok {
+			continue
+		}
+		if _, exists := store[rs.Primary.ID]; exists {
+			return fmt.Errorf("%s %s still exists", rs.Type, rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+func (f *fakeBackend) newID(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s-%d", prefix, f.nextID)
+}
+
+type fakeGraphQLError struct {
+	Message    string                 `json:"message"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *fakeGraphQLError) Error() string {
+	return e.Message
+}
+
+func fakeNotFound(kind string, id interface{}) error {
+	return &fakeGraphQLError{
+		Message:    fmt.Sprintf("%s with id %v not found", kind, id),
+		Extensions: map[string]interface{}{"code": "NOT_FOUND"},
+	}
+}
+
+func (f *fakeBackend) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	document, parseErr := parser.ParseQuery(&ast.Source{Input: body.Query})
+	if parseErr != nil || len(document.Operations) != 1 {
+		http.Error(w, fmt.Sprintf("could not parse query %q: %v", body.Query, parseErr), http.StatusBadRequest)
+		return
+	}
+	operation := document.Operations[0]
+
+	f.mu.Lock()
+	delay := f.delays[operation.Name]
+	f.mu.Unlock()
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.operations = append(f.operations, operation.Name)
+	f.authorizations = append(f.authorizations, r.Header.Get("Authorization"))
+
+	w.Header().Set("Content-Type", "application/json")
+	if failure, ok := f.failures[operation.Name]; ok {
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": nil, "errors": []interface{}{failure}})
+		return
+	}
+
+	data := map[string]interface{}{}
+	var errs []interface{}
+	for _, selection := range operation.SelectionSet {
+		field, ok := selection.(*ast.Field)
+		if !ok {
+			continue
+		}
+		resolver, ok := fakeResolvers[field.Name]
+		if !ok {
+			f.t.Errorf("fake backend does not implement %s (operation %s)", field.Name, operation.Name)
+			errs = append(errs, &fakeGraphQLError{Message: "Cannot query field " + field.Name})
+			continue
+		}
+		args := map[string]interface{}{}
+		for _, argument := range field.Arguments {
+			value, err := argument.Value.Value(body.Variables)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			args[argument.Name] = value
+		}
+		result, err := resolver(f, args)
+		if err != nil {
+			errs = append(errs, err)
+			data[field.Alias] = nil
+			continue
+		}
+		data[field.Alias] = project(result, field.SelectionSet)
+	}
+
+	response := map[string]interface{}{"data": data}
+	if len(errs) > 0 {
+		response["errors"] = errs
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (f *fakeBackend) handleDHPublicKey(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.operations = append(f.operations, "DHPublicKey")
+	if f.dhPublicKeyHangUp {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+		return
+	}
+	if f.dhPublicKeyStatus != 0 {
+		http.Error(w, http.StatusText(f.dhPublicKeyStatus), f.dhPublicKeyStatus)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"publicKey": base64.StdEncoding.EncodeToString(f.sombraPublicKey)})
+}
+
+func (f *fakeBackend) handleRegisterSaas(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Client error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.operations = append(f.operations, "RegisterSaas")
+	presigned := fmt.Sprintf("presigned-saas-context-%d", len(f.registeredSaasContexts)+1)
+
+	encrypted, ok := body["dhEncrypted"]
+	if !ok {
+		f.registeredSaasContexts = append(f.registeredSaasContexts, body)
+		fmt.Fprint(w, presigned)
+		return
+	}
+
+	// Agree on the key with the provider, decrypt the SaaS context and encrypt the presigned context in return
+	message := fakeMap(encrypted)
+	providerPublicKey, _ := base64.StdEncoding.DecodeString(fakeString(message["publicKey"]))
+	aead, err := dhAEAD(f.sombraPrivateKey, providerPublicKey, providerPublicKey, f.sombraPublicKey)
+	if err != nil {
+		http.Error(w, "Client error: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	session := &dhSession{publicKey: providerPublicKey, aead: aead}
+	nonce, _ := base64.StdEncoding.DecodeString(fakeString(message["nonce"]))
+	ciphertext, _ := base64.StdEncoding.DecodeString(fakeString(message["ciphertext"]))
+	plaintext, err := session.open(nonce, ciphertext, dhRequestData)
+	if err != nil {
+		http.Error(w, "Client error: could not decrypt the SaaS context", http.StatusBadRequest)
+		return
+	}
+	var saasContext map[string]interface{}
+	json.Unmarshal(plaintext, &saasContext)
+	f.registeredSaasContexts = append(f.registeredSaasContexts, saasContext)
+	f.dhSessions[fakeString(message["publicKey"])] = session
+
+	responseNonce, responseCiphertext, _ := session.seal([]byte(presigned), dhResponseData)
+	if f.tamperDHResponses {
+		responseCiphertext[0] ^= 0xff
+	}
+	json.NewEncoder(w).Encode(dhEncryptedMessage{
+		Nonce:      base64.StdEncoding.EncodeToString(responseNonce),
+		Ciphertext: base64.StdEncoding.EncodeToString(responseCiphertext),
+	})
+}
+
+// project copies only the selected fields out of a resolved value
+func project(value interface{}, selectionSet ast.SelectionSet) interface{} {
+	if len(selectionSet) == 0 || value == nil {
+		return value
+	}
+	switch typed := value.(type) {
+	case []interface{}:
+		ret := make([]interface{}, len(typed))
+		for i, item := range typed {
+			ret[i] = project(item, selectionSet)
+		}
+		return ret
+	case []map[string]interface{}:
+		ret := make([]interface{}, len(typed))
+		for i, item := range typed {
+			ret[i] = project(item, selectionSet)
+		}
+		return ret
+	case map[string]interface{}:
+		ret := map[string]interface{}{}
+		for _, selection := range selectionSet {
+			if field, ok := selection.(*ast.Field); ok {
+				ret[field.Alias] = project(typed[field.Name], field.SelectionSet)
+			}
+		}
+		return ret
+	}
+	return value
+}
+
+func fakeString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+func fakeStrings(value interface{}) []string {
+	var ret []string
+	switch typed := value.(type) {
+	case []interface{}:
+		for _, item := range typed {
+			ret = append(ret, fakeString(item))
+		}
+	case string:
+		ret = append(ret, typed)
+	}
+	return ret
+}
+
+func fakeMap(value interface{}) map[string]interface{} {
+	if typed, ok := value.(map[string]interface{}); ok {
+		return typed
+	}
+	return map[string]interface{}{}
+}
+
+func fakeList(value interface{}) []interface{} {
+	if typed, ok := value.([]interface{}); ok {
+		return typed
+	}
+	return []interface{}{}
+}
+
+func fakeIDObjects(ids []string) []interface{} {
+	ret := make([]interface{}, len(ids))
+	for i, id := range ids {
+		ret[i] = map[string]interface{}{"id": id}
+	}
+	return ret
+}
+
+func fakeHeaders(value interface{}) []interface{} {
+	ret := []interface{}{}
+	for _, raw := range fakeList(value) {
+		header := fakeMap(raw)
+		ret = append(ret, map[string]interface{}{
+			"name":     header["name"],
+			"value":    header["value"],
+			"isSecret": header["isSecret"] == true,
+		})
+	}
+	return ret
+}
+
+func (f *fakeBackend) resolveOrganization(args map[string]interface{}) (interface{}, error) {
+	return map[string]interface{}{"sombra": f.sombras[0]}, nil
+}
+
+func (f *fakeBackend) resolveSombras(args map[string]interface{}) (interface{}, error) {
+	filterBy := fakeMap(args["filterBy"])
+	ids := fakeStrings(filterBy["ids"])
+	urls := fakeStrings(filterBy["urls"])
+	ret := []interface{}{}
+	for _, sombra := range f.sombras {
+		if len(ids) > 0 && !containsString(ids, fakeString(sombra["id"])) {
+			continue
+		}
+		if len(urls) > 0 && !containsString(urls, fakeString(sombra["url"])) {
+			continue
+		}
+		ret = append(ret, sombra)
+	}
+	return ret, nil
+}
+
+func (f *fakeBackend) resolveCatalog(args map[string]interface{}) (interface{}, error) {
+	integrationName := fakeString(fakeMap(args["input"])["integrationName"])
+	catalog, ok := f.catalogs[integrationName]
+	if !ok {
+		catalog = map[string]interface{}{
+			"integrationName":      integrationName,
+			"hasAvcFunctionality":  false,
+			"plaintextInformation": []interface{}{},
+			"supportedPlugins":     []interface{}{"SCHEMA_DISCOVERY", "CONTENT_CLASSIFICATION", "DATA_SILO_DISCOVERY"},
+			"integrationConfig": map[string]interface{}{
+				"configuredBaseHosts": map[string]interface{}{"PROD": []interface{}{"api." + integrationName + ".com"}},
+			},
+		}
+	}
+	return map[string]interface{}{"catalog": catalog}, nil
+}
+
+func (f *fakeBackend) resolveIdentifiers(args map[string]interface{}) (interface{}, error) {
+	text := fakeString(fakeMap(args["filterBy"])["text"])
+	nodes := []interface{}{}
+	for _, identifier := range f.identifiers {
+		if text == "" || strings.Contains(fakeString(identifier["name"]), text) {
+			nodes = append(nodes, identifier)
+		}
+	}
+	return map[string]interface{}{"nodes": nodes, "totalCount": len(nodes)}, nil
+}
+
+func (f *fakeBackend) findIdentifier(id string) (map[string]interface{}, bool) {
+	for _, identifier := range f.identifiers {
+		if fakeString(identifier["id"]) == id {
+			return identifier, true
+		}
+	}
+	return nil, false
+}
+
+func (f *fakeBackend) resolveDataSilo(args map[string]interface{}) (interface{}, error) {
+	silo, ok := f.dataSilos[fakeString(args["id"])]
+	if !ok {
+		return nil, fakeNotFound("DataSilo", args["id"])
+	}
+	if pending, ok := silo["pendingConnectionStates"].([]string); ok && len(pending) > 0 {
+		silo["connectionState"] = pending[0]
+		silo["pendingConnectionStates"] = pending[1:]
+		if pending[0] == "EXPIRED" || pending[0] == "PERMISSIONS_UPDATED" {
+			silo["connectionError"] = f.connectionError
+		}
+	}
+	return silo, nil
+}
+
+func (f *fakeBackend) resolveDataSilos(args map[string]interface{}) (interface{}, error) {
+	filterBy := fakeMap(args["filterBy"])
+	ids := fakeStrings(filterBy["ids"])
+	types := fakeStrings(filterBy["type"])
+	titles := fakeStrings(filterBy["titles"])
+	discoveredBy := fakeStrings(filterBy["discoveredBy"])
+
+	nodes := []interface{}{}
+	for _, id := range sortedKeys(f.dataSilos) {
+		silo := f.dataSilos[id]
+		if len(ids) > 0 && !containsString(ids, id) {
+			continue
+		}
+		if len(types) > 0 && !containsString(types, fakeString(silo["type"])) {
+			continue
+		}
+		if len(titles) > 0 && !containsString(titles, fakeString(silo["title"])) {
+			continue
+		}
+		if len(discoveredBy) > 0 && !containsString(discoveredBy, fakeString(silo["discoveredBy"])) {
+			continue
+		}
+		nodes = append(nodes, silo)
+	}
+	return map[string]interface{}{"nodes": nodes, "totalCount": len(nodes)}, nil
+}
+
+func (f *fakeBackend) resolveCreateDataSilos(args map[string]interface{}) (interface{}, error) {
+	created := []interface{}{}
+	for _, raw := range fakeList(args["input"]) {
+		input := fakeMap(raw)
+		id := f.newID("silo")
+		title := fakeString(input["title"])
+		if title == "" {
+			title = fakeString(input["name"])
+		}
+		silo := map[string]interface{}{
+			"id":                            id,
+			"link":                          "https://app.transcend.io/data-map/data-inventory/data-silos/" + id,
+			"externalId":                    "external-" + id,
+			"catalog":                       map[string]interface{}{"hasAvcFunctionality": false},
+			"type":                          fakeString(input["name"]),
+			"title":                         title,
+			"description":                   "",
+			"url":                           "",
+			"notifyEmailAddress":            "",
+			"isLive":                        false,
+			"owners":                        []interface{}{},
+			"teams":                         []interface{}{},
+			"subjectBlocklist":              []interface{}{},
+			"identifiers":                   []interface{}{},
+			"dependentDataSilos":            []interface{}{},
+			"headers":                       []interface{}{},
+			"outerType":                     "",
+			"plaintextContext":              []interface{}{},
+			"connectionState":               "NOT_CONFIGURED",
+			"sombraId":                      "",
+			"enricherIdentifierMappings":    []interface{}{},
+			"notes":                         "",
+			"dataRetentionNote":             "",
+			"dataProcessingAgreementLink":   "",
+			"contactName":                   "",
+			"contactEmail":                  "",
+			"dataProcessingAgreementStatus": "UNSIGNED",
+			"recommendedForConsent":         false,
+			"recommendedForPrivacy":         true,
+			"hasPersonalData":               true,
+			"deprecationState":              "NOT_DEPRECATED",
+		}
+		f.dataSilos[id] = silo
+
+		// Every silo comes with disabled plugins and a disabled scan config
+		for _, pluginType := range f.pluginTypes {
+			pluginID := f.newID("plugin")
+			f.plugins[pluginID] = map[string]interface{}{
+				"id":                pluginID,
+				"type":              pluginType,
+				"enabled":           false,
+				"scheduledAt":       "",
+				"lastRunAt":         "",
+				"lastEnabledAt":     "",
+				"scheduleStartAt":   "",
+				"scheduleFrequency": "86400000",
+				"error":             "",
+				"dataSilo":          map[string]interface{}{"id": id},
+			}
+		}
+		f.discoClassScanConfigs[id] = map[string]interface{}{
+			"id":                f.newID("disco"),
+			"dataSiloId":        id,
+			"type":              "FULL_SCAN",
+			"enabled":           false,
+			"scheduleFrequency": 0,
+			"scheduleStartAt":   "",
+			"scanPluginConfigs": []interface{}{},
+		}
+		created = append(created, silo)
+	}
+	return map[string]interface{}{"dataSilos": created}, nil
+}
+
+func (f *fakeBackend) resolveUpdateDataSilos(args map[string]interface{}) (interface{}, error) {
+	updated := []interface{}{}
+	for _, raw := range fakeList(fakeMap(args["input"])["dataSilos"]) {
+		input := fakeMap(raw)
+		silo, ok := f.dataSilos[fakeString(input["id"])]
+		if !ok {
+			return nil, fakeNotFound("DataSilo", input["id"])
+		}
+		for _, field := range []string{"title", "description", "url", "notifyEmailAddress", "sombraId", "notes", "dataRetentionNote", "dataProcessingAgreementLink", "contactName", "contactEmail", "dataProcessingAgreementStatus", "deprecationState"} {
+			if value, ok := input[field]; ok {
+				silo[field] = fakeString(value)
+			}
+		}
+		for _, field := range []string{"isLive", "recommendedForConsent", "recommendedForPrivacy", "hasPersonalData"} {
+			if value, ok := input[field]; ok {
+				silo[field] = value == true
+			}
+		}
+		if value, ok := input["ownerEmails"]; ok {
+			owners := []interface{}{}
+			for _, email := range fakeStrings(value) {
+				owners = append(owners, map[string]interface{}{"id": "user-" + email, "email": email})
+			}
+			silo["owners"] = owners
+		}
+		if value, ok := input["teamNames"]; ok {
+			teams := []interface{}{}
+			for _, name := range fakeStrings(value) {
+				teams = append(teams, map[string]interface{}{"id": "team-" + name, "name": name})
+			}
+			silo["teams"] = teams
+		}
+		if value, ok := input["dataSubjectBlockListIds"]; ok {
+			silo["subjectBlocklist"] = fakeIDObjects(fakeStrings(value))
+		}
+		if value, ok := input["dependedOnDataSiloIds"]; ok {
+			for _, id := range fakeStrings(value) {
+				if _, ok := f.dataSilos[id]; !ok {
+					return nil, fakeNotFound("DataSilo", id)
+				}
+			}
+			silo["dependentDataSilos"] = fakeIDObjects(fakeStrings(value))
+		}
+		if value, ok := input["identifiers"]; ok {
+			identifiers := []interface{}{}
+			for _, id := range fakeStrings(value) {
+				identifier, ok := f.findIdentifier(id)
+				if !ok {
+					return nil, fakeNotFound("Identifier", id)
+				}
+				identifiers = append(identifiers, identifier)
+			}
+			silo["identifiers"] = identifiers
+		}
+		if value, ok := input["headers"]; ok {
+			silo["headers"] = fakeHeaders(value)
+		}
+		updated = append(updated, silo)
+	}
+	return map[string]interface{}{"dataSilos": updated}, nil
+}
+
+func (f *fakeBackend) resolveDeleteDataSilos(args map[string]interface{}) (interface{}, error) {
+	for _, id := range fakeStrings(fakeMap(args["input"])["ids"]) {
+		if _, ok := f.dataSilos[id]; !ok {
+			return nil, fakeNotFound("DataSilo", id)
+		}
+		delete(f.dataSilos, id)
+		delete(f.discoClassScanConfigs, id)
+		for pluginID, plugin := range f.plugins {
+			if fakeString(fakeMap(plugin["dataSilo"])["id"]) == id {
+				delete(f.plugins, pluginID)
+			}
+		}
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (f *fakeBackend) resolveReconnectDataSilo(args map[string]interface{}) (interface{}, error) {
+	input := fakeMap(args["input"])
+	silo, ok := f.dataSilos[fakeString(input["dataSiloId"])]
+	if !ok {
+		return nil, fakeNotFound("DataSilo", input["dataSiloId"])
+	}
+	plaintextContext := []interface{}{}
+	for _, raw := range fakeList(input["plaintextContext"]) {
+		context := fakeMap(raw)
+		plaintextContext = append(plaintextContext, map[string]interface{}{"name": context["name"], "value": context["value"]})
+	}
+	silo["plaintextContext"] = plaintextContext
+	silo["presignedSaasContext"] = fakeString(input["presignedSaasContext"])
+	silo["dhEncrypted"] = fakeString(args["dhEncrypted"])
+	if dhEncrypted := fakeString(args["dhEncrypted"]); dhEncrypted != "" {
+		// The backend forwards encrypted contexts to sombra, which is the only one able to read them
+		var message dhEncryptedMessage
+		json.Unmarshal([]byte(dhEncrypted), &message)
+		session, ok := f.dhSessions[message.PublicKey]
+		if !ok {
+			return nil, &fakeGraphQLError{Message: "Unknown dhEncrypted public key"}
+		}
+		nonce, _ := base64.StdEncoding.DecodeString(message.Nonce)
+		ciphertext, _ := base64.StdEncoding.DecodeString(fakeString(input["presignedSaasContext"]))
+		presigned, err := session.open(nonce, ciphertext, dhResponseData)
+		if err != nil {
+			return nil, &fakeGraphQLError{Message: "Could not decrypt the presigned SaaS context"}
+		}
+		silo["encryptedSaasContext"] = fakeString(input["presignedSaasContext"])
+		silo["presignedSaasContext"] = string(presigned)
+	}
+	silo["connectionState"] = "CONNECTED"
+	silo["connectionError"] = ""
+	if len(f.connectionStates) > 0 {
+		silo["connectionState"] = "PENDING"
+		silo["pendingConnectionStates"] = append([]string{}, f.connectionStates...)
+	}
+	return map[string]interface{}{"dataSilo": silo}, nil
+}
+
+func (f *fakeBackend) resolvePlugins(args map[string]interface{}) (interface{}, error) {
+	filterBy := fakeMap(args["filterBy"])
+	dataSiloID := fakeString(filterBy["dataSiloId"])
+	pluginType := fakeString(filterBy["type"])
+	plugins := []interface{}{}
+	for _, id := range sortedKeys(f.plugins) {
+		plugin := f.plugins[id]
+		if dataSiloID != "" && fakeString(fakeMap(plugin["dataSilo"])["id"]) != dataSiloID {
+			continue
+		}
+		if pluginType != "" && fakeString(plugin["type"]) != pluginType {
+			continue
+		}
+		if polls, ok := plugin["pendingRunPolls"].(int); ok {
+			if polls > 0 {
+				plugin["pendingRunPolls"] = polls - 1
+			} else {
+				delete(plugin, "pendingRunPolls")
+				f.pluginRuns++
+				plugin["lastRunAt"] = fmt.Sprintf("2122-09-06T18:%02d:00.000Z", f.pluginRuns)
+				plugin["error"] = f.pluginRunError
+			}
+		}
+		plugins = append(plugins, plugin)
+	}
+	return map[string]interface{}{"plugins": plugins, "totalCount": len(plugins)}, nil
+}
+
+func (f *fakeBackend) resolveUpdateDataSiloPlugin(args map[string]interface{}) (interface{}, error) {
+	input := fakeMap(args["input"])
+	plugin, ok := f.plugins[fakeString(input["pluginId"])]
+	if !ok || fakeString(fakeMap(plugin["dataSilo"])["id"]) != fakeString(input["dataSiloId"]) {
+		return nil, fakeNotFound("Plugin", input["pluginId"])
+	}
+	enabled := input["enabled"] == true
+	if enabled && plugin["enabled"] != true {
+		plugin["lastEnabledAt"] = "2022-09-06T17:51:13.000Z"
+	}
+	plugin["enabled"] = enabled
+	plugin["scheduleFrequency"] = fakeString(input["scheduleFrequency"])
+	plugin["scheduleStartAt"] = fakeString(input["scheduleStartAt"])
+	if input["scheduleNow"] == true {
+		plugin["pendingRunPolls"] = f.pluginRunPolls
+	}
+	return map[string]interface{}{"plugin": plugin}, nil
+}
+
+func (f *fakeBackend) resolveDiscoClassScanConfig(args map[string]interface{}) (interface{}, error) {
+	dataSiloID := fakeString(fakeMap(args["input"])["dataSiloId"])
+	config, ok := f.discoClassScanConfigs[dataSiloID]
+	if !ok {
+		return nil, fakeNotFound("DiscoClassScanConfig for data silo", dataSiloID)
+	}
+	return config, nil
+}
+
+// The values that Transcend fills in for the scan plugin configs that leave them out
+var fakeScanPluginDefaults = map[string]interface{}{
+	"classifiers": []interface{}{"EMAIL", "NAME"},
+	"sampleSize":  1000,
+	"settings":    []interface{}{map[string]interface{}{"name": "confidence", "value": "MEDIUM"}},
+}
+
+func (f *fakeBackend) resolveUpdateDiscoClassScanConfig(args map[string]interface{}) (interface{}, error) {
+	input := fakeMap(args["input"])
+	for _, config := range f.discoClassScanConfigs {
+		if config["id"] != input["id"] {
+			continue
+		}
+		config["enabled"] = input["enabled"] == true
+		if value, ok := input["type"]; ok && value != nil {
+			config["type"] = fakeString(value)
+		}
+		config["scheduleFrequency"] = input["scheduleFrequency"]
+		config["scheduleStartAt"] = fakeString(input["scheduleStartAt"])
+		if value, ok := input["scanPluginConfigs"]; ok && value != nil {
+			// Values that are left out get the defaults of the plugin, like in Transcend
+			scanPluginConfigs := []interface{}{}
+			for _, raw := range value.([]interface{}) {
+				scanPluginConfig := fakeMap(raw)
+				for key, fallback := range fakeScanPluginDefaults {
+					if _, ok := scanPluginConfig[key]; !ok {
+						scanPluginConfig[key] = fakeCopy(fallback)
+					}
+				}
+				scanPluginConfigs = append(scanPluginConfigs, scanPluginConfig)
+			}
+			config["scanPluginConfigs"] = scanPluginConfigs
+		}
+		return map[string]interface{}{"discoClassScanConfig": config}, nil
+	}
+	return nil, fakeNotFound("DiscoClassScanConfig", input["id"])
+}
+
+func (f *fakeBackend) resolveDataPoints(args map[string]interface{}) (interface{}, error) {
+	ids := fakeStrings(fakeMap(args["filterBy"])["ids"])
+	nodes := []interface{}{}
+	for _, id := range ids {
+		if dataPoint, ok := f.dataPoints[id]; ok {
+			nodes = append(nodes, dataPoint)
+		}
+	}
+	return map[string]interface{}{"nodes": nodes, "totalCount": len(nodes)}, nil
+}
+
+func (f *fakeBackend) resolveSubDataPoints(args map[string]interface{}) (interface{}, error) {
+	all := []interface{}{}
+	for _, dataPointID := range fakeStrings(fakeMap(args["filterBy"])["dataPoints"]) {
+		all = append(all, f.subDataPoints[dataPointID]...)
+	}
+	offset := 0
+	if value, ok := args["offset"]; ok {
+		offset = fakeInt(value)
+	}
+	first := len(all)
+	if value, ok := args["first"]; ok {
+		first = fakeInt(value)
+	}
+	page := []interface{}{}
+	for i := offset; i < len(all) && i < offset+first; i++ {
+		page = append(page, all[i])
+	}
+	return map[string]interface{}{"nodes": page, "totalCount": len(all)}, nil
+}
+
+func (f *fakeBackend) resolveUpdateOrCreateDataPoint(args map[string]interface{}) (interface{}, error) {
+	input := fakeMap(args["input"])
+	dataSiloID := fakeString(input["dataSiloId"])
+	if _, ok := f.dataSilos[dataSiloID]; !ok {
+		return nil, fakeNotFound("DataSilo", dataSiloID)
+	}
+
+	id := fakeString(input["id"])
+	if id == "" {
+		// Data points are keyed by their name within a data silo
+		for existingID, existing := range f.dataPoints {
+			if existing["name"] == input["name"] && fakeString(fakeMap(existing["dataSilo"])["id"]) == dataSiloID {
+				id = existingID
+			}
+		}
+	}
+	if id == "" {
+		id = f.newID("datapoint")
+	}
+
+	path := []interface{}{}
+	for _, segment := range fakeStrings(input["path"]) {
+		path = append(path, segment)
+	}
+	dataPoint := map[string]interface{}{
+		"id":          id,
+		"name":        fakeString(input["name"]),
+		"dataSilo":    map[string]interface{}{"id": dataSiloID},
+		"title":       map[string]interface{}{"defaultMessage": fakeString(input["title"])},
+		"description": map[string]interface{}{"defaultMessage": fakeString(input["description"])},
+		"path":        path,
+	}
+	f.dataPoints[id] = dataPoint
+
+	if rawSubDataPoints, ok := input["subDataPoints"]; ok {
+		subDataPoints := []interface{}{}
+		for _, raw := range fakeList(rawSubDataPoints) {
+			property := fakeMap(raw)
+			attributeValues := []interface{}{}
+			for _, rawAttribute := range fakeList(property["attributes"]) {
+				attribute := fakeMap(rawAttribute)
+				for _, value := range fakeStrings(attribute["values"]) {
+					attributeValues = append(attributeValues, map[string]interface{}{
+						"name":         value,
+						"attributeKey": map[string]interface{}{"name": attribute["key"]},
+					})
+				}
+			}
+			subDataPoints = append(subDataPoints, map[string]interface{}{
+				"name":                           property["name"],
+				"dataPoint":                      map[string]interface{}{"id": id},
+				"description":                    property["description"],
+				"categories":                     fakeList(property["categories"]),
+				"purposes":                       fakeList(property["purposes"]),
+				"attributeValues":                attributeValues,
+				"accessRequestVisibilityEnabled": property["accessRequestVisibilityEnabled"] == true,
+				"erasureRequestRedactionEnabled": property["erasureRequestRedactionEnabled"] == true,
+			})
+		}
+		f.subDataPoints[id] = subDataPoints
+	}
+	return map[string]interface{}{"dataPoint": dataPoint}, nil
+}
+
+func (f *fakeBackend) resolveDeleteDataPoints(args map[string]interface{}) (interface{}, error) {
+	for _, id := range fakeStrings(fakeMap(args["input"])["ids"]) {
+		if _, ok := f.dataPoints[id]; !ok {
+			return nil, fakeNotFound("DataPoint", id)
+		}
+		delete(f.dataPoints, id)
+		delete(f.subDataPoints, id)
+	}
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (f *fakeBackend) enricherFromInput(id string, input map[string]interface{}) map[string]interface{} {
+	actions := []interface{}{}
+	for _, action := range fakeStrings(input["actions"]) {
+		actions = append(actions, action)
+	}
+	return map[string]interface{}{
+		"id":              id,
+		"title":           fakeString(input["title"]),
+		"description":     fakeString(input["description"]),
+		"url":             fakeString(input["url"]),
+		"inputIdentifier": map[string]interface{}{"id": fakeString(input["inputIdentifier"])},
+		"identifiers":     fakeIDObjects(fakeStrings(input["identifiers"])),
+		"headers":         fakeHeaders(input["headers"]),
+		"actions":         actions,
+		"type":            fakeString(input["type"]),
+	}
+}
+
+func (f *fakeBackend) resolveEnricher(args map[string]interface{}) (interface{}, error) {
+	enricher, ok := f.enrichers[fakeString(args["id"])]
+	if !ok {
+		return nil, fakeNotFound("Enricher", args["id"])
+	}
+	return enricher, nil
+}
+
+func (f *fakeBackend) resolveCreateEnricher(args map[string]interface{}) (interface{}, error) {
+	id := f.newID("enricher")
+	f.enrichers[id] = f.enricherFromInput(id, fakeMap(args["input"]))
+	return map[string]interface{}{"enricher": f.enrichers[id]}, nil
+}
+
+func (f *fakeBackend) resolveUpdateEnricher(args map[string]interface{}) (interface{}, error) {
+	input := fakeMap(args["input"])
+	id := fakeString(input["id"])
+	if _, ok := f.enrichers[id]; !ok {
+		return nil, fakeNotFound("Enricher", id)
+	}
+	f.enrichers[id] = f.enricherFromInput(id, input)
+	return map[string]interface{}{"enricher": f.enrichers[id]}, nil
+}
+
+func (f *fakeBackend) resolveDeleteEnricher(args map[string]interface{}) (interface{}, error) {
+	id := fakeString(args["id"])
+	if _, ok := f.enrichers[id]; !ok {
+		return nil, fakeNotFound("Enricher", id)
+	}
+	delete(f.enrichers, id)
+	return map[string]interface{}{"success": true}, nil
+}
+
+func (f *fakeBackend) apiKeyFromInput(id string, title string, input map[string]interface{}) map[string]interface{} {
+	scopes := []interface{}{}
+	for _, scope := range fakeStrings(input["scopes"]) {
+		scopes = append(scopes, map[string]interface{}{"name": scope})
+	}
+	return map[string]interface{}{
+		"id":        id,
+		"title":     title,
+		"scopes":    scopes,
+		"dataSilos": fakeIDObjects(fakeStrings(input["dataSilos"])),
+	}
+}
+
+func (f *fakeBackend) resolveAPIKey(args map[string]interface{}) (interface{}, error) {
+	apiKey, ok := f.apiKeys[fakeString(args["id"])]
+	if !ok {
+		return nil, fakeNotFound("ApiKey", args["id"])
+	}
+	return apiKey, nil
+}
+
+func (f *fakeBackend) resolveCreateAPIKey(args map[string]interface{}) (interface{}, error) {
+	input := fakeMap(args["input"])
+	id := f.newID("apikey")
+	f.apiKeys[id] = f.apiKeyFromInput(id, fakeString(input["title"]), input)
+	return map[string]interface{}{"apiKey": f.apiKeys[id]}, nil
+}
+
+func (f *fakeBackend) resolveUpdateAPIKey(args map[string]interface{}) (interface{}, error) {
+	input := fakeMap(args["input"])
+	id := fakeString(input["id"])
+	existing, ok := f.apiKeys[id]
+	if !ok {
+		return nil, fakeNotFound("ApiKey", id)
+	}
+	f.apiKeys[id] = f.apiKeyFromInput(id, fakeString(existing["title"]), input)
+	return map[string]interface{}{"apiKey": f.apiKeys[id]}, nil
+}
+
+func (f *fakeBackend) resolveDeleteAPIKey(args map[string]interface{}) (interface{}, error) {
+	id := fakeString(args["id"])
+	if _, ok := f.apiKeys[id]; !ok {
+		return nil, fakeNotFound("ApiKey", id)
+	}
+	delete(f.apiKeys, id)
+	return map[string]interface{}{"success": true}, nil
+}
+
+func fakeInt(value interface{}) int {
+	switch typed := value.(type) {
+	case float64:
+		return int(typed)
+	case int64:
+		return int(typed)
+	case int:
+		return typed
+	case string:
+		i, _ := strconv.Atoi(typed)
+		return i
+	}
+	return 0
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,193 @@
+package transcend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	providerschema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	fwtypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// frameworkProvider serves the resources that were ported to terraform-plugin-framework. It runs next to
+// the SDKv2 provider behind a mux server, and shares the client configured by the SDKv2 provider so that
+// both halves talk to the backend and sombra through the same connections and credentials.
+type frameworkProvider struct {
+	sdkProvider *schema.Provider
+}
+
+var _ provider.Provider = &frameworkProvider{}
+
+// NewFrameworkProvider creates the terraform-plugin-framework half of the provider. sdkProvider must be
+// served by the same mux server, before this provider, so that it is configured first.
+func NewFrameworkProvider(sdkProvider *schema.Provider) func() provider.Provider {
+	return func() provider.Provider {
+		return &frameworkProvider{sdkProvider: sdkProvider}
+	}
+}
+
+func (p *frameworkProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "transcend"
+}
+
+// Schema mirrors the schema of the SDKv2 provider, as the mux server requires every provider it serves
+// to declare the same provider configuration.
+func (p *frameworkProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
+	attributes, blocks := frameworkProviderAttributes(p.sdkProvider.Schema)
+	resp.Schema = providerschema.Schema{
+		Attributes: attributes,
+		Blocks:     blocks,
+	}
+}
+
+func (p *frameworkProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	client, ok := p.sdkProvider.Meta().(*Client)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unable to configure provider",
+			"The resources served by terraform-plugin-framework were configured before the rest of the provider. This is a bug in the provider.",
+		)
+		return
+	}
+	resp.ResourceData = client
+	resp.DataSourceData = client
+}
+
+func (p *frameworkProvider) Resources(ctx context.Context) []func() resource.Resource {
+	return []func() resource.Resource{
+		newAPIKeyResource,
+		newEnricherResource,
+	}
+}
+
+func (p *frameworkProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
+	return nil
+}
+
+// frameworkProviderAttributes converts the SDKv2 provider schema into the same attributes and blocks in
+// terraform-plugin-framework. Only the types used by the provider configuration are supported.
+func frameworkProviderAttributes(sdkSchema map[string]*schema.Schema) (map[string]providerschema.Attribute, map[string]providerschema.Block) {
+	attributes := map[string]providerschema.Attribute{}
+	blocks := map[string]providerschema.Block{}
+
+	for name, s := range sdkSchema {
+		// The SDK reports required attributes that have a default as optional
+		required := s.Required && s.DefaultFunc == nil
+		optional := !required
+
+		switch s.Type {
+		case schema.TypeString:
+			attributes[name] = providerschema.StringAttribute{Required: required, Optional: optional, Sensitive: s.Sensitive, Description: s.Description}
+		case schema.TypeInt:
+			attributes[name] = providerschema.Int64Attribute{Required: required, Optional: optional, Sensitive: s.Sensitive, Description: s.Description}
+		case schema.TypeFloat:
+			attributes[name] = providerschema.Float64Attribute{Required: required, Optional: optional, Sensitive: s.Sensitive, Description: s.Description}
+		case schema.TypeBool:
+			attributes[name] = providerschema.BoolAttribute{Required: required, Optional: optional, Sensitive: s.Sensitive, Description: s.Description}
+		case schema.TypeList:
+			switch elem := s.Elem.(type) {
+			case *schema.Schema:
+				if elem.Type != schema.TypeString {
+					panic(fmt.Sprintf("unsupported element type %s for provider attribute %s", elem.Type, name))
+				}
+				attributes[name] = providerschema.ListAttribute{ElementType: fwtypes.StringType, Required: required, Optional: optional, Sensitive: s.Sensitive, Description: s.Description}
+			case *schema.Resource:
+				nestedAttributes, nestedBlocks := frameworkProviderAttributes(elem.Schema)
+				blocks[name] = providerschema.ListNestedBlock{
+					Description: s.Description,
+					NestedObject: providerschema.NestedBlockObject{
+						Attributes: nestedAttributes,
+						Blocks:     nestedBlocks,
+					},
+				}
+			}
+		default:
+			panic(fmt.Sprintf("unsupported type %s for provider attribute %s", s.Type, name))
+		}
+	}
+	return attributes, blocks
+}
+
+// frameworkClient extracts the client from the provider data passed to framework resources. The provider
+// data is nil while Terraform validates configurations, before the provider is configured.
+func frameworkClient(providerData interface{}, diags *fwdiag.Diagnostics) *Client {
+	if providerData == nil {
+		return nil
+	}
+	client, ok := providerData.(*Client)
+	if !ok {
+		diags.AddError("Unexpected provider data", fmt.Sprintf("Expected *Client, got %T. This is a bug in the provider.", providerData))
+		return nil
+	}
+	return client
+}
+
+func listToStrings(ctx context.Context, list fwtypes.List, diags *fwdiag.Diagnostics) []string {
+	var values []string
+	if list.IsNull() || list.IsUnknown() {
+		return values
+	}
+	diags.Append(list.ElementsAs(ctx, &values, false)...)
+	return values
+}
+
+// stringsToList builds the list stored in state for values read from the backend. The backend does not
+// distinguish an unset list from an empty one, so an empty result keeps the form of the prior value.
+func stringsToList(values []string, prior fwtypes.List, diags *fwdiag.Diagnostics) fwtypes.List {
+	if len(values) == 0 && prior.IsNull() {
+		return fwtypes.ListNull(fwtypes.StringType)
+	}
+	elements := make([]attr.Value, len(values))
+	for i, value := range values {
+		elements[i] = fwtypes.StringValue(value)
+	}
+	list, listDiags := fwtypes.ListValue(fwtypes.StringType, elements)
+	diags.Append(listDiags...)
+	return list
+}
+
+// stringValue is stringsToList for optional strings, which the backend returns as empty strings when unset.
+func stringValue(value string, prior fwtypes.String) fwtypes.String {
+	if value == "" && prior.IsNull() {
+		return fwtypes.StringNull()
+	}
+	return fwtypes.StringValue(value)
+}
+
+// boolValue is stringValue for optional booleans, which the backend returns as false when unset.
+func boolValue(value bool, prior fwtypes.Bool) fwtypes.Bool {
+	if !value && prior.IsNull() {
+		return fwtypes.BoolNull()
+	}
+	return fwtypes.BoolValue(value)
+}
+
+// The SDKv2 provider stored unset optional attributes as zero values, where the framework tells them apart
+// from null. The state upgraders of ported resources convert them to null, matching a configuration that
+// leaves the attributes unset.
+
+func nullIfEmptyString(value fwtypes.String) fwtypes.String {
+	if value.ValueString() == "" {
+		return fwtypes.StringNull()
+	}
+	return value
+}
+
+func nullIfFalse(value fwtypes.Bool) fwtypes.Bool {
+	if !value.ValueBool() {
+		return fwtypes.BoolNull()
+	}
+	return value
+}
+
+func nullIfEmptyList(value fwtypes.List) fwtypes.List {
+	if len(value.Elements()) == 0 {
+		return fwtypes.ListNull(value.ElementType(context.Background()))
+	}
+	return value
+}
@@ -0,0 +1,14 @@
+package transcend
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// Resources that only exist as part of a data silo (connections, plugins, scan configs) are
+// imported by the ID of that data silo, which can be found in the data silo's admin dashboard URL.
+func importByDataSiloId(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	d.Set("data_silo_id", d.Id())
+	return []*schema.ResourceData{d}, nil
+}
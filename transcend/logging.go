@@ -0,0 +1,187 @@
+package transcend
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const redactedValue = "***"
+
+// Headers that carry credentials for the backend or sombra
+var sensitiveHeaders = map[string]bool{
+	"authorization":          true,
+	"x-sombra-authorization": true,
+}
+
+// Request and response fields whose values are secrets, wherever they appear in a payload:
+// the secret map sent to sombra, and the SaaS context that sombra encrypted it into.
+var sensitiveFields = map[string]bool{
+	"secretmap":            true,
+	"secretcontext":        true,
+	"presignedsaascontext": true,
+	"dhencrypted":          true,
+}
+
+var graphQLOperationPattern = regexp.MustCompile(`^\s*(query|mutation|subscription)\s+(\w+)`)
+
+// logRoundTrip sends the request through send and emits a debug log entry describing the exchange.
+// setHeaders applies the headers that the transport adds to every attempt, so that they can be shown (masked).
+func logRoundTrip(req *http.Request, describe func(requestBody []byte, resp *http.Response, responseBody []byte) (string, map[string]interface{}), setHeaders func(http.Header), send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(requestBody))
+	}
+
+	start := time.Now()
+	resp, err := send(req)
+	latency := time.Since(start)
+
+	var responseBody []byte
+	if resp != nil && resp.Body != nil {
+		var readErr error
+		responseBody, readErr = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+		if readErr != nil && err == nil {
+			err = readErr
+		}
+	}
+
+	headers := req.Header.Clone()
+	setHeaders(headers)
+	message, fields := describe(requestBody, resp, responseBody)
+	fields["http_method"] = req.Method
+	fields["http_url"] = req.URL.Redacted()
+	fields["http_request_headers"] = redactHeaders(headers)
+	fields["latency_ms"] = latency.Milliseconds()
+	if resp != nil {
+		fields["http_status"] = resp.StatusCode
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	tflog.Debug(req.Context(), message, fields)
+
+	return resp, err
+}
+
+// describeGraphQLExchange logs the operation name, its masked variables, and any errors the backend returned.
+func describeGraphQLExchange(requestBody []byte, resp *http.Response, responseBody []byte) (string, map[string]interface{}) {
+	fields := map[string]interface{}{}
+
+	var request struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if json.Unmarshal(requestBody, &request) == nil {
+		if match := graphQLOperationPattern.FindStringSubmatch(request.Query); match != nil {
+			fields["graphql_operation_type"] = match[1]
+			fields["graphql_operation"] = match[2]
+		}
+		fields["graphql_variables"] = redactJSON(request.Variables)
+	}
+
+	var response struct {
+		Errors []struct {
+			Message    string                 `json:"message"`
+			Extensions map[string]interface{} `json:"extensions"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(responseBody, &response) == nil && len(response.Errors) > 0 {
+		errs := []interface{}{}
+		for _, graphQLErr := range response.Errors {
+			errs = append(errs, map[string]interface{}{
+				"message":    graphQLErr.Message,
+				"extensions": redactJSON(graphQLErr.Extensions),
+			})
+		}
+		fields["graphql_errors"] = errs
+	} else if resp != nil && resp.StatusCode != http.StatusOK {
+		fields["http_response_body"] = string(responseBody)
+	}
+
+	return "Sent GraphQL operation", fields
+}
+
+// describeSombraExchange logs the masked request body. The response of a successful call is the
+// encrypted SaaS context, so the response body is only logged for failures.
+func describeSombraExchange(requestBody []byte, resp *http.Response, responseBody []byte) (string, map[string]interface{}) {
+	fields := map[string]interface{}{}
+
+	var request interface{}
+	if json.Unmarshal(requestBody, &request) == nil {
+		fields["sombra_request_body"] = redactJSON(request)
+	}
+	if resp != nil && resp.StatusCode >= 300 {
+		fields["sombra_response_body"] = string(responseBody)
+	}
+
+	return "Sent sombra request", fields
+}
+
+func redactHeaders(headers http.Header) map[string]string {
+	redacted := map[string]string{}
+	for name, values := range headers {
+		if sensitiveHeaders[strings.ToLower(name)] {
+			redacted[name] = redactedValue
+		} else {
+			redacted[name] = strings.Join(values, ", ")
+		}
+	}
+	return redacted
+}
+
+// redactJSON returns a copy of a decoded JSON value with every secret masked. Besides the
+// sensitiveFields, the value of any object with an `isSecret` marker is masked, which covers every
+// header of data silos and enrichers. Their values are all sensitive, whether marked secret or not.
+func redactJSON(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		redacted := map[string]interface{}{}
+		_, isHeader := typed["isSecret"]
+		for key, child := range typed {
+			if sensitiveFields[strings.ToLower(key)] || (isHeader && key == "value") {
+				redacted[key] = redactSecret(child)
+			} else {
+				redacted[key] = redactJSON(child)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(typed))
+		for i, child := range typed {
+			redacted[i] = redactJSON(child)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// redactSecret masks a secret, keeping the keys of a secret map so logs still show which secrets were sent.
+func redactSecret(value interface{}) interface{} {
+	if secrets, ok := value.(map[string]interface{}); ok {
+		redacted := map[string]interface{}{}
+		for key := range secrets {
+			redacted[key] = redactedValue
+		}
+		return redacted
+	}
+	if value == nil {
+		return nil
+	}
+	return redactedValue
+}
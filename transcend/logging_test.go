@@ -0,0 +1,105 @@
+package transcend
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	graphql "github.com/hasura/go-graphql-client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphQLOperationsAreLoggedWithSecretsMasked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":null,"errors":[{"message":"Data silo not connected","extensions":{"code":"BAD_USER_INPUT"}}]}`))
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	var mutation struct {
+		ReconnectDataSilo struct {
+			Success graphql.Boolean
+		} `graphql:"reconnectDataSilo(input: $input)"`
+	}
+	client := NewClientWithConfig(ClientConfig{URL: server.URL, APIToken: "super-secret-api-key", Retry: DefaultRetryConfig()})
+	err := client.graphql.Mutate(ctx, &mutation, map[string]interface{}{
+		"input": map[string]interface{}{
+			"dataSiloId":           "silo-1",
+			"presignedSaasContext": "encrypted-secrets",
+			"headers": []interface{}{
+				map[string]interface{}{"name": "x-public", "value": "visible", "isSecret": false},
+				map[string]interface{}{"name": "x-api-key", "value": "hidden", "isSecret": true},
+			},
+		},
+	}, graphql.OperationName("ReconnectDataSilo"))
+	assert.NotNil(t, err)
+
+	entries, decodeErr := tflogtest.MultilineJSONDecode(&output)
+	assert.Nil(t, decodeErr)
+	assert.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "Sent GraphQL operation", entry["@message"])
+	assert.Equal(t, "ReconnectDataSilo", entry["graphql_operation"])
+	assert.Equal(t, "mutation", entry["graphql_operation_type"])
+	assert.Equal(t, float64(200), entry["http_status"])
+	assert.Contains(t, entry, "latency_ms")
+	assert.Equal(t, redactedValue, entry["http_request_headers"].(map[string]interface{})["Authorization"])
+
+	input := entry["graphql_variables"].(map[string]interface{})["input"].(map[string]interface{})
+	assert.Equal(t, "silo-1", input["dataSiloId"])
+	assert.Equal(t, redactedValue, input["presignedSaasContext"])
+	headers := input["headers"].([]interface{})
+	assert.Equal(t, "x-public", headers[0].(map[string]interface{})["name"])
+	assert.Equal(t, redactedValue, headers[0].(map[string]interface{})["value"], "header values are sensitive even when not marked secret")
+	assert.Equal(t, redactedValue, headers[1].(map[string]interface{})["value"])
+
+	errs := entry["graphql_errors"].([]interface{})
+	assert.Equal(t, "Data silo not connected", errs[0].(map[string]interface{})["message"])
+
+	assert.NotContains(t, output.String(), "super-secret-api-key")
+	assert.NotContains(t, output.String(), "encrypted-secrets")
+	assert.NotContains(t, output.String(), "hidden")
+	assert.NotContains(t, output.String(), "visible")
+}
+
+func TestSombraCallsAreLoggedWithSecretsMasked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("presigned-saas-context"))
+	}))
+	defer server.Close()
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	client := NewClientWithConfig(ClientConfig{URL: server.URL, APIToken: "api-key", InternalKey: "internal-key", Retry: DefaultRetryConfig()})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/v1/register-saas", strings.NewReader(`{"secretMap":{"apiKey":"datadog-secret"},"allowedHosts":["api.datadoghq.com"]}`))
+	assert.Nil(t, err)
+	resp, err := client.sombraClient.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+
+	entries, decodeErr := tflogtest.MultilineJSONDecode(&output)
+	assert.Nil(t, decodeErr)
+	assert.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "Sent sombra request", entry["@message"])
+	assert.Equal(t, float64(200), entry["http_status"])
+
+	headers := entry["http_request_headers"].(map[string]interface{})
+	assert.Equal(t, redactedValue, headers["Authorization"])
+	assert.Equal(t, redactedValue, headers["X-Sombra-Authorization"])
+
+	body := entry["sombra_request_body"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"apiKey": redactedValue}, body["secretMap"])
+	assert.Equal(t, []interface{}{"api.datadoghq.com"}, body["allowedHosts"])
+
+	assert.NotContains(t, output.String(), "datadog-secret")
+	assert.NotContains(t, output.String(), "presigned-saas-context")
+	assert.NotContains(t, output.String(), "internal-key")
+}
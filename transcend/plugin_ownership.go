@@ -0,0 +1,226 @@
+package transcend
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// The disco class scan config is claimed like the plugins, under a kind of its own
+const discoClassScanConfigOwnershipKind = "DISCO_CLASS_SCAN_CONFIG"
+
+// pluginOwnership records which resource of the run manages each plugin, and the disco class scan config, of a
+// data silo. Each can be managed by a single block of transcend_data_silo or standalone resource, as two would
+// keep undoing each other's changes.
+//
+// Providers are not told the addresses of the resources they plan, so owners are told apart by an identity
+// instead: the ID of the data silo for its blocks, and the ownership_id that the provider gives each standalone
+// resource when creating it. Standalone resources that are not created yet have no ownership_id, so each plan of
+// one is a new owner. Terraform plans each resource again right before applying it, so those claims are also
+// made when the data silo of the resource is only created in the same run.
+type pluginOwnership struct {
+	mu sync.Mutex
+	// The owner of each plugin, keyed by <data_silo_id>:<kind>
+	owners map[string]pluginOwner
+	// The number of owners that were given an identity for a single plan
+	planned int
+}
+
+// pluginOwner is a resource that manages a plugin. Its description names it in errors.
+type pluginOwner struct {
+	identity    string
+	description string
+}
+
+func newPluginOwnership() *pluginOwnership {
+	return &pluginOwnership{owners: map[string]pluginOwner{}}
+}
+
+// claim records that owner manages the plugin of the given kind of a data silo, failing if another resource
+// already manages it.
+func (o *pluginOwnership) claim(dataSiloID string, kind string, owner pluginOwner) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key := dataSiloID + ":" + kind
+	if existing, ok := o.owners[key]; ok && existing.identity != owner.identity {
+		managed := kind + " plugin"
+		if kind == discoClassScanConfigOwnershipKind {
+			managed = "disco class scan config"
+		}
+		if existing.description == owner.description {
+			return fmt.Errorf("the %s of data silo %s is managed by two resources that are both %s. Remove one of them, as they would keep undoing each other's changes", managed, dataSiloID, owner.description)
+		}
+		return fmt.Errorf("the %s of data silo %s is managed by both %s and %s. Remove one of them, as they would keep undoing each other's changes", managed, dataSiloID, existing.description, owner.description)
+	}
+	o.owners[key] = owner
+	return nil
+}
+
+// release records that the resource with the given identity no longer manages the plugin of the given kind of a
+// data silo, if it did.
+func (o *pluginOwnership) release(dataSiloID string, kind string, identity string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key := dataSiloID + ":" + kind
+	if existing, ok := o.owners[key]; ok && existing.identity == identity {
+		delete(o.owners, key)
+	}
+}
+
+// plannedIdentity gives an identity to a standalone resource that has none yet, for the plan being made
+func (o *pluginOwnership) plannedIdentity(resourceType string) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.planned++
+	return fmt.Sprintf("%s:planned-%d", resourceType, o.planned)
+}
+
+// The kinds of plugins that the blocks of a data silo manage, as in dataSiloPluginBlocks
+var embeddedPluginKinds = map[string]string{
+	"data_silo_discovery_plugin":    "DATA_SILO_DISCOVERY",
+	"schema_discovery_plugin":       "SCHEMA_DISCOVERY",
+	"content_classification_plugin": "CONTENT_CLASSIFICATION",
+	"disco_class_scan_config":       discoClassScanConfigOwnershipKind,
+}
+
+func embeddedPluginOwner(dataSiloID string, block string) pluginOwner {
+	return pluginOwner{
+		identity:    "transcend_data_silo:" + dataSiloID,
+		description: "the " + block + " block of transcend_data_silo",
+	}
+}
+
+// claimEmbeddedPlugins claims the plugins configured by the blocks of a data silo, and releases the ones whose
+// block was removed.
+func claimEmbeddedPlugins(client *Client, dataSiloID string, blocks func(block string) []interface{}) error {
+	for block, kind := range embeddedPluginKinds {
+		owner := embeddedPluginOwner(dataSiloID, block)
+		if len(blocks(block)) != 1 {
+			client.pluginOwnership.release(dataSiloID, kind, owner.identity)
+			continue
+		}
+		if err := client.pluginOwnership.claim(dataSiloID, kind, owner); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseEmbeddedPlugins releases the plugins of a data silo that is destroyed.
+func releaseEmbeddedPlugins(client *Client, dataSiloID string) {
+	for block, kind := range embeddedPluginKinds {
+		client.pluginOwnership.release(dataSiloID, kind, embeddedPluginOwner(dataSiloID, block).identity)
+	}
+}
+
+// The settings that describe the standalone resources of a plugin, and of the disco class scan config
+var (
+	standalonePluginSettings               = []string{"enabled", "schedule_frequency_minutes", "schedule_start_at"}
+	standaloneDiscoClassScanConfigSettings = []string{"type", "enabled", "schedule_frequency_minutes", "schedule_start_at"}
+)
+
+func ownershipIDSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: "Identifies this resource to the provider, which reports any other resource that manages the same plugin",
+	}
+}
+
+// newOwnershipID gives a standalone resource the identity that it is told apart from the other resources by
+func newOwnershipID(d *schema.ResourceData) diag.Diagnostics {
+	var diags diag.Diagnostics
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Error generating ownership_id",
+			Detail:   err.Error(),
+		})
+		return diags
+	}
+	d.Set("ownership_id", hex.EncodeToString(id))
+	return diags
+}
+
+func describeStandalonePlugin(resourceType string, d types.ResourceGetter, settings []string) string {
+	described := make([]string, len(settings))
+	for i, setting := range settings {
+		described[i] = fmt.Sprintf("%s = %#v", setting, d.Get(setting))
+	}
+	return "a " + resourceType + " resource with " + strings.Join(described, ", ")
+}
+
+// standalonePluginIdentity tells apart a standalone resource that is being applied or destroyed. Resources
+// created before the ownership_id was added only get one when refreshed, and are told apart by their ID until
+// then.
+func standalonePluginIdentity(resourceType string, d *schema.ResourceData) string {
+	if ownershipID := d.Get("ownership_id").(string); ownershipID != "" {
+		return resourceType + ":" + ownershipID
+	}
+	return resourceType + ":id:" + d.Id()
+}
+
+// plannedStandalonePluginOwner is the owner of the plugin of a standalone resource that is being planned. A
+// resource that is replaced is planned a second time without its state, which is then only left in the raw state.
+func plannedStandalonePluginOwner(client *Client, resourceType string, d *schema.ResourceDiff, settings []string) pluginOwner {
+	owner := pluginOwner{description: describeStandalonePlugin(resourceType, d, settings)}
+	state := d.GetRawState()
+	if state.IsNull() {
+		owner.identity = client.pluginOwnership.plannedIdentity(resourceType)
+		return owner
+	}
+	if ownershipID := state.GetAttr("ownership_id"); ownershipID.IsKnown() && !ownershipID.IsNull() && ownershipID.AsString() != "" {
+		owner.identity = resourceType + ":" + ownershipID.AsString()
+	} else {
+		owner.identity = resourceType + ":id:" + state.GetAttr("id").AsString()
+	}
+	return owner
+}
+
+// customizeDiffStandalonePlugin refuses standalone plugin resources whose plugin is also managed by another
+// resource. Data silos created in the same run only have an ID once applied, so their plugins are checked when
+// Terraform plans them again before applying them.
+func customizeDiffStandalonePlugin(resourceType string, kind string, settings []string) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+		if !d.NewValueKnown("data_silo_id") {
+			return nil
+		}
+		client := m.(*Client)
+		return client.pluginOwnership.claim(d.Get("data_silo_id").(string), kind, plannedStandalonePluginOwner(client, resourceType, d, settings))
+	}
+}
+
+// claimStandalonePlugin claims the plugin of a standalone plugin resource before updating it. Created resources
+// were claimed when planned, under an identity that only lasts for that plan.
+func claimStandalonePlugin(client *Client, d *schema.ResourceData, resourceType string, kind string, settings []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if d.IsNewResource() {
+		return diags
+	}
+	err := client.pluginOwnership.claim(d.Get("data_silo_id").(string), kind, pluginOwner{
+		identity:    standalonePluginIdentity(resourceType, d),
+		description: describeStandalonePlugin(resourceType, d, settings),
+	})
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Plugin managed by two resources",
+			Detail:   err.Error(),
+		})
+	}
+	return diags
+}
+
+// releaseStandalonePlugin releases the plugin of a standalone plugin resource that is destroyed.
+func releaseStandalonePlugin(client *Client, d *schema.ResourceData, resourceType string, kind string) {
+	client.pluginOwnership.release(d.Get("data_silo_id").(string), kind, standalonePluginIdentity(resourceType, d))
+}
@@ -0,0 +1,42 @@
+package transcend
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginOwnershipReleasesClaims(t *testing.T) {
+	ownership := newPluginOwnership()
+	silo := embeddedPluginOwner("silo-1", "schema_discovery_plugin")
+	standalone := pluginOwner{identity: "transcend_schema_discovery_plugin:abc", description: "a transcend_schema_discovery_plugin resource"}
+
+	assert.Nil(t, ownership.claim("silo-1", "SCHEMA_DISCOVERY", silo))
+	assert.Nil(t, ownership.claim("silo-1", "SCHEMA_DISCOVERY", silo), "a resource can claim its plugin again")
+	assert.NotNil(t, ownership.claim("silo-1", "SCHEMA_DISCOVERY", standalone))
+
+	// Only the owner can release its claim
+	ownership.release("silo-1", "SCHEMA_DISCOVERY", standalone.identity)
+	assert.NotNil(t, ownership.claim("silo-1", "SCHEMA_DISCOVERY", standalone))
+	ownership.release("silo-1", "SCHEMA_DISCOVERY", silo.identity)
+	assert.Nil(t, ownership.claim("silo-1", "SCHEMA_DISCOVERY", standalone))
+
+	// Removing the block of a data silo releases its plugin
+	assert.Nil(t, claimEmbeddedPlugins(&Client{pluginOwnership: ownership}, "silo-2", func(block string) []interface{} {
+		if block == "content_classification_plugin" {
+			return []interface{}{map[string]interface{}{}}
+		}
+		return nil
+	}))
+	assert.NotNil(t, ownership.claim("silo-2", "CONTENT_CLASSIFICATION", standalone))
+	assert.Nil(t, claimEmbeddedPlugins(&Client{pluginOwnership: ownership}, "silo-2", func(block string) []interface{} {
+		return nil
+	}))
+	assert.Nil(t, ownership.claim("silo-2", "CONTENT_CLASSIFICATION", standalone))
+
+	// Two planned resources are different owners, even with the same settings
+	first := pluginOwner{identity: ownership.plannedIdentity("transcend_schema_discovery_plugin"), description: standalone.description}
+	second := pluginOwner{identity: ownership.plannedIdentity("transcend_schema_discovery_plugin"), description: standalone.description}
+	assert.Nil(t, ownership.claim("silo-3", "SCHEMA_DISCOVERY", first))
+	assert.EqualError(t, ownership.claim("silo-3", "SCHEMA_DISCOVERY", second), "the SCHEMA_DISCOVERY plugin of data silo silo-3 is managed by two resources that are both a transcend_schema_discovery_plugin resource. Remove one of them, as they would keep undoing each other's changes")
+}
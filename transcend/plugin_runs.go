@@ -0,0 +1,127 @@
+package transcend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const (
+	defaultPluginRunPollInterval = "10s"
+	defaultPluginRunTimeout      = "30m"
+)
+
+func runOnApplySchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Default:     false,
+		Description: "Whether to run the plugin right away when it is applied for the first time, and again whenever `triggers` changes, instead of waiting for its schedule",
+	}
+}
+
+func pluginTriggersSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeMap,
+		Optional:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Description: "Arbitrary values that run the plugin again when they change, if `run_on_apply` is set. For example, the version of a database schema.",
+	}
+}
+
+func waitForCompletionSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "Waits for the runs started by `run_on_apply` to finish, so that the resources that depend on their results, like discovered data points, can be looked up. Fails with the error of the plugin if the run failed.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"poll_interval": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          defaultPluginRunPollInterval,
+					Description:      "How often to check whether the run finished, as a duration like \"10s\"",
+					ValidateDiagFunc: validatePositiveDuration,
+				},
+				"timeout": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          defaultPluginRunTimeout,
+					Description:      "How long to wait for the run, as a duration like \"30m\". The wait also ends when the create or update timeout of the resource runs out first, so raise those as well to wait longer than them.",
+					ValidateDiagFunc: validatePositiveDuration,
+				},
+			},
+		},
+	}
+}
+
+// shouldRunPlugin tells whether a plugin configured at prefix, like "" for the plugin resources or
+// "schema_discovery_plugin.0." for the blocks of a data silo, is run as part of this apply.
+func shouldRunPlugin(d *schema.ResourceData, prefix string) bool {
+	if !d.Get(prefix + "run_on_apply").(bool) {
+		return false
+	}
+	return d.IsNewResource() || d.HasChange(prefix+"run_on_apply") || d.HasChange(prefix+"triggers")
+}
+
+// waitForPluginRun polls the plugin until it has run since previousRun, as configured in the wait_for_completion
+// block at prefix, if any.
+func waitForPluginRun(ctx context.Context, client *Client, d *schema.ResourceData, prefix string, plugin types.Plugin) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	blocks := d.Get(prefix + "wait_for_completion").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+	pollInterval, _ := time.ParseDuration(block["poll_interval"].(string))
+	timeout, _ := time.ParseDuration(block["timeout"].(string))
+
+	deadline := newWaitDeadline(ctx, timeout)
+	ctx, cancel := context.WithTimeout(ctx, deadline.timeout)
+	defer cancel()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	dataSiloID := string(plugin.DataSilo.ID)
+	name := fmt.Sprintf("The %s plugin of data silo %s", plugin.Type, dataSiloID)
+	for {
+		// The plugins are cached, but the run is only seen by looking them up again
+		client.cache.invalidate(pluginsCacheKey + dataSiloID)
+		plugins, err := client.dataSiloPlugins(ctx, dataSiloID)
+		if err != nil && ctx.Err() == nil {
+			diags = append(diags, graphQLErrorDiagnostics("Error reading the plugins of data silo "+dataSiloID, err)...)
+			return diags
+		}
+		for _, current := range plugins {
+			if current.ID != plugin.ID || current.LastRunAt == "" || current.LastRunAt == plugin.LastRunAt {
+				continue
+			}
+			if current.Error != "" {
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Error running plugin " + string(plugin.ID),
+					Detail:   fmt.Sprintf("%s failed at %s: %s", name, current.LastRunAt, current.Error),
+				})
+			}
+			return diags
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Error waiting for plugin " + string(plugin.ID) + " to run",
+				Detail:   deadline.describe(fmt.Sprintf("the %s plugin of data silo %s to finish running", plugin.Type, dataSiloID), "wait_for_completion"),
+			})
+			return diags
+		}
+	}
+}
@@ -2,12 +2,61 @@ package transcend
 
 import (
 	"context"
+	"net/http"
 	"net/url"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
+// NewProviderServer serves the SDKv2 provider and the resources ported to terraform-plugin-framework
+// as a single provider.
+func NewProviderServer(ctx context.Context) (func() tfprotov5.ProviderServer, error) {
+	sdkProvider := Provider()
+	frameworkServer := providerserver.NewProtocol5(NewFrameworkProvider(sdkProvider)())
+	muxServer, err := tf5muxserver.NewMuxServer(ctx,
+		sdkProvider.GRPCProvider,
+		func() tfprotov5.ProviderServer {
+			return frameworkProviderServer{frameworkServer()}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return muxServer.ProviderServer, nil
+}
+
+// frameworkProviderServer leaves the provider configuration to the SDKv2 provider:
+//   - The framework cannot declare the MaxItems of the transport blocks, so only the SDK's provider schema is
+//     reported to Terraform. The framework provider declares the same schema otherwise (see TestProviderSchemasMatch).
+//   - The SDK fills in the defaults of the provider attributes while the framework returns the configuration
+//     unchanged, which the mux server would otherwise reject as conflicting prepared configurations.
+type frameworkProviderServer struct {
+	tfprotov5.ProviderServer
+}
+
+func (s frameworkProviderServer) GetProviderSchema(ctx context.Context, req *tfprotov5.GetProviderSchemaRequest) (*tfprotov5.GetProviderSchemaResponse, error) {
+	resp, err := s.ProviderServer.GetProviderSchema(ctx, req)
+	if resp != nil {
+		resp.Provider = nil
+	}
+	return resp, err
+}
+
+func (s frameworkProviderServer) PrepareProviderConfig(ctx context.Context, req *tfprotov5.PrepareProviderConfigRequest) (*tfprotov5.PrepareProviderConfigResponse, error) {
+	resp, err := s.ProviderServer.PrepareProviderConfig(ctx, req)
+	if resp != nil {
+		resp.PreparedConfig = nil
+	}
+	return resp, err
+}
+
 // Provider -
 func Provider() *schema.Provider {
 	return &schema.Provider{
@@ -20,33 +69,113 @@ func Provider() *schema.Provider {
 			},
 			"key": {
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
+				Sensitive:   true,
 				DefaultFunc: schema.EnvDefaultFunc("TRANSCEND_KEY", nil),
-				Description: "The API Key to use to talk to Transcend. Ensure it has the scopes to perform whatever actions you need. Can be set using the TRANSCEND_KEY environment variable.",
+				Description: "The API Key to use to talk to Transcend. Ensure it has the scopes to perform whatever actions you need. Can be set using the TRANSCEND_KEY environment variable. One of `key`, `key_file` or `key_command` is required.",
+			},
+			"key_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("TRANSCEND_KEY_FILE", nil),
+				ConflictsWith: []string{"key_command"},
+				Description:   "Path to a file containing the API Key to use to talk to Transcend. The file is read again whenever it changes, so keys rotated by an agent are picked up. Takes precedence over `key`. Can be set using the TRANSCEND_KEY_FILE environment variable.",
+			},
+			"key_command": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				ConflictsWith: []string{"key_file"},
+				Description:   "A command, and its arguments, that prints the API Key to use to talk to Transcend as a JSON object: `{\"key\": \"...\", \"expires_at\": \"2022-09-06T17:51:13Z\"}`. `expires_at` is optional; when set, the command is run again shortly before the key expires. Takes precedence over `key` and `key_file`.",
 			},
 			"internal_sombra_key": {
 				Type:        schema.TypeString,
 				Optional:    true,
+				Sensitive:   true,
 				DefaultFunc: schema.EnvDefaultFunc("TRANSCEND_INTERNAL_SOMBRA_KEY", nil),
 				Description: "The API Key to use to talk to a self-hosted sombra. Only used for enterprises with the self-hosted option",
 			},
+			"internal_sombra_key_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("TRANSCEND_INTERNAL_SOMBRA_KEY_FILE", nil),
+				ConflictsWith: []string{"internal_sombra_key_command"},
+				Description:   "Path to a file containing the API Key to use to talk to a self-hosted sombra. Works like `key_file`.",
+			},
+			"internal_sombra_key_command": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				ConflictsWith: []string{"internal_sombra_key_file"},
+				Description:   "A command that prints the API Key to use to talk to a self-hosted sombra. Works like `key_command`.",
+			},
 			"internal_sombra_url": {
 				Type:        schema.TypeString,
 				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("TRANSCEND_INTERNAL_SOMBRA_URL", nil),
 				Description: "If set, this URL will be used for sombra operations instead of querying the backend. Useful for reverse proxy instances.",
 			},
+			"max_retries": {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Default:          defaultMaxRetries,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(0)),
+				Description:      "The maximum number of times a request to the backend or sombra is retried after a rate limit, gateway error or dropped connection. GraphQL mutations are only retried when the backend reports that the request was not processed. Set to 0 to disable retries.",
+			},
+			"max_retry_wait_seconds": {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Default:          int(defaultMaxRetryWait / time.Second),
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+				Description:      "The maximum number of seconds to wait between two attempts of the same request. Requests whose Retry-After header asks for a longer wait are not retried.",
+			},
+			"max_concurrent_requests": {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Default:          defaultMaxConcurrentRequests,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(0)),
+				Description:      "The maximum number of GraphQL requests sent to the backend at once. When every slot is taken, requests wait their turn, alternating between the different kinds of operations so that a burst of one of them does not hold up the others. Set to 0 to disable the limit.",
+			},
+			"max_concurrent_sombra_requests": {
+				Type:             schema.TypeInt,
+				Optional:         true,
+				Default:          defaultMaxConcurrentSombraRequests,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(0)),
+				Description:      "The maximum number of requests sent to sombra at once. Set to 0 to disable the limit.",
+			},
+			"max_requests_per_second": {
+				Type:             schema.TypeFloat,
+				Optional:         true,
+				Default:          0,
+				ValidateDiagFunc: validation.ToDiagFunc(validation.FloatAtLeast(0)),
+				Description:      "The sustained number of GraphQL requests sent to the backend per second. Bursts of up to one second worth of requests are let through. Defaults to 0, which disables rate limiting.",
+			},
+			"saas_context_encryption": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				DefaultFunc:      schema.EnvDefaultFunc("TRANSCEND_SAAS_CONTEXT_ENCRYPTION", saasContextEncryptionAuto),
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{saasContextEncryptionAuto, saasContextEncryptionRequired, saasContextEncryptionDisabled}, false)),
+				Description:      "How data silo credentials are sent to sombra. With `auto`, the SaaS context is encrypted with a key agreed with sombra through a Diffie-Hellman key exchange whenever sombra serves its public key, so that only sombra can read it as it goes through the backend. Only when sombra answers that it has no public key route, with a 404 or 501 response, does it fall back to the unencrypted flow, which only TLS protects, logging a warning. Any other failure to fetch the public key, like a network, TLS or proxy error, fails instead. `required` fails instead of falling back. `disabled` always uses the unencrypted flow. Unless `sombra_public_key` is set, the public key of sombra is only authenticated by TLS. Can be set using the TRANSCEND_SAAS_CONTEXT_ENCRYPTION environment variable.",
+			},
+			"sombra_public_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("TRANSCEND_SOMBRA_PUBLIC_KEY", nil),
+				Description: "The base64 encoded X25519 public key of sombra to encrypt SaaS contexts with. When set, the key that sombra serves is not fetched, so that SaaS contexts can only be read by the holder of this key. Ignored when `saas_context_encryption` is `disabled`. Can be set using the TRANSCEND_SOMBRA_PUBLIC_KEY environment variable.",
+			},
+			"backend_transport": transportSchema("backend_transport", "the Transcend backend"),
+			"sombra_transport":  transportSchema("sombra_transport", "sombra, e.g. a self-hosted sombra behind an internal certificate authority"),
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"transcend_api_key":                       resourceAPIKey(),
 			"transcend_data_point":                    resourceDataPoint(),
-			"transcend_enricher":                      resourceEnricher(),
 			"transcend_data_silo":                     resourceDataSilo(),
 			"transcend_data_silo_connection":          resourceDataSiloConnection(),
+			"transcend_data_silo_dependency":          resourceDataSiloDependency(),
 			"transcend_disco_class_scan_config":       resourceDiscoClassScanConfig(),
 			"transcend_data_silo_discovery_plugin":    resourceDataSiloDiscoveryPlugin(),
 			"transcend_schema_discovery_plugin":       resourceSchemaDiscoveryPlugin(),
 			"transcend_content_classification_plugin": resourceContentClassificationPlugin(),
+			"transcend_data_silo_plugin":              resourceDataSiloPlugin(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
 			"transcend_identifier": dataSourceIdentifier(),
@@ -60,14 +189,22 @@ func Provider() *schema.Provider {
 
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	backendUrl := d.Get("url").(string)
-	backendApiKey := d.Get("key").(string)
-	sombraInternalKey := d.Get("internal_sombra_key").(string)
+	apiKeySource := credentialSourceFromConfig(d, "key", "key_file", "key_command")
+	internalKeySource := credentialSourceFromConfig(d, "internal_sombra_key", "internal_sombra_key_file", "internal_sombra_key_command")
 	internalSombraUrl := d.Get("internal_sombra_url").(string)
+	retryConfig := DefaultRetryConfig()
+	retryConfig.MaxRetries = d.Get("max_retries").(int)
+	retryConfig.MaxWait = time.Duration(d.Get("max_retry_wait_seconds").(int)) * time.Second
+	schedulerConfig := SchedulerConfig{
+		MaxConcurrentRequests:       d.Get("max_concurrent_requests").(int),
+		MaxConcurrentSombraRequests: d.Get("max_concurrent_sombra_requests").(int),
+		MaxRequestsPerSecond:        d.Get("max_requests_per_second").(float64),
+	}
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
-	if backendUrl == "" || backendApiKey == "" {
+	if backendUrl == "" || apiKeySource == nil {
 		diags = append(diags, diag.Diagnostic{
 			Severity: diag.Error,
 			Summary:  "Unable to authenticate provider",
@@ -76,6 +213,28 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}
 		return nil, diags
 	}
 
+	// Fetch the keys once up front, so that a broken file or command fails here rather than on the first request
+	credentials := []struct {
+		attribute string
+		source    CredentialSource
+	}{
+		{"key", apiKeySource},
+		{"internal_sombra_key", internalKeySource},
+	}
+	for _, credential := range credentials {
+		if credential.source == nil {
+			continue
+		}
+		if _, err := credential.source.Key(ctx); err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Unable to load the " + credential.attribute + " of the provider",
+				Detail:   err.Error(),
+			})
+			return nil, diags
+		}
+	}
+
 	graphQlUrl, err := url.JoinPath(backendUrl, "/graphql")
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
@@ -86,5 +245,46 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}
 		return nil, diags
 	}
 
-	return NewClientWithSombraUrl(graphQlUrl, backendApiKey, sombraInternalKey, internalSombraUrl), nil
+	var sombraPublicKey []byte
+	if encoded := d.Get("sombra_public_key").(string); encoded != "" {
+		if sombraPublicKey, err = parseSombraPublicKey(encoded); err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid sombra_public_key",
+				Detail:        err.Error(),
+				AttributePath: cty.GetAttrPath("sombra_public_key"),
+			})
+			return nil, diags
+		}
+	}
+
+	transports := map[string]http.RoundTripper{}
+	for _, name := range []string{"backend_transport", "sombra_transport"} {
+		transportConfig, err := readTransportConfig(d, name)
+		if err == nil {
+			transports[name], err = transportConfig.NewTransport()
+		}
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid " + name + " configuration",
+				Detail:        err.Error(),
+				AttributePath: cty.GetAttrPath(name),
+			})
+			return nil, diags
+		}
+	}
+
+	return NewClientWithConfig(ClientConfig{
+		URL:                   graphQlUrl,
+		APIKeySource:          apiKeySource,
+		InternalKeySource:     internalKeySource,
+		InternalSombraURL:     internalSombraUrl,
+		Retry:                 retryConfig,
+		BackendTransport:      transports["backend_transport"],
+		SombraTransport:       transports["sombra_transport"],
+		Scheduler:             schedulerConfig,
+		SaasContextEncryption: d.Get("saas_context_encryption").(string),
+		SombraPublicKey:       sombraPublicKey,
+	}), nil
 }
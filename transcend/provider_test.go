@@ -0,0 +1,117 @@
+package transcend
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
+)
+
+// The terratest based tests talk to a live Transcend organization, so they only run
+// when TF_ACC is set, just like tests written with resource.Test
+func skipUnlessAcceptance(t *testing.T) {
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("Acceptance tests skipped unless env 'TF_ACC' set")
+	}
+}
+
+func testProviderFactories() map[string]func() (tfprotov5.ProviderServer, error) {
+	return map[string]func() (tfprotov5.ProviderServer, error){
+		"transcend": func() (tfprotov5.ProviderServer, error) {
+			providerServer, err := NewProviderServer(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			return providerServer(), nil
+		},
+	}
+}
+
+func TestProvider(t *testing.T) {
+	if err := Provider().InternalValidate(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestProviderServer(t *testing.T) {
+	providerServer, err := NewProviderServer(context.Background())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, err := providerServer().GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, diagnostic := range resp.Diagnostics {
+		t.Errorf("%s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+	if resp.Provider.Block.BlockTypes[0].MaxItems != 1 {
+		t.Errorf("the provider schema should be the one of the SDKv2 provider")
+	}
+	for _, name := range []string{"transcend_api_key", "transcend_enricher", "transcend_data_silo"} {
+		if _, ok := resp.ResourceSchemas[name]; !ok {
+			t.Errorf("%s is not served", name)
+		}
+	}
+}
+
+// Both halves of the provider decode the same provider configuration, so they must declare the same schema
+func TestProviderSchemasMatch(t *testing.T) {
+	sdkProvider := Provider()
+	muxServer, err := tf5muxserver.NewMuxServer(context.Background(),
+		sdkProvider.GRPCProvider,
+		providerserver.NewProtocol5(NewFrameworkProvider(sdkProvider)()),
+	)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, err := muxServer.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, diagnostic := range resp.Diagnostics {
+		t.Errorf("%s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+}
+
+// upgradeSDKState passes state written by the SDKv2 implementation of a resource through the provider server,
+// as Terraform does with the existing state of resources that were ported to terraform-plugin-framework.
+func upgradeSDKState(t *testing.T, typeName string, stateJSON string) map[string]tftypes.Value {
+	providerServer, err := NewProviderServer(context.Background())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	server := providerServer()
+
+	schemaResp, err := server.GetProviderSchema(context.Background(), &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	resp, err := server.UpgradeResourceState(context.Background(), &tfprotov5.UpgradeResourceStateRequest{
+		TypeName: typeName,
+		Version:  0,
+		RawState: &tfprotov5.RawState{JSON: []byte(stateJSON)},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	for _, diagnostic := range resp.Diagnostics {
+		t.Errorf("%s: %s", diagnostic.Summary, diagnostic.Detail)
+	}
+
+	state, err := resp.UpgradedState.Unmarshal(schemaResp.ResourceSchemas[typeName].ValueType())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	attributes := map[string]tftypes.Value{}
+	if err := state.As(&attributes); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return attributes
+}
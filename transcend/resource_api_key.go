@@ -5,55 +5,128 @@ import (
 
 	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	fwtypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	graphql "github.com/hasura/go-graphql-client"
 )
 
-func resourceAPIKey() *schema.Resource {
-	return &schema.Resource{
-		CreateContext: resourceAPIKeyCreate,
-		ReadContext:   resourceAPIKeyRead,
-		UpdateContext: resourceAPIKeyUpdate,
-		DeleteContext: resourceAPIKeyDelete,
-		Schema: map[string]*schema.Schema{
-			"id": &schema.Schema{
-				Type:     schema.TypeString,
+type apiKeyResource struct {
+	client *Client
+}
+
+type apiKeyModel struct {
+	ID        fwtypes.String `tfsdk:"id"`
+	Title     fwtypes.String `tfsdk:"title"`
+	Scopes    fwtypes.List   `tfsdk:"scopes"`
+	DataSilos fwtypes.List   `tfsdk:"data_silos"`
+}
+
+var (
+	_ resource.ResourceWithConfigure    = &apiKeyResource{}
+	_ resource.ResourceWithImportState  = &apiKeyResource{}
+	_ resource.ResourceWithUpgradeState = &apiKeyResource{}
+)
+
+func newAPIKeyResource() resource.Resource {
+	return &apiKeyResource{}
+}
+
+func (r *apiKeyResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_api_key"
+}
+
+func (r *apiKeyResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = apiKeySchema()
+	// Version 0 is the state written by the SDKv2 implementation of this resource
+	resp.Schema.Version = 1
+}
+
+func apiKeySchema() schema.Schema {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
 				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
-			"title": &schema.Schema{
-				Type:        schema.TypeString,
+			"title": schema.StringAttribute{
 				Required:    true,
 				Description: "The title used to identify the API key",
-				ForceNew:    true,
-			},
-			"scopes": &schema.Schema{
-				Type:     schema.TypeList,
-				Optional: true,
-				Elem: &schema.Schema{
-					Type: schema.TypeString,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
 				},
+			},
+			"scopes": schema.ListAttribute{
+				ElementType: fwtypes.StringType,
+				Optional:    true,
 				Description: "The names of the scopes to add",
 			},
-			"data_silos": &schema.Schema{
-				Type:     schema.TypeList,
-				Optional: true,
-				Elem: &schema.Schema{
-					Type: schema.TypeString,
-				},
+			"data_silos": schema.ListAttribute{
+				ElementType: fwtypes.StringType,
+				Optional:    true,
 				Description: "The ids of the data silos to assign to",
 			},
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+	}
+}
+
+func (r *apiKeyResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	priorSchema := apiKeySchema()
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema: &priorSchema,
+			StateUpgrader: func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+				var state apiKeyModel
+				resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+				if resp.Diagnostics.HasError() {
+					return
+				}
+				state.Scopes = nullIfEmptyList(state.Scopes)
+				state.DataSilos = nullIfEmptyList(state.DataSilos)
+				resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+			},
 		},
 	}
 }
 
-func resourceAPIKeyCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*Client)
+func (r *apiKeyResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = frameworkClient(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *apiKeyResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
 
-	var diags diag.Diagnostics
+func (r *apiKeyResource) updatableFields(ctx context.Context, model apiKeyModel) (types.APIKeyUpdatableFields, fwdiag.Diagnostics) {
+	var diags fwdiag.Diagnostics
+	scopes := listToStrings(ctx, model.Scopes, &diags)
+	dataSilos := listToStrings(ctx, model.DataSilos, &diags)
+
+	fields := types.APIKeyUpdatableFields{}
+	for _, scope := range scopes {
+		fields.Scopes = append(fields.Scopes, types.ScopeName(scope))
+	}
+	for _, dataSilo := range dataSilos {
+		fields.DataSilos = append(fields.DataSilos, graphql.ID(dataSilo))
+	}
+	return fields, diags
+}
+
+func (r *apiKeyResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan apiKeyModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	fields, diags := r.updatableFields(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	var mutation struct {
 		CreateApiKey struct {
@@ -62,48 +135,91 @@ func resourceAPIKeyCreate(ctx context.Context, d *schema.ResourceData, m interfa
 	}
 
 	vars := map[string]interface{}{
-		"input": types.MakeApiKeyInput(d),
+		"input": types.ApiKeyInput{
+			Title:                 graphql.String(plan.Title.ValueString()),
+			APIKeyUpdatableFields: fields,
+		},
 	}
 
-	err := client.graphql.Mutate(context.Background(), &mutation, vars, graphql.OperationName("CreateApiKey"))
+	err := r.client.graphql.Mutate(ctx, &mutation, vars, graphql.OperationName("CreateApiKey"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error creating API Key " + d.Get("title").(string),
-			Detail:   err.Error(),
-		})
-		return diags
+		resp.Diagnostics.Append(frameworkErrorDiagnostics("Error creating API Key "+plan.Title.ValueString(), err)...)
+		return
 	}
-	d.SetId(string(mutation.CreateApiKey.APIKey.ID))
+	plan.ID = fwtypes.StringValue(string(mutation.CreateApiKey.APIKey.ID))
 
-	return resourceAPIKeyRead(ctx, d, m)
+	found, diags := r.read(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if found {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	}
 }
 
-func resourceAPIKeyRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*Client)
+// read refreshes the model from the backend, returning false when the API key no longer exists.
+func (r *apiKeyResource) read(ctx context.Context, model *apiKeyModel) (bool, fwdiag.Diagnostics) {
+	var diags fwdiag.Diagnostics
 
 	var query struct {
 		APIKey types.APIKey `graphql:"apiKey(id: $id)"`
 	}
 
 	vars := map[string]interface{}{
-		"id": graphql.ID(d.Get("id").(string)),
+		"id": graphql.ID(model.ID.ValueString()),
 	}
 
-	err := client.graphql.Query(context.Background(), &query, vars, graphql.OperationName("MyQuery"))
+	err := r.client.graphql.Query(ctx, &query, vars, graphql.OperationName("MyQuery"))
 	if err != nil {
-		return diag.FromErr(err)
+		if isNotFoundError(err) {
+			tflog.Warn(ctx, "API Key "+model.Title.ValueString()+" no longer exists, removing it from state", map[string]interface{}{"id": model.ID.ValueString()})
+			return false, diags
+		}
+		diags.Append(frameworkErrorDiagnostics("Error reading API Key "+model.Title.ValueString(), err)...)
+		return false, diags
 	}
 
-	types.ReadApiKeyIntoState(d, query.APIKey)
+	key := query.APIKey
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope.Name)
+	}
+	dataSilos := make([]string, len(key.DataSilos))
+	for i, dataSilo := range key.DataSilos {
+		dataSilos[i] = string(dataSilo.ID)
+	}
 
-	return nil
+	model.Title = fwtypes.StringValue(string(key.Title))
+	model.Scopes = stringsToList(scopes, model.Scopes, &diags)
+	model.DataSilos = stringsToList(dataSilos, model.DataSilos, &diags)
+	return true, diags
 }
 
-func resourceAPIKeyUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*Client)
+func (r *apiKeyResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state apiKeyModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	found, diags := r.read(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !found {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
 
-	var diags diag.Diagnostics
+func (r *apiKeyResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan apiKeyModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	fields, diags := r.updatableFields(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	var mutation struct {
 		UpdateApiKey struct {
@@ -112,25 +228,31 @@ func resourceAPIKeyUpdate(ctx context.Context, d *schema.ResourceData, m interfa
 	}
 
 	vars := map[string]interface{}{
-		"input": types.MakeUpdateApiKeyInput(d),
+		"input": types.UpdateApiKeyInput{
+			ID:                    graphql.String(plan.ID.ValueString()),
+			APIKeyUpdatableFields: fields,
+		},
 	}
 
-	err := client.graphql.Mutate(context.Background(), &mutation, vars, graphql.OperationName("UpdateApiKey"))
+	err := r.client.graphql.Mutate(ctx, &mutation, vars, graphql.OperationName("UpdateApiKey"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error updating API Key " + d.Get("title").(string),
-			Detail:   err.Error(),
-		})
-		return diags
+		resp.Diagnostics.Append(frameworkErrorDiagnostics("Error updating API Key "+plan.Title.ValueString(), err)...)
+		return
 	}
 
-	return resourceAPIKeyRead(ctx, d, m)
+	found, diags := r.read(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if found {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	}
 }
-func resourceAPIKeyDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*Client)
 
-	var diags diag.Diagnostics
+func (r *apiKeyResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state apiKeyModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	var mutation struct {
 		DeleteApiKey struct {
@@ -139,20 +261,12 @@ func resourceAPIKeyDelete(ctx context.Context, d *schema.ResourceData, m interfa
 	}
 
 	vars := map[string]interface{}{
-		"id": graphql.ID(d.Get("id").(string)),
+		"id": graphql.ID(state.ID.ValueString()),
 	}
 
-	err := client.graphql.Mutate(context.Background(), &mutation, vars, graphql.OperationName("DeleteApiKey"))
-	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error deleting API Key " + d.Get("title").(string),
-			Detail:   err.Error(),
-		})
-		return diags
+	err := r.client.graphql.Mutate(ctx, &mutation, vars, graphql.OperationName("DeleteApiKey"))
+	// Objects that were already removed outside of Terraform have nothing left to delete
+	if err != nil && !isNotFoundError(err) {
+		resp.Diagnostics.Append(frameworkErrorDiagnostics("Error deleting API Key "+state.Title.ValueString(), err)...)
 	}
-
-	d.SetId("")
-
-	return nil
 }
@@ -7,6 +7,8 @@ import (
 	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	graphql "github.com/hasura/go-graphql-client"
 	"github.com/stretchr/testify/assert"
 )
@@ -28,6 +30,7 @@ func lookupApiKey(t *testing.T, id string) types.APIKey {
 }
 
 func prepareApiKeyOptions(t *testing.T, vars map[string]interface{}) *terraform.Options {
+	skipUnlessAcceptance(t)
 	defaultVars := map[string]interface{}{"title": t.Name()}
 	for k, v := range vars {
 		defaultVars[k] = v
@@ -93,3 +96,97 @@ func TestCanChangeDataSilos(t *testing.T) {
 	// Ensure that the data silo was recreated so that the API key would have to have been updated
 	assert.NotEqual(t, originalSiloId, newSiloId)
 }
+
+func TestUnitAPIKey(t *testing.T) {
+	backend := newFakeBackend(t)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_api_key" "key" {
+  title  = "unit test key"
+  scopes = ["connectDataSilos"]
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("transcend_api_key.key", "id"),
+					resource.TestCheckResourceAttr("transcend_api_key.key", "title", "unit test key"),
+					resource.TestCheckResourceAttr("transcend_api_key.key", "scopes.#", "1"),
+					resource.TestCheckResourceAttr("transcend_api_key.key", "scopes.0", "connectDataSilos"),
+				),
+			},
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonS3"
+  skip_connecting = true
+}
+
+resource "transcend_api_key" "key" {
+  title      = "unit test key"
+  scopes     = ["makeDataSubjectRequest"]
+  data_silos = [transcend_data_silo.silo.id]
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_api_key.key", "scopes.0", "makeDataSubjectRequest"),
+					resource.TestCheckResourceAttrPair("transcend_api_key.key", "data_silos.0", "transcend_data_silo.silo", "id"),
+				),
+			},
+			{
+				ResourceName:      "transcend_api_key.key",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestUnitAPIKeyDeletedOutsideTerraform(t *testing.T) {
+	backend := newFakeBackend(t)
+	config := backend.providerConfig() + `
+resource "transcend_api_key" "key" {
+  title  = "unit test key"
+  scopes = ["connectDataSilos"]
+}
+`
+	var id string
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.TestCheckResourceAttrWith("transcend_api_key.key", "id", func(value string) error {
+					id = value
+					return nil
+				}),
+			},
+			{
+				// The refresh drops the key from state, so the plan proposes to create it again
+				PreConfig:          func() { backend.deleteOutsideTerraform(backend.apiKeys, id) },
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAPIKeyStateFromSDKProvider(t *testing.T) {
+	state := upgradeSDKState(t, "transcend_api_key", `{
+  "data_silos": [],
+  "id": "key-1",
+  "scopes": ["connectDataSilos", "makeDataSubjectRequest"],
+  "title": "unit test key"
+}`)
+	assert.True(t, state["id"].Equal(tftypes.NewValue(tftypes.String, "key-1")))
+	assert.True(t, state["title"].Equal(tftypes.NewValue(tftypes.String, "unit test key")))
+	assert.True(t, state["scopes"].Equal(tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+		tftypes.NewValue(tftypes.String, "connectDataSilos"),
+		tftypes.NewValue(tftypes.String, "makeDataSubjectRequest"),
+	})))
+	// Unset optional attributes were stored as empty values by the SDK, and are null in configurations
+	assert.True(t, state["data_silos"].IsNull())
+}
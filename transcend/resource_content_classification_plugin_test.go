@@ -11,6 +11,7 @@ import (
 )
 
 func prepareContentClassificationPluginOptions(t *testing.T, vars map[string]interface{}) *terraform.Options {
+	skipUnlessAcceptance(t)
 	defaultVars := map[string]interface{}{"title": t.Name()}
 	for k, v := range vars {
 		defaultVars[k] = v
@@ -48,3 +49,7 @@ func TestCanUseSeparateContentClassificationPluginResource(t *testing.T) {
 	assert.Equal(t, graphql.String(t.Name()), silo.Title)
 	assert.NotEmpty(t, terraform.Output(t, options, "awsExternalId"))
 }
+
+func TestUnitContentClassificationPlugin(t *testing.T) {
+	testUnitStandalonePlugin(t, "transcend_content_classification_plugin")
+}
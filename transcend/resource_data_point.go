@@ -2,9 +2,11 @@ package transcend
 
 import (
 	"context"
+	"time"
 
 	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	graphql "github.com/hasura/go-graphql-client"
@@ -187,6 +189,12 @@ to define which fields should be redacted.`,
 		Importer: &schema.ResourceImporter{
 			StateContext: schema.ImportStatePassthroughContext,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
 	}
 }
 
@@ -205,13 +213,9 @@ func resourceDataPointCreate(ctx context.Context, d *schema.ResourceData, m inte
 		"input": types.MakeUpdateOrCreateDataPointInput(d),
 	}
 
-	err := client.graphql.Mutate(context.Background(), &mutation, vars, graphql.OperationName("UpdateOrCreateDataPoint"))
+	err := client.graphql.Mutate(ctx, &mutation, vars, graphql.OperationName("UpdateOrCreateDataPoint"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error creating Data Point",
-			Detail:   err.Error(),
-		})
+		diags = append(diags, graphQLErrorDiagnostics("Error creating Data Point", err)...)
 		return diags
 	}
 	d.SetId(string(mutation.CreateApiKey.DataPoint.ID))
@@ -233,22 +237,15 @@ func resourceDataPointRead(ctx context.Context, d *schema.ResourceData, m interf
 	dataPointsQueryVars := map[string]interface{}{
 		"id": graphql.ID(d.Get("id").(string)),
 	}
-	err := client.graphql.Query(context.Background(), &dataPointsQuery, dataPointsQueryVars, graphql.OperationName("DataPoints"))
+	err := client.graphql.Query(ctx, &dataPointsQuery, dataPointsQueryVars, graphql.OperationName("DataPoints"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error reading datapoint " + d.Get("name").(string),
-			Detail:   err.Error(),
-		})
-		return diags
+		return readErrorDiagnostics(ctx, d, "Error reading datapoint "+d.Get("name").(string), err)
 	}
+	// The backend filters out ids that no longer exist instead of returning an error
 	if len(dataPointsQuery.DataPoints.Nodes) == 0 {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error reading datapoint " + d.Get("name").(string),
-			Detail:   "Cannot find datapoint.",
-		})
-		return diags
+		tflog.Warn(ctx, "Data point "+d.Get("name").(string)+" no longer exists, removing it from state", map[string]interface{}{"id": d.Id()})
+		d.SetId("")
+		return nil
 	}
 
 	// First query for how many subdatapoints there are
@@ -258,13 +255,9 @@ func resourceDataPointRead(ctx context.Context, d *schema.ResourceData, m interf
 		} `graphql:"subDataPoints(first: 1, filterBy: { dataPoints: [$dataPointId] })"`
 	}
 	totalCountQueryVars := map[string]interface{}{"dataPointId": graphql.ID(d.Get("id").(string))}
-	err = client.graphql.Query(context.Background(), &totalCountQuery, totalCountQueryVars, graphql.OperationName("SubDataPoints"))
+	err = client.graphql.Query(ctx, &totalCountQuery, totalCountQueryVars, graphql.OperationName("SubDataPoints"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error counting subdatapoints for datapoint " + d.Get("id").(string),
-			Detail:   err.Error(),
-		})
+		diags = append(diags, graphQLErrorDiagnostics("Error counting subdatapoints for datapoint "+d.Get("id").(string), err)...)
 		return diags
 	}
 
@@ -285,17 +278,13 @@ func resourceDataPointRead(ctx context.Context, d *schema.ResourceData, m interf
 	}
 	for {
 		err = client.graphql.Query(
-			context.Background(),
+			ctx,
 			&subDataPointsQuery,
 			subDataPointsQueryVars,
 			graphql.OperationName("SubDataPoints"),
 		)
 		if err != nil {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error reading subdatapoints for datapoint " + d.Get("id").(string),
-				Detail:   err.Error(),
-			})
+			diags = append(diags, graphQLErrorDiagnostics("Error reading subdatapoints for datapoint "+d.Get("id").(string), err)...)
 			return diags
 		}
 		allSubDataPoints = append(allSubDataPoints, subDataPointsQuery.DataPoints.Nodes...)
@@ -326,13 +315,9 @@ func resourceDataPointUpdate(ctx context.Context, d *schema.ResourceData, m inte
 		"input": types.MakeUpdateOrCreateDataPointInput(d),
 	}
 
-	err := client.graphql.Mutate(context.Background(), &mutation, vars, graphql.OperationName("UdpateOrCreateDataPoint"))
+	err := client.graphql.Mutate(ctx, &mutation, vars, graphql.OperationName("UdpateOrCreateDataPoint"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error updating Data Point",
-			Detail:   err.Error(),
-		})
+		diags = append(diags, graphQLErrorDiagnostics("Error updating Data Point", err)...)
 		return diags
 	}
 
@@ -357,13 +342,10 @@ func resourceDataPointDelete(ctx context.Context, d *schema.ResourceData, m inte
 		"ids": ids,
 	}
 
-	err := client.graphql.Mutate(context.Background(), &mutation, vars, graphql.OperationName("DeleteDataPoints"))
-	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error deleting datapoint " + d.Get("title").(string),
-			Detail:   err.Error(),
-		})
+	err := client.graphql.Mutate(ctx, &mutation, vars, graphql.OperationName("DeleteDataPoints"))
+	// Objects that were already removed outside of Terraform have nothing left to delete
+	if err != nil && !isNotFoundError(err) {
+		diags = append(diags, graphQLErrorDiagnostics("Error deleting datapoint "+d.Get("title").(string), err)...)
 		return diags
 	}
 
@@ -3,12 +3,15 @@ package transcend
 import (
 	"context"
 	"encoding/json"
+	"regexp"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	graphql "github.com/hasura/go-graphql-client"
 	"github.com/stretchr/testify/assert"
 )
@@ -33,6 +36,7 @@ func lookupDataPoint(t *testing.T, id string) types.DataPoint {
 }
 
 func prepareDataPointOptions(t *testing.T, vars map[string]interface{}) *terraform.Options {
+	skipUnlessAcceptance(t)
 	defaultVars := map[string]interface{}{"name": t.Name(), "title": t.Name()}
 	for k, v := range vars {
 		defaultVars[k] = v
@@ -493,3 +497,128 @@ func TestCanChangeSubDataPointAttributes(t *testing.T) {
 		map[string]interface{}{"key": "Foo", "values": []interface{}{"bar"}},
 	}, properties[0].(map[string]interface{})["attributes"].([]interface{}))
 }
+
+func TestUnitDataPoint(t *testing.T) {
+	backend := newFakeBackend(t)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "server"
+  skip_connecting = true
+}
+
+resource "transcend_data_point" "customer" {
+  data_silo_id = transcend_data_silo.silo.id
+  name         = "customer"
+  title        = "Customer Data"
+  path         = ["public"]
+
+  properties {
+    name        = "email"
+    description = "The customer's email"
+
+    categories {
+      name     = "Email"
+      category = "CONTACT"
+    }
+
+    purposes {
+      name    = "Other"
+      purpose = "ESSENTIAL"
+    }
+  }
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("transcend_data_point.customer", "id"),
+					resource.TestCheckResourceAttr("transcend_data_point.customer", "title", "Customer Data"),
+					resource.TestCheckResourceAttr("transcend_data_point.customer", "path.0", "public"),
+					resource.TestCheckResourceAttr("transcend_data_point.customer", "properties.#", "1"),
+				),
+			},
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "server"
+  skip_connecting = true
+}
+
+resource "transcend_data_point" "customer" {
+  data_silo_id = transcend_data_silo.silo.id
+  name         = "customer"
+  title        = "Customers"
+  description  = "All customers"
+  path         = ["public"]
+
+  properties {
+    name        = "email"
+    description = "The customer's email"
+
+    categories {
+      name     = "Email"
+      category = "CONTACT"
+    }
+
+    attributes {
+      key    = "Retention"
+      values = ["1 year"]
+    }
+  }
+
+  properties {
+    name                              = "name"
+    access_request_visibility_enabled = true
+  }
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_point.customer", "title", "Customers"),
+					resource.TestCheckResourceAttr("transcend_data_point.customer", "description", "All customers"),
+					resource.TestCheckResourceAttr("transcend_data_point.customer", "properties.#", "2"),
+				),
+			},
+			{
+				ResourceName:      "transcend_data_point.customer",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestUnitDataPointCreateTimeout(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.setDelay("UpdateOrCreateDataPoint", time.Minute)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "server"
+  skip_connecting = true
+}
+
+resource "transcend_data_point" "customer" {
+  data_silo_id = transcend_data_silo.silo.id
+  name         = "customer"
+  title        = "Customer Data"
+
+  properties {
+    name = "email"
+  }
+
+  timeouts {
+    create = "1s"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile(`context deadline\s+exceeded`),
+			},
+		},
+	})
+}
@@ -1,18 +1,22 @@
 package transcend
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/url"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 
 	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	graphql "github.com/hasura/go-graphql-client"
 )
 
@@ -23,12 +27,26 @@ func UpdateContextJsonWithAllowedIdentifierPaths(contextJson *types.ContextJson,
 	contextJson.AllowedIdentifierPaths = types.BuildAllowedIdentifierPaths(mappings)
 }
 
+// The values of destroy_behavior
+const (
+	destroyBehaviorDelete  = "delete"
+	destroyBehaviorArchive = "archive"
+)
+
+// The values of on_create_failure
+const (
+	onCreateFailureDelete      = "delete"
+	onCreateFailureKeepTainted = "keep_tainted"
+	onCreateFailureKeep        = "keep"
+)
+
 func resourceDataSilo() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceDataSilosCreate,
 		ReadContext:   resourceDataSilosRead,
 		UpdateContext: resourceDataSilosUpdate,
 		DeleteContext: resourceDataSilosDelete,
+		CustomizeDiff: resourceDataSilosCustomizeDiff,
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:     schema.TypeString,
@@ -86,49 +104,20 @@ func resourceDataSilo() *schema.Resource {
 					},
 				},
 			},
-			// TODO: What if we just had the API here be formItems as a schema.TypeMap and the provider
-			// queried the catalog for if the values should be secret or not? In the statefile, all values would be secretive,
-			// but the provider could separate out plaintext from secret context values and give better error messages if there are
-			// missing fields or if invalid field names are provided.
-			"plaintext_context": {
-				Type:        schema.TypeSet,
-				Optional:    true,
-				Description: "This is where you put non-secretive values that go in the form when connecting a data silo",
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"name": {
-							Type:        schema.TypeString,
-							Required:    true,
-							Description: "The name of the plaintext input",
-						},
-						"value": {
-							Type:        schema.TypeString,
-							Required:    true,
-							Description: "The value of the plaintext input",
-						},
-					},
-				},
-			},
-			"secret_context": {
-				Type:        schema.TypeSet,
-				Optional:    true,
-				Description: "This is where you put values that go in the form when connecting a data silo. In general, most form values are secret context.",
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"name": {
-							Type:        schema.TypeString,
-							Required:    true,
-							Description: "The name of the input",
-						},
-						"value": {
-							Type:        schema.TypeString,
-							Required:    true,
-							Description: "The value of the input in plaintext",
-							Sensitive:   true,
-						},
-					},
+			"form_items": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
 				},
+				Sensitive:     true,
+				ConflictsWith: []string{"plaintext_context", "secret_context"},
+				Description: "The values of the form filled when connecting the data silo, by form item name. The catalog of the integration " +
+					"determines which values are sent as plaintext context and which are encrypted by sombra. " +
+					"Unless skip_connecting is set, every item of one of the integration's forms must be given.",
 			},
+			"plaintext_context": plaintextContextSchema(),
+			"secret_context":    secretContextSchema(),
 			"data_silo_discovery_plugin": {
 				Type:        schema.TypeList,
 				Optional:    true,
@@ -162,6 +151,9 @@ func resourceDataSilo() *schema.Resource {
 							Computed:    true,
 							Description: "The date at which this data silo was last enabled",
 						},
+						"run_on_apply":        runOnApplySchema(),
+						"triggers":            pluginTriggersSchema(),
+						"wait_for_completion": waitForCompletionSchema(),
 					},
 				},
 			},
@@ -182,9 +174,10 @@ func resourceDataSilo() *schema.Resource {
 							Computed: true,
 						},
 						"type": {
-							Type:        schema.TypeString,
-							Optional:    true,
-							Description: "The type of disco class scan config",
+							Type:             schema.TypeString,
+							Optional:         true,
+							Description:      "The type of disco class scan config, one of " + strings.Join(types.DiscoClassScanTypes, ", "),
+							ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice(types.DiscoClassScanTypes, false)),
 						},
 						"schedule_frequency_minutes": {
 							Type:        schema.TypeInt,
@@ -196,6 +189,7 @@ func resourceDataSilo() *schema.Resource {
 							Optional:    true,
 							Description: "The start time when we should start scheduling this disco class scan, in ISO format",
 						},
+						"scan_plugin_config": scanPluginConfigSchema(),
 					},
 				},
 			},
@@ -232,6 +226,9 @@ func resourceDataSilo() *schema.Resource {
 							Computed:    true,
 							Description: "The date at which this data silo was last enabled",
 						},
+						"run_on_apply":        runOnApplySchema(),
+						"triggers":            pluginTriggersSchema(),
+						"wait_for_completion": waitForCompletionSchema(),
 					},
 				},
 			},
@@ -268,6 +265,9 @@ func resourceDataSilo() *schema.Resource {
 							Computed:    true,
 							Description: "The date at which this data silo was last enabled",
 						},
+						"run_on_apply":        runOnApplySchema(),
+						"triggers":            pluginTriggersSchema(),
+						"wait_for_completion": waitForCompletionSchema(),
 					},
 				},
 			},
@@ -304,6 +304,9 @@ func resourceDataSilo() *schema.Resource {
 							Computed:    true,
 							Description: "The date at which this data silo was last enabled",
 						},
+						"run_on_apply":        runOnApplySchema(),
+						"triggers":            pluginTriggersSchema(),
+						"wait_for_completion": waitForCompletionSchema(),
 					},
 				},
 			},
@@ -361,35 +364,44 @@ func resourceDataSilo() *schema.Resource {
 				Computed:    true,
 				Description: "The current state of the integration",
 			},
+			"wait_for_connection": waitForConnectionSchema(),
+			"credentials_hash": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "A salted hash of the credentials last used to connect the data silo, including the identifier paths of its enrichers. The data silo is only reconnected when they change.",
+			},
 			// "api_key_id": &schema.Schema{
 			// 	Type:        schema.TypeString,
 			// 	Optional:    true,
 			// 	Description: "The id of the existing api key to attach to",
 			// },
-			// "identifiers": &schema.Schema{
-			// 	Type:     schema.TypeList,
-			// 	Optional: true,
-			// 	Elem: &schema.Schema{
-			// 		Type: schema.TypeString,
-			// 	},
-			// 	Description: "The names of the identifiers that the data silo should be connected to",
-			// },
-			// "depended_on_data_silo_ids": &schema.Schema{
-			// 	Type:     schema.TypeList,
-			// 	Optional: true,
-			// 	Elem: &schema.Schema{
-			// 		Type: schema.TypeString,
-			// 	},
-			// 	Description: "The IDs of the data silo that this data silo depends on during a deletion request.",
-			// },
-			// "data_subject_block_list_ids": &schema.Schema{
-			// 	Type:     schema.TypeList,
-			// 	Optional: true,
-			// 	Elem: &schema.Schema{
-			// 		Type: schema.TypeString,
-			// 	},
-			// 	Description: "The list of subject IDs to block list from this data silo",
-			// },
+			"identifiers": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Description: "The names of the identifiers that the data silo should be connected to. Left unchanged when not set.",
+			},
+			"depended_on_data_silo_ids": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Description: "The IDs of the data silos that this data silo depends on during a deletion request, e.g. to only delete from a database once a queue has been processed. Cycles are reported when planning. Left unchanged when not set.",
+			},
+			"data_subject_block_list_ids": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Description: "The IDs of the data subjects whose requests this data silo should not process. Left unchanged when not set.",
+			},
 			"owner_emails": {
 				Type:     schema.TypeSet,
 				Optional: true,
@@ -419,9 +431,100 @@ func resourceDataSilo() *schema.Resource {
 				Optional:    true,
 				Description: "Id of sombra instance used to talk to this data silo",
 			},
+			"notes": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Any notes about the data silo. Left unchanged when not set.",
+			},
+			"data_retention_note": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "Notes about how long the vendor retains data. Left unchanged when not set.",
+			},
+			"data_processing_agreement_link": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				Description:      "The URL of the data processing agreement signed with the vendor. Left unchanged when not set.",
+				ValidateDiagFunc: validation.ToDiagFunc(validation.IsURLWithHTTPorHTTPS),
+			},
+			"contact_name": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The name of the contact at the vendor. Left unchanged when not set.",
+			},
+			"contact_email": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The email address of the contact at the vendor. Left unchanged when not set.",
+			},
+			"data_processing_agreement_status": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				Description:      "The status of the data processing agreement with the vendor. One of " + strings.Join(types.DataProcessingAgreementStatuses, ", ") + ". Left unchanged when not set.",
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice(types.DataProcessingAgreementStatuses, false)),
+			},
+			"recommended_for_consent": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: "Whether the data silo is recommended for consent management. Left unchanged when not set.",
+			},
+			"recommended_for_privacy": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: "Whether the data silo is recommended for privacy requests. Left unchanged when not set.",
+			},
+			"has_personal_data": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: "Whether the data silo stores personal data. Left unchanged when not set.",
+			},
+			"deprecation_state": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				Description:      "Whether the data silo is being deprecated. One of " + strings.Join(types.DeprecationStates, ", ") + ". Left unchanged when not set.",
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice(types.DeprecationStates, false)),
+			},
+			"deletion_protection": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When true, destroying the data silo, including replacing it, fails until this is set to false and applied. This guards the data silo, along with its data points and request history, against a destroy caused by a mistake like renaming the resource.",
+			},
+			"destroy_behavior": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Default:          destroyBehaviorDelete,
+				Description:      "What to do with the data silo when it is destroyed. Either \"delete\" to delete it, or \"archive\" to only set it to not live and DEPRECATED, keeping its data points and request history in Transcend.",
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{destroyBehaviorDelete, destroyBehaviorArchive}, false)),
+			},
+			"on_create_failure": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  onCreateFailureDelete,
+				Description: "What to do with a new data silo when a step of configuring it fails, like connecting it or updating its plugins. " +
+					"Either \"delete\" to delete it again, \"keep_tainted\" to keep it as a tainted resource that the next apply replaces, " +
+					"or \"keep\" to keep it as is, reporting the failure as a warning, so that the next apply resumes from the failed step.",
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice([]string{onCreateFailureDelete, onCreateFailureKeepTainted, onCreateFailureKeep}, false)),
+			},
 		},
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importDataSilo,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
 	}
 }
@@ -440,13 +543,9 @@ func resourceDataSilosCreate(ctx context.Context, d *schema.ResourceData, m inte
 	createVars := map[string]interface{}{
 		"dataSilo": types.CreateDataSiloInput(d),
 	}
-	err := client.graphql.Mutate(context.Background(), &createMutation, createVars, graphql.OperationName("CreateDataSilos"))
+	err := client.graphql.Mutate(ctx, &createMutation, createVars, graphql.OperationName("CreateDataSilos"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error connecting to " + d.Get("type").(string),
-			Detail:   "Error when connecting to data silo: " + err.Error(),
-		})
+		diags = append(diags, graphQLErrorDiagnostics("Error connecting to "+d.Get("type").(string), err)...)
 		return diags
 	}
 
@@ -464,6 +563,180 @@ func resourceDataSilosCreate(ctx context.Context, d *schema.ResourceData, m inte
 	return resourceDataSilosUpdate(ctx, d, m)
 }
 
+// The plugin blocks of a data silo, and the type of plugin that each configures
+var dataSiloPluginBlocks = map[string]types.PluginType{
+	"data_silo_discovery_plugin":    "DATA_SILO_DISCOVERY",
+	"schema_discovery_plugin":       "SCHEMA_DISCOVERY",
+	"content_classification_plugin": "CONTENT_CLASSIFICATION",
+}
+
+// The attributes that are checked against the catalog of the integration
+var dataSiloCatalogAttributes = []string{
+	"type", "outer_type", "skip_connecting", "form_items", "plaintext_context", "secret_context",
+	"data_silo_discovery_plugin", "schema_discovery_plugin", "content_classification_plugin",
+}
+
+// A value of the connection form given in the configuration
+type dataSiloFormValue struct {
+	path  string
+	name  string
+	value cty.Value
+}
+
+func resourceDataSilosCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	client := m.(*Client)
+
+	if err := checkDataSiloDependencies(ctx, d, client); err != nil {
+		return err
+	}
+
+	// New data silos are only checked once they have an ID, when applied. Their standalone plugin resources only
+	// know the ID then too, and are checked when Terraform plans them again before applying them.
+	if d.Id() != "" {
+		err := claimEmbeddedPlugins(client, d.Id(), func(block string) []interface{} {
+			return d.Get(block).([]interface{})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.Id() != "" {
+		reconnect, err := dataSiloReconnectPlanned(ctx, client, d)
+		if err != nil {
+			return err
+		}
+		if reconnect {
+			if err := d.SetNewComputed("credentials_hash"); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Check the form values and plugins against the catalog of the integration when planning, so that
+	// mistakes do not surface halfway through an apply
+	if !d.NewValueKnown("type") || !d.NewValueKnown("outer_type") || !d.NewValueKnown("skip_connecting") {
+		return nil
+	}
+	if d.Id() != "" && !d.HasChanges(dataSiloCatalogAttributes...) {
+		return nil
+	}
+	config := d.GetRawConfig()
+	if !config.IsKnown() || config.IsNull() {
+		return nil
+	}
+
+	var formValues []dataSiloFormValue
+	formItemNames := map[string]interface{}{}
+	formItemsKnown := true
+	contextNamesKnown := true
+	if formItems := config.GetAttr("form_items"); !formItems.IsKnown() {
+		formItemsKnown = false
+	} else if !formItems.IsNull() {
+		for it := formItems.ElementIterator(); it.Next(); {
+			key, value := it.Element()
+			name := key.AsString()
+			formItemNames[name] = value
+			formValues = append(formValues, dataSiloFormValue{path: fmt.Sprintf("form_items[%q]", name), name: name, value: value})
+		}
+	}
+	for _, attribute := range []string{"plaintext_context", "secret_context"} {
+		contexts := config.GetAttr(attribute)
+		if !contexts.IsKnown() {
+			contextNamesKnown = false
+			continue
+		}
+		if contexts.IsNull() {
+			continue
+		}
+		for it := contexts.ElementIterator(); it.Next(); {
+			_, context := it.Element()
+			name := context.GetAttr("name")
+			if !name.IsKnown() || name.IsNull() {
+				contextNamesKnown = false
+				continue
+			}
+			formValues = append(formValues, dataSiloFormValue{
+				path:  fmt.Sprintf("%s[name = %q]", attribute, name.AsString()),
+				name:  name.AsString(),
+				value: context.GetAttr("value"),
+			})
+		}
+	}
+
+	var configuredPlugins []string
+	for block := range dataSiloPluginBlocks {
+		if plugins, ok := d.Get(block).([]interface{}); ok && len(plugins) > 0 {
+			configuredPlugins = append(configuredPlugins, block)
+		}
+	}
+	sort.Strings(configuredPlugins)
+
+	if len(formValues) == 0 && len(formItemNames) == 0 && len(configuredPlugins) == 0 {
+		return nil
+	}
+	integrationName := types.GetIntegrationName(d)
+	catalog, err := client.catalog(ctx, integrationName)
+	if err != nil {
+		return fmt.Errorf("error looking up the %s integration in the catalog: %w", integrationName, err)
+	}
+
+	var problems []string
+	if formItemsKnown {
+		if err := types.ValidateFormItems(integrationName, catalog, formItemNames, !d.Get("skip_connecting").(bool)); err != nil {
+			problems = append(problems, "form_items: "+err.Error())
+		}
+	}
+
+	// Integrations without forms in the catalog take free form context
+	catalogFormItems := catalog.FormItems()
+
+	// The plaintext_context and secret_context of a data silo that is connected must fill the required items of
+	// a form as well
+	if formItemsKnown && len(formItemNames) == 0 && contextNamesKnown && !d.Get("skip_connecting").(bool) {
+		contextNames := map[string]interface{}{}
+		for _, formValue := range formValues {
+			if _, ok := catalogFormItems[formValue.name]; ok {
+				contextNames[formValue.name] = formValue.value
+			}
+		}
+		if err := types.ValidateFormItems(integrationName, catalog, contextNames, true); err != nil {
+			problems = append(problems, "plaintext_context, secret_context: "+err.Error())
+		}
+	}
+	plaintextPaths := map[string]bool{}
+	for _, plaintext := range catalog.PlaintextInformation {
+		plaintextPaths[string(plaintext.Path)] = true
+	}
+	for _, formValue := range formValues {
+		item, ok := catalogFormItems[formValue.name]
+		if !ok {
+			if len(catalogFormItems) > 0 && !plaintextPaths[formValue.name] && !strings.HasPrefix(formValue.path, "form_items") {
+				problems = append(problems, fmt.Sprintf("%s: unknown form item %q for the %s integration, expected any of: %s", formValue.path, formValue.name, integrationName, strings.Join(catalog.FormItemNames(), ", ")))
+			}
+			continue
+		}
+		if !formValue.value.IsKnown() || formValue.value.IsNull() {
+			continue
+		}
+		if err := types.ValidateFormItemValue(item, formValue.value.AsString()); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", formValue.path, err.Error()))
+		}
+	}
+
+	// Catalogs that do not list the plugins of the integration are not checked
+	for _, block := range configuredPlugins {
+		if len(catalog.SupportedPlugins) > 0 && !catalog.SupportsPlugin(dataSiloPluginBlocks[block]) {
+			problems = append(problems, fmt.Sprintf("%s: the %s integration does not support the %s plugin", block, integrationName, dataSiloPluginBlocks[block]))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
 func resourceDataSilosRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	client := m.(*Client)
 
@@ -474,9 +747,9 @@ func resourceDataSilosRead(ctx context.Context, d *schema.ResourceData, m interf
 	vars := map[string]interface{}{
 		"id": graphql.String(d.Get("id").(string)),
 	}
-	err := client.graphql.Query(context.Background(), &query, vars, graphql.OperationName("DataSilo"))
+	err := client.graphql.Query(ctx, &query, vars, graphql.OperationName("DataSilo"))
 	if err != nil {
-		return diag.FromErr(err)
+		return readErrorDiagnostics(ctx, d, "Error reading data silo "+d.Get("type").(string), err)
 	}
 	types.ReadDataSiloIntoState(d, query.DataSilo)
 
@@ -485,21 +758,13 @@ func resourceDataSilosRead(ctx context.Context, d *schema.ResourceData, m interf
 	_, contentOk := d.GetOk("content_classification_plugin")
 	_, dataSiloOk := d.GetOk("data_silo_discovery_plugin")
 	if schemaOk || contentOk || dataSiloOk {
-		var pluginQuery struct {
-			Plugins struct {
-				Plugins []types.Plugin
-			} `graphql:"plugins(filterBy: { dataSiloId: $dataSiloId })"`
-		}
-		pluginVars := map[string]interface{}{
-			"dataSiloId": graphql.String(d.Get("id").(string)),
-		}
-		err = client.graphql.Query(context.Background(), &pluginQuery, pluginVars, graphql.OperationName("Plugins"))
+		plugins, err := client.dataSiloPlugins(ctx, d.Get("id").(string))
 		if err != nil {
-			return diag.FromErr(err)
+			return graphQLErrorDiagnostics("Error reading data silo plugins", err)
 		}
 
-		if len(pluginQuery.Plugins.Plugins) > 0 {
-			types.ReadDataSiloPluginsIntoState(d, pluginQuery.Plugins.Plugins)
+		if len(plugins) > 0 {
+			types.ReadDataSiloPluginsIntoState(d, plugins)
 		}
 	}
 
@@ -511,9 +776,9 @@ func resourceDataSilosRead(ctx context.Context, d *schema.ResourceData, m interf
 		discoClassScanConfigVars := map[string]interface{}{
 			"dataSiloId": graphql.ID(d.Get("id").(string)),
 		}
-		err = client.graphql.Query(context.Background(), &discoClassScanConfigQuery, discoClassScanConfigVars, graphql.OperationName("DiscoClassScanConfig"))
+		err = client.graphql.Query(ctx, &discoClassScanConfigQuery, discoClassScanConfigVars, graphql.OperationName("DiscoClassScanConfig"))
 		if err != nil {
-			return diag.FromErr(err)
+			return graphQLErrorDiagnostics("Error reading disco class scan config", err)
 		}
 
 		// Read the disco class scan config into state
@@ -523,290 +788,188 @@ func resourceDataSilosRead(ctx context.Context, d *schema.ResourceData, m interf
 	return nil
 }
 
-func resourceDataSilosUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*Client)
-
-	var diags diag.Diagnostics
-
-	// Perform updates to most fields on the data silo
-	var updateMutation struct {
-		UpdateDataSilos struct {
-			DataSilos []types.DataSilo
-		} `graphql:"updateDataSilos(input: { dataSilos: [$input] })"`
+// checkDataSiloDependencies reports the cycles that the planned depended_on_data_silo_ids would create. New data
+// silos are skipped: other data silos can only depend on them through their ID, which is unknown until they are
+// created. The cycles through them are caught when the data silos that depend on them are planned again while
+// applying, with the ID known.
+func checkDataSiloDependencies(ctx context.Context, d *schema.ResourceDiff, client *Client) error {
+	config := d.GetRawConfig()
+	if d.Id() == "" || !config.IsKnown() || config.IsNull() {
+		return nil
 	}
-	updateVars := map[string]interface{}{
-		"input": types.UpdateDataSiloInput{
-			Id:                      graphql.ID(d.Get("id").(string)),
-			DataSiloUpdatableFields: types.CreateDataSiloUpdatableFields(d),
-		},
+	dependencies := config.GetAttr("depended_on_data_silo_ids")
+	if dependencies.IsNull() {
+		client.dependencies.forget(d.Id())
+		return nil
+	}
+	if !dependencies.IsWhollyKnown() {
+		return nil
+	}
+	var dependsOn []string
+	for it := dependencies.ElementIterator(); it.Next(); {
+		_, id := it.Element()
+		dependsOn = append(dependsOn, id.AsString())
 	}
-	err := client.graphql.Mutate(context.Background(), &updateMutation, updateVars, graphql.OperationName("UpdateDataSilos"))
+	client.dependencies.plan(d.Id(), dependsOn)
+
+	cycle, err := client.findDependencyCycle(ctx, d.Id(), dependsOn)
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error updating data silos",
-			Detail:   "Error when updating data silo: " + err.Error(),
-		})
-		if d.IsNewResource() {
-			deletionDiags := resourceDataSilosDelete(ctx, d, m)
-			if deletionDiags.HasError() {
-				diags = append(diags, deletionDiags...)
-			}
-		}
-		return diags
+		return fmt.Errorf("error looking up the dependencies of the data silos: %w", err)
+	}
+	if cycle != nil {
+		return fmt.Errorf("depended_on_data_silo_ids: the dependencies of the data silos would form a cycle: %s", client.describeDependencyCycle(ctx, cycle))
 	}
+	return nil
+}
 
-	var allowedIdentifierPaths []types.AllowedIdentifierPath
-	if len(updateMutation.UpdateDataSilos.DataSilos) > 0 {
-		silo := updateMutation.UpdateDataSilos.DataSilos[0]
-		// Directly access the field if it exists
-		if silo.EnricherIdentifierMappings != nil {
-			allowedIdentifierPaths = types.BuildAllowedIdentifierPaths(silo.EnricherIdentifierMappings)
+// resolveIdentifierIDs looks up the IDs of identifiers by name.
+func resolveIdentifierIDs(ctx context.Context, client *Client, names *schema.Set) ([]graphql.String, error) {
+	sorted := make([]string, names.Len())
+	for i, name := range names.List() {
+		sorted[i] = name.(string)
+	}
+	sort.Strings(sorted)
+
+	ids := make([]graphql.String, len(sorted))
+	for i, name := range sorted {
+		identifiers, err := client.identifiersByText(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, identifier := range identifiers {
+			if string(identifier.Name) == name {
+				ids[i] = identifier.ID
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no identifier is named %q", name)
 		}
 	}
+	return ids, nil
+}
 
-	// Presign the SaaS context if the integration has secrets
-	// For Internal Transcend Folks, see: https://docs.google.com/document/d/1PURNdW7VI9r9kwDM4fud9Hx_58vZbhMhB8OPEYxl8O4/view#
-	var saasContext []byte
+// The attributes sent in the updateDataSilos mutation
+var dataSiloFieldAttributes = []string{
+	"title", "description", "url", "notify_email_address", "is_live", "owner_emails", "owner_teams", "headers", "sombra_id",
+	"notes", "data_retention_note", "data_processing_agreement_link", "contact_name", "contact_email",
+	"data_processing_agreement_status", "recommended_for_consent", "recommended_for_privacy", "has_personal_data",
+	"deprecation_state", "identifiers", "depended_on_data_silo_ids", "data_subject_block_list_ids",
+}
 
-	if d.Get("secret_context") != nil {
-		// Allow override of sombra URL from provider settings
-		var sombraCustomerUrl string
-		if client.internalSombraUrl != "" {
-			sombraCustomerUrl = client.internalSombraUrl
-		} else {
-			// Lookup the sombra URL to talk to
-			var queryBySombraId struct {
-				Sombras []types.SombraOutput `graphql:"sombras(filterBy: {ids: [$sombra_id]})"`
-			}
-			var queryPrimarySombra struct {
-				Organization struct {
-					Sombra struct {
-						CustomerUrl  graphql.String `graphql:"customerUrl"`
-						HostedMethod graphql.String `graphql:"hostedMethod"`
-					} `graphql:"sombra"`
-				} `graphql:"organization"`
-			}
+// The steps of configuring a data silo, in the order resourceDataSilosUpdate applies them
+const (
+	dataSiloFieldsStep = iota
+	dataSiloConnectionStep
+	dataSiloWaitForConnectionStep
+	dataSiloPluginsStep
+	dataSiloDiscoClassScanConfigStep
+)
 
-			var err error
-			sombraId := d.Get("sombra_id")
-			if sombraId == nil || sombraId == "" {
-				err = client.graphql.Query(context.Background(), &queryPrimarySombra, map[string]interface{}{}, graphql.OperationName("SombraUrlQuery"))
-			} else {
-				var queryBySombraIdVars = map[string]interface{}{"sombra_id": sombraId.(string)}
-				err = client.graphql.Query(context.Background(), &queryBySombraId, queryBySombraIdVars, graphql.OperationName("SombraUrlQuery"))
-			}
+// The attributes applied by each step of configuring a data silo
+var dataSiloStepAttributes = map[int][]string{
+	dataSiloFieldsStep:               dataSiloFieldAttributes,
+	dataSiloConnectionStep:           {"skip_connecting", "form_items", "plaintext_context", "secret_context"},
+	dataSiloWaitForConnectionStep:    {"wait_for_connection"},
+	dataSiloPluginsStep:              {"data_silo_discovery_plugin", "schema_discovery_plugin", "content_classification_plugin"},
+	dataSiloDiscoClassScanConfigStep: {"disco_class_scan_config"},
+}
 
-			if err != nil {
-				diags = append(diags, diag.Diagnostic{
-					Severity: diag.Error,
-					Summary:  "Error Finding sombra URL",
-					Detail:   "Error when updating data silo: " + err.Error(),
-				})
-				if d.IsNewResource() {
-					deletionDiags := resourceDataSilosDelete(ctx, d, m)
-					if deletionDiags.HasError() {
-						diags = append(diags, deletionDiags...)
-					}
-				}
-				return diags
-			}
+func resourceDataSilosUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+	defer client.dependencies.forget(d.Id())
 
-			// Set sombra customer url
-			if sombraId == nil || sombraId == "" {
-				sombraCustomerUrl = string(queryPrimarySombra.Organization.Sombra.CustomerUrl)
-			} else {
-				sombraCustomerUrl = string(queryBySombraId.Sombras[0].CustomerUrl)
-			}
-		}
+	// Perform updates to most fields on the data silo
+	silo, diags := updateDataSiloFields(ctx, d, client)
+	if diags.HasError() {
+		return abortDataSiloUpdate(ctx, d, m, dataSiloFieldsStep, diags)
+	}
 
-		// Lookup the saas context metadata
-		var catalogQuery struct {
-			Catalog struct {
-				Catalog types.Catalog `json:"catalog"`
-			} `graphql:"catalog(input: { integrationName: $integrationName })"`
-		}
-		err = client.graphql.Query(context.Background(), &catalogQuery, map[string]interface{}{
-			"integrationName": graphql.String(types.GetIntegrationName(d)),
-		}, graphql.OperationName("catalog"))
-		if err != nil {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error Finding saas context metadata",
-				Detail:   "Error when updating data silo: " + err.Error(),
-			})
-			if d.IsNewResource() {
-				deletionDiags := resourceDataSilosDelete(ctx, d, m)
-				if deletionDiags.HasError() {
-					diags = append(diags, deletionDiags...)
-				}
-			}
-			return diags
-		}
-		// Have sombra encrypt the secret map and parse the resulting saas context
-		allowedBaseHosts := catalogQuery.Catalog.Catalog.IntegrationConfig.ConfiguredBaseHosts.PROD
-		jsonBody, err := types.ConstructSecretMapString(d, allowedBaseHosts, catalogQuery.Catalog.Catalog.PlaintextInformation, allowedIdentifierPaths)
-		if err != nil {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error encoding secret map to create saas context",
-				Detail:   "Error when updating data silo: " + err.Error(),
-			})
-			if d.IsNewResource() {
-				deletionDiags := resourceDataSilosDelete(ctx, d, m)
-				if deletionDiags.HasError() {
-					diags = append(diags, deletionDiags...)
-				}
-			}
-			return diags
-		}
-		registerSaasEndpoint, err := url.JoinPath(sombraCustomerUrl, "/v1/register-saas")
-		if err != nil {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error constructing sombra url for the register saas route",
-				Detail:   "Details: " + err.Error(),
-			})
-			if d.IsNewResource() {
-				deletionDiags := resourceDataSilosDelete(ctx, d, m)
-				if deletionDiags.HasError() {
-					diags = append(diags, deletionDiags...)
-				}
-			}
-			return diags
-		}
-		sombraResponse, err := client.sombraClient.Post(registerSaasEndpoint, "application/json", bytes.NewReader(jsonBody))
-		if err != nil {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error creating SaaS context for the secret values",
-				Detail:   "Error when updating data silo: " + err.Error(),
-			})
-			if d.IsNewResource() {
-				deletionDiags := resourceDataSilosDelete(ctx, d, m)
-				if deletionDiags.HasError() {
-					diags = append(diags, deletionDiags...)
-				}
-			}
-			return diags
-		}
-		if sombraResponse.StatusCode != 200 {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error creating SaaS context for the secret values. Is the internal sombra down?",
-				Detail:   "Error when updating data silo: received non-200 response from sombra",
-			})
-			if d.IsNewResource() {
-				deletionDiags := resourceDataSilosDelete(ctx, d, m)
-				if deletionDiags.HasError() {
-					diags = append(diags, deletionDiags...)
-				}
+	// Optionally attempt to connect the data silo, setting the form fields on success. Every connection tests the
+	// credentials against the vendor's API, so this is only done again when the credentials change.
+	connected := false
+	if !d.Get("skip_connecting").(bool) {
+		allowedIdentifierPaths := types.BuildAllowedIdentifierPaths(silo.EnricherIdentifierMappings)
+		credentialsHash := dataSiloCredentialsHash(d.Id(), d, allowedIdentifierPaths)
+		if d.IsNewResource() || dataSiloReconnectApplied(d, credentialsHash) {
+			credentials := dataSiloCredentials{
+				dataSiloID:             d.Id(),
+				integrationName:        types.GetIntegrationName(d),
+				sombraID:               d.Get("sombra_id").(string),
+				plaintextContext:       types.ToPlaintextContextList(d.Get("plaintext_context").(*schema.Set)),
+				secretContext:          types.ToSecretContextMap(d.Get("secret_context").(*schema.Set)),
+				formItems:              d.Get("form_items").(map[string]interface{}),
+				allowedIdentifierPaths: allowedIdentifierPaths,
 			}
-			return diags
-		}
-		saasContext, err = io.ReadAll(sombraResponse.Body)
-		if err != nil {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error reading response of the new SaaS context",
-				Detail:   "Error when updating data silo: " + err.Error(),
-			})
-			if d.IsNewResource() {
-				deletionDiags := resourceDataSilosDelete(ctx, d, m)
-				if deletionDiags.HasError() {
-					diags = append(diags, deletionDiags...)
-				}
-			}
-			return diags
-		}
-		if strings.Contains(string(saasContext), "Client error") {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error creating a new SaaS context",
-				Detail:   string(saasContext),
-			})
-			if d.IsNewResource() {
-				deletionDiags := resourceDataSilosDelete(ctx, d, m)
-				if deletionDiags.HasError() {
-					diags = append(diags, deletionDiags...)
-				}
+			if diags := connectDataSilo(ctx, client, credentials, true); diags.HasError() {
+				return abortDataSiloUpdate(ctx, d, m, dataSiloConnectionStep, diags)
 			}
-			return diags
+			d.Set("credentials_hash", credentialsHash)
+			connected = true
 		}
 	}
 
-	// Optionally attempt to connect the data silo, setting the form fields on success
-	shouldSkipConnecting := d.Get("skip_connecting").(bool)
-	if !shouldSkipConnecting {
-		var connectMutation struct {
-			ReconnectDataSilo struct {
-				DataSilo types.DataSilo
-			} `graphql:"reconnectDataSilo(input: $input, dhEncrypted: $dhEncrypted)"`
-		}
-		connectVars := map[string]interface{}{
-			"input":       types.CreateReconnectDataSiloFields(d, saasContext),
-			"dhEncrypted": graphql.String(""), // This is not needed when no encrypted saas contexts are provided
-		}
-		err = client.graphql.Mutate(context.Background(), &connectMutation, connectVars, graphql.OperationName("ReconnectDataSilo"))
-		if err != nil {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error connecting data silos",
-				Detail:   "Error when connecting data silo: " + err.Error(),
-			})
-			if d.IsNewResource() {
-				deletionDiags := resourceDataSilosDelete(ctx, d, m)
-				if deletionDiags.HasError() {
-					diags = append(diags, deletionDiags...)
-				}
-			}
-			return diags
+	// Wait for the connection before configuring the plugins, which need it. Data silos that were not connected by
+	// this apply are left in whatever state they are in, so that other changes still apply to them. With
+	// skip_connecting, the data silo is connected elsewhere, like by transcend_data_silo_connection, which waits.
+	if connected || (!d.Get("skip_connecting").(bool) && d.HasChange("wait_for_connection")) {
+		if diags := waitForDataSiloConnection(ctx, client, d.Id(), d); diags.HasError() {
+			return abortDataSiloUpdate(ctx, d, m, dataSiloWaitForConnectionStep, diags)
 		}
 	}
 
+	var err error
+
+	// Make sure that no standalone resource manages the same plugins
+	err = claimEmbeddedPlugins(client, d.Id(), func(block string) []interface{} {
+		return d.Get(block).([]interface{})
+	})
+	if err != nil {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Plugin managed by two resources",
+			Detail:   err.Error(),
+		})
+		return abortDataSiloUpdate(ctx, d, m, dataSiloPluginsStep, diags)
+	}
+
 	// Handle the plugin settings if defined
 	if (d.Get("schema_discovery_plugin") != nil && len(d.Get("schema_discovery_plugin").([]interface{})) == 1) || (d.Get("content_classification_plugin") != nil && len(d.Get("content_classification_plugin").([]interface{})) == 1) || (d.Get("data_silo_discovery_plugin") != nil && len(d.Get("data_silo_discovery_plugin").([]interface{})) == 1) || (d.Get("disco_class_scan_config") != nil && len(d.Get("disco_class_scan_config").([]interface{})) == 1) {
 		// Read the data silo plugin information
-		var pluginQuery struct {
-			Plugins struct {
-				Plugins []types.Plugin
-			} `graphql:"plugins(filterBy: { dataSiloId: $dataSiloId })"`
-		}
-		pluginVars := map[string]interface{}{
-			"dataSiloId": graphql.String(d.Get("id").(string)),
-		}
-		err = client.graphql.Query(context.Background(), &pluginQuery, pluginVars, graphql.OperationName("Plugins"))
+		plugins, err := client.dataSiloPlugins(ctx, d.Get("id").(string))
 		if err != nil {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error finding data silo plugin for data silo",
-				Detail:   "Error when reading data silo plugin: " + err.Error(),
-			})
-			return diags
+			diags = append(diags, graphQLErrorDiagnostics("Error finding data silo plugin for data silo", err)...)
+			return abortDataSiloUpdate(ctx, d, m, dataSiloPluginsStep, diags)
 		}
-		if len(pluginQuery.Plugins.Plugins) == 0 {
+		if len(plugins) == 0 {
 			diags = append(diags, diag.Diagnostic{
 				Severity: diag.Error,
 				Summary:  "Error finding exactly any plugin for data silo",
 				Detail:   "Error when reading data silo plugin",
 			})
-			return diags
+			return abortDataSiloUpdate(ctx, d, m, dataSiloPluginsStep, diags)
 		}
 
-		for _, plugin := range pluginQuery.Plugins.Plugins {
+		for _, plugin := range plugins {
 			var updateMutation struct {
 				UpdateDataSiloPlugin struct {
 					Plugin types.Plugin
 				} `graphql:"updateDataSiloPlugin(input: $input)"`
 			}
 
+			var block string
 			var configuration []interface{}
 			switch plugin.Type {
 			case "SCHEMA_DISCOVERY":
+				block = "schema_discovery_plugin"
 				configuration = d.Get("schema_discovery_plugin").([]interface{})
 			case "CONTENT_CLASSIFICATION":
+				block = "content_classification_plugin"
 				configuration = d.Get("content_classification_plugin").([]interface{})
 			case "DATA_SILO_DISCOVERY":
+				block = "data_silo_discovery_plugin"
 				configuration = d.Get("data_silo_discovery_plugin").([]interface{})
 			default:
 				configuration = nil
@@ -819,18 +982,23 @@ func resourceDataSilosUpdate(ctx context.Context, d *schema.ResourceData, m inte
 					Detail:   fmt.Sprintf("No configuration found for plugin type %s.", plugin.Type),
 				})
 			} else {
+				input := types.MakeUpdatePluginInput(d, configuration[0].(map[string]interface{}), plugin.ID)
+				prefix := block + ".0."
+				run := shouldRunPlugin(d, prefix)
+				input.ScheduleNow = graphql.Boolean(run)
 				updateVars := map[string]interface{}{
-					"input": types.MakeUpdatePluginInput(d, configuration[0].(map[string]interface{}), plugin.ID),
+					"input": input,
 				}
 
-				err := client.graphql.Mutate(context.Background(), &updateMutation, updateVars, graphql.OperationName("UpdateDataSiloPlugin"))
+				err := client.graphql.Mutate(ctx, &updateMutation, updateVars, graphql.OperationName("UpdateDataSiloPlugin"))
 				if err != nil {
-					diags = append(diags, diag.Diagnostic{
-						Severity: diag.Error,
-						Summary:  "Error updating data silo plugin",
-						Detail:   "Error when updating data silo plugin: " + err.Error(),
-					})
-					return diags
+					diags = append(diags, graphQLErrorDiagnostics("Error updating data silo plugin", err)...)
+					return abortDataSiloUpdate(ctx, d, m, dataSiloPluginsStep, diags)
+				}
+				if run {
+					if diags := waitForPluginRun(ctx, client, d, prefix, plugin); diags.HasError() {
+						return abortDataSiloUpdate(ctx, d, m, dataSiloPluginsStep, diags)
+					}
 				}
 			}
 		}
@@ -848,14 +1016,10 @@ func resourceDataSilosUpdate(ctx context.Context, d *schema.ResourceData, m inte
 		discoClassScanConfigVars := map[string]interface{}{
 			"dataSiloId": graphql.ID(d.Get("id").(string)),
 		}
-		err = client.graphql.Query(context.Background(), &discoClassScanConfigQuery, discoClassScanConfigVars, graphql.OperationName("DiscoClassScanConfig"))
+		err = client.graphql.Query(ctx, &discoClassScanConfigQuery, discoClassScanConfigVars, graphql.OperationName("DiscoClassScanConfig"))
 		if err != nil {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error finding disco class scan config",
-				Detail:   "Error when reading disco class scan config: " + err.Error(),
-			})
-			return diags
+			diags = append(diags, graphQLErrorDiagnostics("Error finding disco class scan config", err)...)
+			return abortDataSiloUpdate(ctx, d, m, dataSiloDiscoClassScanConfigStep, diags)
 		}
 
 		// Update the disco class scan config
@@ -867,18 +1031,199 @@ func resourceDataSilosUpdate(ctx context.Context, d *schema.ResourceData, m inte
 		updateVars := map[string]interface{}{
 			"input": types.MakeUpdateDiscoClassScanConfigInput(d, discoClassScanConfig, discoClassScanConfigQuery.DiscoClassScanConfig.ID),
 		}
-		err = client.graphql.Mutate(context.Background(), &updateMutation, updateVars, graphql.OperationName("UpdateDiscoClassScanConfig"))
+		err = client.graphql.Mutate(ctx, &updateMutation, updateVars, graphql.OperationName("UpdateDiscoClassScanConfig"))
+		if err != nil {
+			diags = append(diags, graphQLErrorDiagnostics("Error updating disco class scan config", err)...)
+			return abortDataSiloUpdate(ctx, d, m, dataSiloDiscoClassScanConfigStep, diags)
+		}
+	}
+
+	return resourceDataSilosRead(ctx, d, m)
+}
+
+// abortDataSiloUpdate reports a failed step of configuring the data silo. The state only records the steps that
+// were applied, so that the next apply resumes from the failed step. When the data silo was being created,
+// on_create_failure decides whether it is deleted again, kept as tainted, or kept.
+func abortDataSiloUpdate(ctx context.Context, d *schema.ResourceData, m interface{}, failedStep int, diags diag.Diagnostics) diag.Diagnostics {
+	if failedStep == dataSiloFieldsStep && !d.IsNewResource() {
+		// Nothing was applied, so keep the state from before the update
+		d.Partial(true)
+	} else {
+		// New data silos have no state to fall back to, so only the failed and later steps are left out. The type
+		// and the destroy settings are kept, so that the data silo is resumed rather than replaced, and is destroyed
+		// as configured.
+		for step := failedStep; step < len(dataSiloStepAttributes); step++ {
+			for _, attribute := range dataSiloStepAttributes[step] {
+				previous, _ := d.GetChange(attribute)
+				d.Set(attribute, previous)
+			}
+		}
+	}
+	if !d.IsNewResource() {
+		return diags
+	}
+
+	switch d.Get("on_create_failure").(string) {
+	case onCreateFailureKeepTainted:
+		// Terraform taints resources that fail to be created
+		return diags
+	case onCreateFailureKeep:
+		// Errors would taint the data silo, so report them as warnings
+		kept := diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Kept data silo " + d.Id() + " that failed to be configured",
+			Detail:   "The data silo was created, but configuring it failed. Apply again to finish configuring it.",
+		}}
+		for _, failure := range diags {
+			failure.Severity = diag.Warning
+			kept = append(kept, failure)
+		}
+		return kept
+	default:
+		// The data silo never finished being created, so it has no history to archive or protect. The create timeout
+		// may be what failed it, so the deletion gets the delete timeout instead.
+		ctx, cancel := context.WithTimeout(detachedContext{ctx}, d.Timeout(schema.TimeoutDelete))
+		defer cancel()
+		if err := deleteDataSilo(ctx, m.(*Client), d.Id()); err != nil {
+			diags = append(diags, graphQLErrorDiagnostics("Error deleting data silo "+d.Get("type").(string), err)...)
+			return diags
+		}
+		d.SetId("")
+		return diags
+	}
+}
+
+// detachedContext keeps the values of a context, like its logger, without its deadline or cancellation
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// updateDataSiloFields sends the fields of the data silo to the backend when they changed, and returns the
+// data silo as the backend now has it.
+func updateDataSiloFields(ctx context.Context, d *schema.ResourceData, client *Client) (types.DataSilo, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if !d.IsNewResource() && !d.HasChanges(dataSiloFieldAttributes...) {
+		var query struct {
+			DataSilo types.DataSilo `graphql:"dataSilo(id: $id)"`
+		}
+		vars := map[string]interface{}{
+			"id": graphql.String(d.Get("id").(string)),
+		}
+		err := client.graphql.Query(ctx, &query, vars, graphql.OperationName("DataSilo"))
+		if err != nil {
+			diags = append(diags, graphQLErrorDiagnostics("Error reading data silo "+d.Get("type").(string), err)...)
+		}
+		return query.DataSilo, diags
+	}
+
+	var updateMutation struct {
+		UpdateDataSilos struct {
+			DataSilos []types.DataSilo
+		} `graphql:"updateDataSilos(input: { dataSilos: [$input] })"`
+	}
+	fields := types.CreateDataSiloUpdatableFields(d)
+	if !d.GetRawConfig().GetAttr("identifiers").IsNull() {
+		identifierIDs, err := resolveIdentifierIDs(ctx, client, d.Get("identifiers").(*schema.Set))
 		if err != nil {
 			diags = append(diags, diag.Diagnostic{
 				Severity: diag.Error,
-				Summary:  "Error updating disco class scan config",
-				Detail:   "Error when updating disco class scan config: " + err.Error(),
+				Summary:  "Error looking up the identifiers of the data silo",
+				Detail:   err.Error(),
 			})
-			return diags
+			return types.DataSilo{}, diags
+		}
+		fields.Identifiers = &identifierIDs
+	}
+	updateVars := map[string]interface{}{
+		"input": types.UpdateDataSiloInput{
+			Id:                      graphql.ID(d.Get("id").(string)),
+			DataSiloUpdatableFields: fields,
+		},
+	}
+	err := client.graphql.Mutate(ctx, &updateMutation, updateVars, graphql.OperationName("UpdateDataSilos"))
+	if err != nil {
+		diags = append(diags, graphQLErrorDiagnostics("Error updating data silos", err)...)
+		return types.DataSilo{}, diags
+	}
+	if len(updateMutation.UpdateDataSilos.DataSilos) == 0 {
+		return types.DataSilo{}, diags
+	}
+	return updateMutation.UpdateDataSilos.DataSilos[0], diags
+}
+
+// dataSiloReconnectPlanned tells whether applying the plan of an existing data silo connects it again, in which
+// case its credentials_hash changes. Besides the configured credentials, the identifier paths of the enrichers
+// of the data silo go into its SaaS context, so they are looked up to compare the hash.
+func dataSiloReconnectPlanned(ctx context.Context, client *Client, d *schema.ResourceDiff) (bool, error) {
+	if d.HasChanges("plaintext_context", "secret_context", "form_items", "sombra_id", "skip_connecting") {
+		return true, nil
+	}
+	if d.Get("skip_connecting").(bool) {
+		return false, nil
+	}
+	var query struct {
+		DataSilo struct {
+			EnricherIdentifierMappings []types.EnricherIdentifierMapping `json:"enricherIdentifierMappings"`
+		} `graphql:"dataSilo(id: $id)"`
+	}
+	err := client.graphql.Query(ctx, &query, map[string]interface{}{
+		"id": graphql.String(d.Id()),
+	}, graphql.OperationName("DataSiloIdentifierMappings"))
+	if err != nil {
+		return false, fmt.Errorf("error looking up the identifier mappings of data silo %s: %w", d.Id(), err)
+	}
+	allowedIdentifierPaths := types.BuildAllowedIdentifierPaths(query.DataSilo.EnricherIdentifierMappings)
+	return dataSiloCredentialsHash(d.Id(), d, allowedIdentifierPaths) != d.Get("credentials_hash").(string), nil
+}
+
+// dataSiloReconnectApplied tells whether an existing data silo is connected again while applying. It is only
+// done when planned, as credentials_hash must otherwise keep its planned value. Identifier mappings that change
+// between the plan and the apply are therefore picked up by the next apply.
+func dataSiloReconnectApplied(d *schema.ResourceData, credentialsHash string) bool {
+	plan := d.GetRawPlan()
+	if plan.IsNull() || !plan.Type().HasAttribute("credentials_hash") {
+		return credentialsHash != d.Get("credentials_hash").(string)
+	}
+	return !plan.GetAttr("credentials_hash").IsKnown()
+}
+
+// dataSiloCredentialsHash fingerprints everything that goes into the SaaS context registered with sombra, so that
+// changes to the credentials can be detected without storing anything they could be recovered from.
+func dataSiloCredentialsHash(dataSiloID string, d types.ResourceGetter, allowedIdentifierPaths []types.AllowedIdentifierPath) string {
+	contexts := map[string]map[string]string{}
+	for _, attribute := range []string{"plaintext_context", "secret_context"} {
+		contexts[attribute] = map[string]string{}
+		for _, rawContext := range d.Get(attribute).(*schema.Set).List() {
+			context := rawContext.(map[string]interface{})
+			contexts[attribute][context["name"].(string)] = context["value"].(string)
 		}
 	}
+	material, _ := json.Marshal(map[string]interface{}{
+		"plaintextContext":       contexts["plaintext_context"],
+		"secretContext":          contexts["secret_context"],
+		"formItems":              d.Get("form_items"),
+		"sombraId":               d.Get("sombra_id"),
+		"allowedIdentifierPaths": allowedIdentifierPaths,
+	})
 
-	return resourceDataSilosRead(ctx, d, m)
+	// Salt with the data silo ID so the hash cannot be compared across data silos
+	hash := sha256.New()
+	hash.Write([]byte(dataSiloID))
+	hash.Write(material)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Imported data silos start with the default destroy settings, which are not stored in Transcend
+func importDataSilo(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	d.Set("deletion_protection", false)
+	d.Set("destroy_behavior", destroyBehaviorDelete)
+	d.Set("on_create_failure", onCreateFailureDelete)
+	return []*schema.ResourceData{d}, nil
 }
 
 func resourceDataSilosDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -886,29 +1231,62 @@ func resourceDataSilosDelete(ctx context.Context, d *schema.ResourceData, m inte
 
 	var diags diag.Diagnostics
 
-	var mutation struct {
-		DeleteDataSilos struct {
-			Success graphql.Boolean
-		} `graphql:"deleteDataSilos(input: { ids: $ids })"`
+	if d.Get("deletion_protection").(bool) {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Cannot destroy data silo " + d.Id(),
+			Detail:   fmt.Sprintf("The data silo %q has deletion_protection set. Set deletion_protection to false and apply before destroying it.", d.Get("title").(string)),
+		})
+		return diags
 	}
 
-	ids := make([]graphql.ID, 1)
-	ids[0] = graphql.ID(d.Get("id").(string))
+	if d.Get("destroy_behavior").(string) == destroyBehaviorArchive {
+		var mutation struct {
+			UpdateDataSilos struct {
+				DataSilos []struct {
+					ID graphql.String
+				}
+			} `graphql:"updateDataSilos(input: { dataSilos: [$input] })"`
+		}
+		err := client.graphql.Mutate(ctx, &mutation, map[string]interface{}{
+			"input": types.ArchiveDataSiloInput{
+				Id:               graphql.ID(d.Id()),
+				IsLive:           graphql.Boolean(false),
+				DeprecationState: types.DeprecationState("DEPRECATED"),
+			},
+		}, graphql.OperationName("UpdateDataSilos"))
+		if err != nil && !isNotFoundError(err) {
+			diags = append(diags, graphQLErrorDiagnostics("Error archiving data silo "+d.Get("type").(string), err)...)
+			return diags
+		}
 
-	vars := map[string]interface{}{
-		"ids": ids,
+		releaseEmbeddedPlugins(client, d.Id())
+		d.SetId("")
+		return nil
 	}
 
-	err := client.graphql.Mutate(context.Background(), &mutation, vars, graphql.OperationName("DeleteDataSilos"))
-	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error deleting data silo " + d.Get("type").(string),
-			Detail:   err.Error(),
-		})
+	err := deleteDataSilo(ctx, client, d.Id())
+	// Objects that were already removed outside of Terraform have nothing left to delete
+	if err != nil && !isNotFoundError(err) {
+		diags = append(diags, graphQLErrorDiagnostics("Error deleting data silo "+d.Get("type").(string), err)...)
 		return diags
 	}
 
+	releaseEmbeddedPlugins(client, d.Id())
 	d.SetId("")
 	return nil
 }
+
+func deleteDataSilo(ctx context.Context, client *Client, id string) error {
+	var mutation struct {
+		DeleteDataSilos struct {
+			Success graphql.Boolean
+		} `graphql:"deleteDataSilos(input: { ids: $ids })"`
+	}
+
+	vars := map[string]interface{}{
+		"ids": []graphql.ID{graphql.ID(id)},
+	}
+
+	return client.graphql.Mutate(ctx, &mutation, vars, graphql.OperationName("DeleteDataSilos"))
+}
@@ -2,6 +2,7 @@ package transcend
 
 import (
 	"context"
+	"time"
 
 	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 
@@ -26,33 +27,23 @@ func resourceDataSiloConnection() *schema.Resource {
 				Required:    true,
 				Description: "The ID of the data silo to connect",
 			},
-			"plaintext_context": &schema.Schema{
-				Type:        schema.TypeSet,
-				Optional:    true,
-				Description: "This is where you put non-secretive values that go in the form when connecting a data silo",
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"name": &schema.Schema{
-							Type:        schema.TypeString,
-							Required:    true,
-							Description: "The name of the plaintext input",
-						},
-						"value": &schema.Schema{
-							Type:        schema.TypeString,
-							Required:    true,
-							Description: "The value of the plaintext input",
-						},
-					},
-				},
-			},
+			"plaintext_context": plaintextContextSchema(),
+			"secret_context":    secretContextSchema(),
 			"connection_state": &schema.Schema{
 				Type:        schema.TypeString,
 				Computed:    true,
 				Description: "The current state of the integration",
 			},
+			"wait_for_connection": waitForConnectionSchema(),
 		},
 		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+			StateContext: importByDataSiloId,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
 		},
 	}
 }
@@ -72,9 +63,9 @@ func resourceDataSiloConnectionsRead(ctx context.Context, d *schema.ResourceData
 		"id": graphql.String(d.Get("data_silo_id").(string)),
 	}
 
-	err := client.graphql.Query(context.Background(), &query, vars, graphql.OperationName("DataSilo"))
+	err := client.graphql.Query(ctx, &query, vars, graphql.OperationName("DataSilo"))
 	if err != nil {
-		return diag.FromErr(err)
+		return readErrorDiagnostics(ctx, d, "Error reading data silo connection", err)
 	}
 
 	types.ReadDataSiloConnectionIntoState(d, query.DataSilo)
@@ -88,25 +79,38 @@ func resourceDataSiloConnectionsUpdate(ctx context.Context, d *schema.ResourceDa
 
 	var diags diag.Diagnostics
 
-	var connectMutation struct {
-		ReconnectDataSilo struct {
-			DataSilo types.DataSilo
-		} `graphql:"reconnectDataSilo(input: $input, dhEncrypted: $dhEncrypted)"`
+	credentials := dataSiloCredentials{
+		dataSiloID:       d.Get("data_silo_id").(string),
+		plaintextContext: types.ToPlaintextContextList(d.Get("plaintext_context").(*schema.Set)),
+		secretContext:    types.ToSecretContextMap(d.Get("secret_context").(*schema.Set)),
 	}
-	connectVars := map[string]interface{}{
-		"input": types.ReconnectDataSiloInput{
-			DataSiloId:       graphql.String(d.Get("data_silo_id").(string)),
-			PlaintextContext: types.ToPlaintextContextList(d.Get("plaintext_context").(*schema.Set)),
-		},
-		"dhEncrypted": graphql.String(""), // This is not needed when no encrypted saas contexts are provided
+	// The secrets are encrypted by the sombra of the data silo, which needs to know the integration it connects to
+	registerSecrets := len(credentials.secretContext) > 0
+	if registerSecrets {
+		var query struct {
+			DataSilo types.DataSilo `graphql:"dataSilo(id: $id)"`
+		}
+		err := client.graphql.Query(ctx, &query, map[string]interface{}{
+			"id": graphql.String(credentials.dataSiloID),
+		}, graphql.OperationName("DataSilo"))
+		if err != nil {
+			diags = append(diags, graphQLErrorDiagnostics("Error reading data silo "+credentials.dataSiloID, err)...)
+			return diags
+		}
+		credentials.integrationName = string(query.DataSilo.OuterType)
+		if credentials.integrationName == "" {
+			credentials.integrationName = string(query.DataSilo.Type)
+		}
+		credentials.sombraID = string(query.DataSilo.SombraId)
+		credentials.allowedIdentifierPaths = types.BuildAllowedIdentifierPaths(query.DataSilo.EnricherIdentifierMappings)
 	}
-	err := client.graphql.Mutate(context.Background(), &connectMutation, connectVars, graphql.OperationName("ReconnectDataSilo"))
-	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error connecting data silos",
-			Detail:   "Error when connecting data silo: " + err.Error(),
-		})
+	if diags := connectDataSilo(ctx, client, credentials, registerSecrets); diags.HasError() {
+		return diags
+	}
+	d.SetId(d.Get("data_silo_id").(string))
+
+	diags = waitForDataSiloConnection(ctx, client, d.Get("data_silo_id").(string), d)
+	if diags.HasError() {
 		return diags
 	}
 
@@ -0,0 +1,153 @@
+package transcend
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	sdkterraform "github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitDataSiloConnection(t *testing.T) {
+	backend := newFakeBackend(t)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonWebServices"
+  skip_connecting = true
+  lifecycle { ignore_changes = [plaintext_context] }
+}
+
+resource "transcend_data_silo_connection" "connection" {
+  data_silo_id = transcend_data_silo.silo.id
+
+  plaintext_context {
+    name  = "role"
+    value = "TranscendAWSIntegrationRole"
+  }
+
+  plaintext_context {
+    name  = "accountId"
+    value = "590309927493"
+  }
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPair("transcend_data_silo_connection.connection", "id", "transcend_data_silo.silo", "id"),
+					resource.TestCheckResourceAttr("transcend_data_silo_connection.connection", "connection_state", "CONNECTED"),
+					resource.TestCheckResourceAttr("transcend_data_silo_connection.connection", "plaintext_context.#", "2"),
+				),
+			},
+			{
+				ResourceName:      "transcend_data_silo_connection.connection",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloConnectionSecretContext(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.setCatalog("datadog", map[string]interface{}{
+		"integrationName":      "datadog",
+		"plaintextInformation": []interface{}{map[string]interface{}{"path": "queryTemplate"}},
+		"integrationConfig": map[string]interface{}{
+			"configuredBaseHosts": map[string]interface{}{"PROD": []interface{}{"api.datadoghq.com"}},
+		},
+	})
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "datadog"
+  skip_connecting = true
+  lifecycle { ignore_changes = [plaintext_context] }
+}
+
+resource "transcend_data_silo_connection" "connection" {
+  data_silo_id = transcend_data_silo.silo.id
+
+  plaintext_context {
+    name  = "queryTemplate"
+    value = "service:programmatic-remote-seeding AND @email:{{identifier}}"
+  }
+
+  secret_context {
+    name  = "apiKey"
+    value = "dd-api-key"
+  }
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo_connection.connection", "connection_state", "CONNECTED"),
+					func(s *sdkterraform.State) error {
+						silo := backend.getDataSilo(s.RootModule().Resources["transcend_data_silo.silo"].Primary.ID)
+						assert.Equal(t, "presigned-saas-context-1", silo["presignedSaasContext"])
+						saasContexts := backend.getRegisteredSaasContexts()
+						assert.Len(t, saasContexts, 1)
+						saasContext := saasContexts[0]
+						assert.Equal(t, map[string]interface{}{"apiKey": "dd-api-key"}, saasContext["secretMap"])
+						assert.Equal(t, []interface{}{"api.datadoghq.com"}, saasContext["allowedHosts"])
+						assert.Equal(t, []interface{}{"queryTemplate"}, saasContext["allowedPlaintextPaths"])
+						return nil
+					},
+				),
+			},
+			{
+				ResourceName:            "transcend_data_silo_connection.connection",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"secret_context"},
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloConnectionWaitsForTargetStates(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.connectionStates = []string{"PENDING", "INDEXING"}
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonWebServices"
+  skip_connecting = true
+  lifecycle { ignore_changes = [plaintext_context] }
+}
+
+resource "transcend_data_silo_connection" "connection" {
+  data_silo_id = transcend_data_silo.silo.id
+
+  plaintext_context {
+    name  = "role"
+    value = "TranscendAWSIntegrationRole"
+  }
+
+  wait_for_connection {
+    target_states = ["CONNECTED", "INDEXING"]
+    poll_interval = "10ms"
+  }
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo_connection.connection", "connection_state", "INDEXING"),
+					func(s *sdkterraform.State) error {
+						assert.Equal(t, 2, backend.countOperations("DataSiloConnectionState"))
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
@@ -0,0 +1,202 @@
+package transcend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	graphql "github.com/hasura/go-graphql-client"
+)
+
+func resourceDataSiloDependency() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceDataSiloDependencyCreate,
+		ReadContext:   resourceDataSiloDependencyRead,
+		DeleteContext: resourceDataSiloDependencyDelete,
+		CustomizeDiff: resourceDataSiloDependencyCustomizeDiff,
+		Description:   "Makes a data silo wait on another data silo before processing deletion requests. Unlike `depended_on_data_silo_ids`, this only manages a single dependency, leaving the other dependencies of the data silo untouched, so that data silos managed in different workspaces can depend on each other. Do not combine it with `depended_on_data_silo_ids` on the same data silo.",
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"data_silo_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The ID of the data silo that waits on the other data silo",
+			},
+			"depends_on_data_silo_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The ID of the data silo to wait on",
+			},
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: importDataSiloDependency,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+	}
+}
+
+// Dependencies are imported as <data_silo_id>:<depends_on_data_silo_id>
+func importDataSiloDependency(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	dataSiloID, dependsOnID, found := strings.Cut(d.Id(), ":")
+	if !found || dataSiloID == "" || dependsOnID == "" {
+		return nil, fmt.Errorf("expected an ID of the form <data_silo_id>:<depends_on_data_silo_id>, got %q", d.Id())
+	}
+	d.Set("data_silo_id", dataSiloID)
+	d.Set("depends_on_data_silo_id", dependsOnID)
+	return []*schema.ResourceData{d}, nil
+}
+
+// Refuse dependencies that would form a cycle when planning, taking into account the dependencies planned by
+// the other resources of the run
+func resourceDataSiloDependencyCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	client := m.(*Client)
+	if !d.NewValueKnown("data_silo_id") || !d.NewValueKnown("depends_on_data_silo_id") {
+		return nil
+	}
+	dataSiloID := d.Get("data_silo_id").(string)
+	dependsOnID := d.Get("depends_on_data_silo_id").(string)
+	client.dependencies.planEdge(dataSiloID, dependsOnID)
+	return checkDependencyCycle(ctx, client, dataSiloID, dependsOnID)
+}
+
+func checkDependencyCycle(ctx context.Context, client *Client, dataSiloID string, dependsOnID string) error {
+	cycle, err := client.findDependencyCycle(ctx, dataSiloID, []string{dependsOnID})
+	if err != nil {
+		return fmt.Errorf("error looking up the dependencies of the data silos: %w", err)
+	}
+	if cycle != nil {
+		return fmt.Errorf("the dependencies of the data silos would form a cycle: %s", client.describeDependencyCycle(ctx, cycle))
+	}
+	return nil
+}
+
+func resourceDataSiloDependencyCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+	var diags diag.Diagnostics
+
+	dataSiloID := d.Get("data_silo_id").(string)
+	dependsOnID := d.Get("depends_on_data_silo_id").(string)
+	defer client.dependencies.forgetEdge(dataSiloID, dependsOnID)
+	err := updateDataSiloDependencies(ctx, client, dataSiloID, func(dependsOn []string) ([]string, error) {
+		if containsDependency(dependsOn, dependsOnID) {
+			return dependsOn, nil
+		}
+		// Check again against the latest dependencies, which may have changed since planning
+		if err := checkDependencyCycle(ctx, client, dataSiloID, dependsOnID); err != nil {
+			return nil, err
+		}
+		return append(dependsOn, dependsOnID), nil
+	})
+	if err != nil {
+		diags = append(diags, graphQLErrorDiagnostics("Error adding a dependency to data silo "+dataSiloID, err)...)
+		return diags
+	}
+	d.SetId(dataSiloID + ":" + dependsOnID)
+
+	return resourceDataSiloDependencyRead(ctx, d, m)
+}
+
+func resourceDataSiloDependencyRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+
+	dependencies, err := client.dataSiloDependencies(ctx, d.Get("data_silo_id").(string))
+	if err != nil {
+		return readErrorDiagnostics(ctx, d, "Error reading the dependencies of data silo "+d.Get("data_silo_id").(string), err)
+	}
+	if !containsDependency(dependencies.DependsOn, d.Get("depends_on_data_silo_id").(string)) {
+		// The dependency, or one of its data silos, was removed outside of Terraform
+		d.SetId("")
+	}
+	return nil
+}
+
+func resourceDataSiloDependencyDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+	var diags diag.Diagnostics
+
+	dataSiloID := d.Get("data_silo_id").(string)
+	dependsOnID := d.Get("depends_on_data_silo_id").(string)
+	err := updateDataSiloDependencies(ctx, client, dataSiloID, func(dependsOn []string) ([]string, error) {
+		remaining := []string{}
+		for _, id := range dependsOn {
+			if id != dependsOnID {
+				remaining = append(remaining, id)
+			}
+		}
+		return remaining, nil
+	})
+	if err != nil && !isNotFoundError(err) {
+		diags = append(diags, graphQLErrorDiagnostics("Error removing a dependency from data silo "+dataSiloID, err)...)
+	}
+	return diags
+}
+
+// updateDataSiloDependencies replaces the dependencies of a data silo with the result of update, which is given
+// the latest dependencies. Concurrent updates of the same data silo wait for each other.
+func updateDataSiloDependencies(ctx context.Context, client *Client, dataSiloID string, update func(dependsOn []string) ([]string, error)) error {
+	unlock := client.dependencies.lock(dataSiloID)
+	defer unlock()
+
+	client.cache.invalidate(dependenciesCacheKey + dataSiloID)
+	dependencies, err := client.dataSiloDependencies(ctx, dataSiloID)
+	if err != nil {
+		return err
+	}
+	dependsOn, err := update(dependencies.DependsOn)
+	if err != nil {
+		return err
+	}
+	if len(dependsOn) == len(dependencies.DependsOn) && containsDependencies(dependencies.DependsOn, dependsOn) {
+		return nil
+	}
+
+	ids := make([]graphql.String, len(dependsOn))
+	for i, id := range dependsOn {
+		ids[i] = graphql.String(id)
+	}
+	var updateMutation struct {
+		UpdateDataSilos struct {
+			DataSilos []struct {
+				ID graphql.String
+			}
+		} `graphql:"updateDataSilos(input: { dataSilos: [$input] })"`
+	}
+	return client.graphql.Mutate(ctx, &updateMutation, map[string]interface{}{
+		"input": types.UpdateDataSiloDependenciesInput{
+			Id:                    graphql.ID(dataSiloID),
+			DependedOnDataSiloIds: ids,
+		},
+	}, graphql.OperationName("UpdateDataSilos"))
+}
+
+func containsDependency(dependsOn []string, id string) bool {
+	for _, dependency := range dependsOn {
+		if dependency == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsDependencies(dependsOn []string, ids []string) bool {
+	for _, id := range ids {
+		if !containsDependency(dependsOn, id) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package transcend
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	sdkterraform "github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnitDataSiloDependency(t *testing.T) {
+	backend := newFakeBackend(t)
+	silos := backend.providerConfig() + `
+resource "transcend_data_silo" "postgres" {
+  type            = "server"
+  title           = "Postgres"
+  skip_connecting = true
+}
+
+resource "transcend_data_silo" "segment" {
+  type            = "server"
+  title           = "Segment"
+  skip_connecting = true
+}
+
+resource "transcend_data_silo" "kafka" {
+  type            = "server"
+  title           = "Kafka"
+  skip_connecting = true
+}
+
+resource "transcend_data_silo" "mongo" {
+  type            = "server"
+  title           = "Mongo"
+  skip_connecting = true
+}
+`
+	dependency := func(name string, dataSilo string, dependsOn string) string {
+		return `
+resource "transcend_data_silo_dependency" "` + name + `" {
+  data_silo_id            = transcend_data_silo.` + dataSilo + `.id
+  depends_on_data_silo_id = transcend_data_silo.` + dependsOn + `.id
+}
+`
+	}
+	ids := map[string]string{}
+	dependsOn := func(dataSilo string) []interface{} {
+		return backend.getDataSilo(ids[dataSilo])["dependentDataSilos"].([]interface{})
+	}
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				// Both dependencies of Postgres are added at once
+				Config: silos + dependency("postgres_segment", "postgres", "segment") + dependency("postgres_kafka", "postgres", "kafka"),
+				Check: func(s *sdkterraform.State) error {
+					for _, name := range []string{"postgres", "segment", "kafka", "mongo"} {
+						ids[name] = s.RootModule().Resources["transcend_data_silo."+name].Primary.ID
+					}
+					assert.ElementsMatch(t, fakeIDObjects([]string{ids["segment"], ids["kafka"]}), dependsOn("postgres"))
+					assert.Equal(t, ids["postgres"]+":"+ids["segment"], s.RootModule().Resources["transcend_data_silo_dependency.postgres_segment"].Primary.ID)
+					return nil
+				},
+			},
+			{
+				PreConfig: func() {
+					// Managed by another workspace
+					backend.updateDataSilo(ids["segment"], func(silo map[string]interface{}) { silo["dependentDataSilos"] = fakeIDObjects([]string{ids["kafka"]}) })
+				},
+				Config: silos + dependency("postgres_segment", "postgres", "segment"),
+				Check: func(s *sdkterraform.State) error {
+					assert.Equal(t, fakeIDObjects([]string{ids["segment"]}), dependsOn("postgres"))
+					assert.Equal(t, fakeIDObjects([]string{ids["kafka"]}), dependsOn("segment"), "the dependencies of other workspaces should be left alone")
+					return nil
+				},
+			},
+			{
+				Config:      silos + dependency("postgres_segment", "postgres", "segment") + dependency("kafka_postgres", "kafka", "postgres"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`would\s+form\s+a\s+cycle:\s+Kafka\s+\(silo-\d+\)\s+->\s+Postgres\s+\(silo-\d+\)\s+->\s+Segment\s+\(silo-\d+\)\s+->\s+Kafka`),
+			},
+			{
+				// Neither dependency forms a cycle on its own
+				Config:      silos + dependency("postgres_segment", "postgres", "segment") + dependency("mongo_kafka", "mongo", "kafka") + dependency("kafka_mongo", "kafka", "mongo"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`would\s+form\s+a\s+cycle:\s+\w+\s+\(silo-\d+\)\s+->\s+\w+\s+\(silo-\d+\)\s+->\s+\w+`),
+			},
+			{
+				ResourceName:      "transcend_data_silo_dependency.postgres_segment",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:  "transcend_data_silo_dependency.postgres_segment",
+				ImportState:   true,
+				ImportStateId: "silo-1",
+				ExpectError:   regexp.MustCompile(`expected\s+an\s+ID\s+of\s+the\s+form\s+<data_silo_id>:<depends_on_data_silo_id>`),
+			},
+			{
+				PreConfig: func() {
+					backend.updateDataSilo(ids["postgres"], func(silo map[string]interface{}) { silo["dependentDataSilos"] = []interface{}{} })
+				},
+				Config:             silos + dependency("postgres_segment", "postgres", "segment"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloDependencyCycleThroughNewDataSilo(t *testing.T) {
+	backend := newFakeBackend(t)
+	postgres := backend.providerConfig() + `
+resource "transcend_data_silo" "postgres" {
+  type            = "server"
+  title           = "Postgres"
+  skip_connecting = true
+}
+`
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: postgres,
+			},
+			{
+				// The ID of Segment is only known once it is created, so the cycle is caught while applying,
+				// before the dependency is added
+				Config: postgres + `
+resource "transcend_data_silo" "segment" {
+  type                      = "server"
+  title                     = "Segment"
+  skip_connecting           = true
+  depended_on_data_silo_ids = [transcend_data_silo.postgres.id]
+}
+
+resource "transcend_data_silo_dependency" "postgres_segment" {
+  data_silo_id            = transcend_data_silo.postgres.id
+  depends_on_data_silo_id = transcend_data_silo.segment.id
+}
+`,
+				ExpectError: regexp.MustCompile(`would\s+form\s+a\s+cycle:\s+Postgres\s+\(silo-\d+\)\s+->\s+Segment\s+\(silo-\d+\)\s+->\s+Postgres`),
+			},
+			{
+				Config: postgres,
+				Check: func(s *sdkterraform.State) error {
+					silo := backend.getDataSilo(s.RootModule().Resources["transcend_data_silo.postgres"].Primary.ID)
+					assert.Empty(t, silo["dependentDataSilos"])
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestDependencyGraphForgetsAppliedPlans(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.mu.Lock()
+	backend.resolveCreateDataSilos(map[string]interface{}{"input": []interface{}{
+		map[string]interface{}{"name": "server", "title": "Postgres"},
+		map[string]interface{}{"name": "server", "title": "Segment"},
+	}})
+	backend.mu.Unlock()
+	ids := backend.getDataSiloIDs()
+	postgres, segment := ids[0], ids[1]
+	client := NewClientWithConfig(ClientConfig{URL: backend.server.URL + "/graphql", APIToken: "fake-api-key", Retry: DefaultRetryConfig()})
+	ctx := context.Background()
+
+	// Postgres is planned to depend on Segment, by its depended_on_data_silo_ids and then by a dependency
+	client.dependencies.plan(postgres, []string{segment})
+	cycle, err := client.findDependencyCycle(ctx, segment, []string{postgres})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{segment, postgres, segment}, cycle)
+
+	// Once applied, or failed to, the backend is used again
+	client.dependencies.forget(postgres)
+	cycle, err = client.findDependencyCycle(ctx, segment, []string{postgres})
+	assert.Nil(t, err)
+	assert.Nil(t, cycle)
+
+	client.dependencies.planEdge(postgres, segment)
+	cycle, err = client.findDependencyCycle(ctx, segment, []string{postgres})
+	assert.Nil(t, err)
+	assert.NotNil(t, cycle)
+
+	client.dependencies.forgetEdge(postgres, segment)
+	cycle, err = client.findDependencyCycle(ctx, segment, []string{postgres})
+	assert.Nil(t, err)
+	assert.Nil(t, cycle)
+}
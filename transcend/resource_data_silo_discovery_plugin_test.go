@@ -11,6 +11,7 @@ import (
 )
 
 func prepareDataSiloDiscoveryPluginOptions(t *testing.T, vars map[string]interface{}) *terraform.Options {
+	skipUnlessAcceptance(t)
 	defaultVars := map[string]interface{}{"title": t.Name()}
 	for k, v := range vars {
 		defaultVars[k] = v
@@ -48,3 +49,7 @@ func TestCanUseSeparateDataSiloDiscoveryPluginResource(t *testing.T) {
 	assert.Equal(t, graphql.String(t.Name()), silo.Title)
 	assert.NotEmpty(t, terraform.Output(t, options, "awsExternalId"))
 }
+
+func TestUnitDataSiloDiscoveryPlugin(t *testing.T) {
+	testUnitStandalonePlugin(t, "transcend_data_silo_discovery_plugin")
+}
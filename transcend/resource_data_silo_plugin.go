@@ -0,0 +1,316 @@
+package transcend
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	graphql "github.com/hasura/go-graphql-client"
+)
+
+// dataSiloPluginResource implements the resources that manage a plugin of a data silo. The generic
+// transcend_data_silo_plugin takes the type of the plugin as an argument, while the older resources each manage
+// a single type of plugin.
+type dataSiloPluginResource struct {
+	resourceType string
+	pluginType   func(d types.ResourceGetter) types.PluginType
+}
+
+// Plugin types vary by integration, so only their format is checked here
+var validatePluginType = validation.ToDiagFunc(validation.StringMatch(regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`), "expected a plugin type like SCHEMA_DISCOVERY"))
+
+func resourceDataSiloPlugin() *schema.Resource {
+	plugin := dataSiloPluginResource{
+		resourceType: "transcend_data_silo_plugin",
+		pluginType: func(d types.ResourceGetter) types.PluginType {
+			return types.PluginType(d.Get("type").(string))
+		},
+	}
+	resource := plugin.resource()
+	resource.Description = "Configures the schedule of any plugin of a data silo, like its schema discovery or content classification. Plugins come with the data silo, so this resource enables and schedules an existing plugin, and disables it again when destroyed."
+	resource.Schema["data_silo_id"].ForceNew = true
+	resource.Schema["type"] = &schema.Schema{
+		Type:             schema.TypeString,
+		Required:         true,
+		ForceNew:         true,
+		Description:      "The type of the plugin, like SCHEMA_DISCOVERY, CONTENT_CLASSIFICATION or DATA_SILO_DISCOVERY. Any type of plugin that the data silo has is accepted.",
+		ValidateDiagFunc: validatePluginType,
+	}
+	resource.CustomizeDiff = plugin.customizeDiff
+	resource.Importer = &schema.ResourceImporter{
+		StateContext: importDataSiloPlugin,
+	}
+	return resource
+}
+
+// fixedTypeDataSiloPlugin is a plugin resource that always manages the plugin of the given type.
+func fixedTypeDataSiloPlugin(resourceType string, pluginType types.PluginType) *schema.Resource {
+	plugin := dataSiloPluginResource{
+		resourceType: resourceType,
+		pluginType: func(types.ResourceGetter) types.PluginType {
+			return pluginType
+		},
+	}
+	resource := plugin.resource()
+	resource.CustomizeDiff = customizeDiffStandalonePlugin(resourceType, string(pluginType), standalonePluginSettings)
+	resource.Importer = &schema.ResourceImporter{
+		StateContext: func(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+			d.Set("run_on_apply", false)
+			return importByDataSiloId(ctx, d, m)
+		},
+	}
+	return resource
+}
+
+func (p dataSiloPluginResource) resource() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: p.create,
+		ReadContext:   p.read,
+		UpdateContext: p.update,
+		DeleteContext: p.delete,
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"data_silo_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The ID of the data silo to connect",
+			},
+			"enabled": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "State to toggle plugin to",
+			},
+			"schedule_frequency_minutes": {
+				Type:        schema.TypeInt,
+				Required:    true,
+				Description: "The updated frequency with which we should schedule this plugin, in milliseconds",
+			},
+			"schedule_start_at": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The updated start time when we should start scheduling this plugin, in ISO format",
+			},
+			"last_enabled_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The date at which this data silo was last enabled",
+			},
+			"last_run_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The date at which the plugin last ran",
+			},
+			"scheduled_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The date at which the plugin is next scheduled to run",
+			},
+			"error": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The error of the last run of the plugin, if it failed",
+			},
+			"ownership_id":        ownershipIDSchema(),
+			"run_on_apply":        runOnApplySchema(),
+			"triggers":            pluginTriggersSchema(),
+			"wait_for_completion": waitForCompletionSchema(),
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+	}
+}
+
+// Plugins are imported as <data_silo_id>:<type>. Imported plugins start with the default run settings, which
+// are not stored in Transcend.
+func importDataSiloPlugin(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	dataSiloID, pluginType, found := strings.Cut(d.Id(), ":")
+	if !found || dataSiloID == "" || pluginType == "" {
+		return nil, fmt.Errorf("expected an ID of the form <data_silo_id>:<type>, got %q", d.Id())
+	}
+	d.Set("data_silo_id", dataSiloID)
+	d.Set("type", pluginType)
+	d.Set("run_on_apply", false)
+	return []*schema.ResourceData{d}, nil
+}
+
+// Check that the data silo has a plugin of the type when planning, as the types of plugins vary by integration
+func (p dataSiloPluginResource) customizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	client := m.(*Client)
+	if !d.NewValueKnown("data_silo_id") || !d.NewValueKnown("type") {
+		return nil
+	}
+	dataSiloID := d.Get("data_silo_id").(string)
+	pluginType := p.pluginType(d)
+	if err := client.pluginOwnership.claim(dataSiloID, string(pluginType), plannedStandalonePluginOwner(client, p.resourceType, d, standalonePluginSettings)); err != nil {
+		return err
+	}
+	if d.Id() != "" && !d.HasChanges("data_silo_id", "type") {
+		return nil
+	}
+
+	plugins, err := client.dataSiloPlugins(ctx, dataSiloID)
+	if err != nil {
+		return fmt.Errorf("error looking up the plugins of data silo %s: %w", dataSiloID, err)
+	}
+	if len(pluginsOfType(plugins, pluginType)) == 0 {
+		return fmt.Errorf("type: data silo %s has no %s plugin. %s", dataSiloID, pluginType, describePluginTypes(plugins))
+	}
+	return nil
+}
+
+// describePluginTypes lists the types of plugins that a data silo has, for error messages.
+func describePluginTypes(plugins []types.Plugin) string {
+	if len(plugins) == 0 {
+		return "It has no plugins."
+	}
+	names := make([]string, len(plugins))
+	for i, plugin := range plugins {
+		names[i] = string(plugin.Type)
+	}
+	sort.Strings(names)
+	return "Its plugins are of the types " + strings.Join(names, ", ") + "."
+}
+
+func (p dataSiloPluginResource) create(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if diags := newOwnershipID(d); diags.HasError() {
+		return diags
+	}
+	return p.update(ctx, d, m)
+}
+
+func (p dataSiloPluginResource) read(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+	var diags diag.Diagnostics
+
+	pluginType := p.pluginType(d)
+	allPlugins, err := client.dataSiloPlugins(ctx, d.Get("data_silo_id").(string))
+	if err != nil {
+		return readErrorDiagnostics(ctx, d, "Error reading "+describePluginType(pluginType)+" plugin", err)
+	}
+	plugins := pluginsOfType(allPlugins, pluginType)
+
+	if len(plugins) == 1 {
+		types.ReadStandaloneDataSiloPluginIntoState(d, plugins[0])
+		if d.Get("ownership_id").(string) == "" {
+			// Imported resources, and those created by earlier versions of the provider, get one when refreshed
+			if diags := newOwnershipID(d); diags.HasError() {
+				return diags
+			}
+		}
+		d.SetId(string(plugins[0].ID))
+	} else if len(plugins) == 0 {
+		// The data silo, and the plugin with it, was deleted outside of Terraform
+		d.SetId("")
+	} else {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Error querying plugin",
+			Detail:   "Error when querying for data silo plugin: Found unexpected number of plugins",
+		})
+	}
+
+	return diags
+}
+
+func (p dataSiloPluginResource) update(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+	var diags diag.Diagnostics
+
+	pluginType := p.pluginType(d)
+	if diags := claimStandalonePlugin(client, d, p.resourceType, string(pluginType), standalonePluginSettings); diags.HasError() {
+		return diags
+	}
+
+	// Plugins already exist when a data silo is created, they are just disabled.
+	// So here we fetch the ID / settings on the existing plugin
+	// Read the data silo plugin information
+	allPlugins, err := client.dataSiloPlugins(ctx, d.Get("data_silo_id").(string))
+	if err != nil {
+		diags = append(diags, graphQLErrorDiagnostics("Error finding data silo plugin for data silo", err)...)
+		return diags
+	}
+	plugins := pluginsOfType(allPlugins, pluginType)
+	if len(plugins) != 1 {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Error finding exactly one data silo plugin for data silo",
+			Detail:   fmt.Sprintf("Found %d %s plugins on data silo %s. %s", len(plugins), pluginType, d.Get("data_silo_id").(string), describePluginTypes(allPlugins)),
+		})
+		return diags
+	}
+	d.Set("id", plugins[0].ID)
+
+	var updateMutation struct {
+		UpdateDataSiloPlugin struct {
+			Plugin types.Plugin
+		} `graphql:"updateDataSiloPlugin(input: $input)"`
+	}
+	input := types.MakeStandaloneUpdatePluginInput(d)
+	run := shouldRunPlugin(d, "")
+	input.ScheduleNow = graphql.Boolean(run)
+	updateVars := map[string]interface{}{
+		"input": input,
+	}
+	err = client.graphql.Mutate(ctx, &updateMutation, updateVars, graphql.OperationName("UpdateDataSiloPlugin"))
+	if err != nil {
+		diags = append(diags, graphQLErrorDiagnostics("Error updating data silo plugin", err)...)
+		return diags
+	}
+	if run {
+		if diags := waitForPluginRun(ctx, client, d, "", plugins[0]); diags.HasError() {
+			// Keep the previous triggers, so that the next apply runs the plugin again
+			previous, _ := d.GetChange("triggers")
+			d.Set("triggers", previous)
+			return diags
+		}
+	}
+
+	return p.read(ctx, d, m)
+}
+
+// Plugins cannot be deleted, but they can be disabled, so we do that here
+func (p dataSiloPluginResource) delete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*Client)
+	var diags diag.Diagnostics
+	p.read(ctx, d, m)
+
+	var updateMutation struct {
+		UpdateDataSiloPlugin struct {
+			Plugin types.Plugin
+		} `graphql:"updateDataSiloPlugin(input: $input)"`
+	}
+	input := types.MakeStandaloneUpdatePluginInput(d)
+	input.Enabled = false
+	updateVars := map[string]interface{}{
+		"input": input,
+	}
+	err := client.graphql.Mutate(ctx, &updateMutation, updateVars, graphql.OperationName("UpdateDataSiloPlugin"))
+	if err != nil {
+		diags = append(diags, graphQLErrorDiagnostics("Error updating data silo plugin", err)...)
+		return diags
+	}
+	releaseStandalonePlugin(client, d, p.resourceType, string(p.pluginType(d)))
+
+	return nil
+}
+
+// describePluginType turns a plugin type like SCHEMA_DISCOVERY into "schema discovery"
+func describePluginType(pluginType types.PluginType) string {
+	return strings.ToLower(strings.ReplaceAll(string(pluginType), "_", " "))
+}
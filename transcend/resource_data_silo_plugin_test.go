@@ -0,0 +1,260 @@
+package transcend
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	sdkterraform "github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestUnitDataSiloPlugin(t *testing.T) {
+	backend := newFakeBackend(t)
+	// A type of plugin that the provider has no dedicated resource for
+	backend.pluginTypes = append(append([]string{}, fakePluginTypes...), "UNSTRUCTURED_DISCOVERY")
+	config := func(pluginType string, frequency int) string {
+		return backend.providerConfig() + fmt.Sprintf(`
+resource "transcend_data_silo" "silo" {
+  type            = "amazonS3"
+  skip_connecting = true
+}
+
+resource "transcend_data_silo_plugin" "plugin" {
+  data_silo_id               = transcend_data_silo.silo.id
+  type                       = %q
+  schedule_frequency_minutes = %d
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+}
+`, pluginType, frequency)
+	}
+	address := "transcend_data_silo_plugin.plugin"
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config:      config("unstructured discovery", 120),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`expected a plugin type like SCHEMA_DISCOVERY`),
+			},
+			{
+				Config: config("UNSTRUCTURED_DISCOVERY", 120),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet(address, "id"),
+					resource.TestCheckResourceAttr(address, "type", "UNSTRUCTURED_DISCOVERY"),
+					resource.TestCheckResourceAttr(address, "enabled", "true"),
+					resource.TestCheckResourceAttr(address, "schedule_frequency_minutes", "120"),
+					resource.TestCheckResourceAttrSet(address, "last_enabled_at"),
+					resource.TestCheckResourceAttr(address, "error", ""),
+				),
+			},
+			{
+				PreConfig: func() {
+					backend.mu.Lock()
+					defer backend.mu.Unlock()
+					for _, plugin := range backend.plugins {
+						if plugin["type"] == "UNSTRUCTURED_DISCOVERY" {
+							plugin["lastRunAt"] = "2122-09-06T17:51:13.000Z"
+							plugin["scheduledAt"] = "2122-09-07T17:51:13.000Z"
+							plugin["error"] = "Access denied to bucket"
+						}
+					}
+				},
+				Config: config("UNSTRUCTURED_DISCOVERY", 120),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(address, "last_run_at", "2122-09-06T17:51:13.000Z"),
+					resource.TestCheckResourceAttr(address, "scheduled_at", "2122-09-07T17:51:13.000Z"),
+					resource.TestCheckResourceAttr(address, "error", "Access denied to bucket"),
+				),
+			},
+			{
+				Config:      config("DATA_POINT_DISCOVERY", 120),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`has no DATA_POINT_DISCOVERY plugin. Its plugins are of the types\s+CONTENT_CLASSIFICATION, DATA_SILO_DISCOVERY, SCHEMA_DISCOVERY,\s+UNSTRUCTURED_DISCOVERY`),
+			},
+			{
+				ResourceName:            address,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"ownership_id"},
+				ImportStateIdFunc: func(s *sdkterraform.State) (string, error) {
+					return s.RootModule().Resources[address].Primary.Attributes["data_silo_id"] + ":UNSTRUCTURED_DISCOVERY", nil
+				},
+			},
+			{
+				ResourceName:  address,
+				ImportState:   true,
+				ImportStateId: "UNSTRUCTURED_DISCOVERY",
+				ExpectError:   regexp.MustCompile(`expected an ID of the form <data_silo_id>:<type>`),
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloPluginManagedTwice(t *testing.T) {
+	backend := newFakeBackend(t)
+	silo := backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonS3"
+  skip_connecting = true
+}
+
+resource "transcend_data_silo_plugin" "plugin" {
+  data_silo_id               = transcend_data_silo.silo.id
+  type                       = "SCHEMA_DISCOVERY"
+  schedule_frequency_minutes = 120
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+}
+`
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: silo,
+			},
+			{
+				Config: silo + `
+resource "transcend_schema_discovery_plugin" "plugin" {
+  data_silo_id               = transcend_data_silo.silo.id
+  schedule_frequency_minutes = 60
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`the SCHEMA_DISCOVERY plugin of data silo \S+ is managed by both a\s+(transcend_data_silo_plugin\s+resource with[\s\S]+and a\s+transcend_schema_discovery_plugin|transcend_schema_discovery_plugin\s+resource with[\s\S]+and a\s+transcend_data_silo_plugin)\s+resource with`),
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloPluginRunOnApply(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.pluginRunPolls = 2
+	config := func(version string) string {
+		return backend.providerConfig() + fmt.Sprintf(`
+resource "transcend_data_silo" "silo" {
+  type            = "amazonS3"
+  skip_connecting = true
+}
+
+resource "transcend_data_silo_plugin" "plugin" {
+  data_silo_id               = transcend_data_silo.silo.id
+  type                       = "SCHEMA_DISCOVERY"
+  schedule_frequency_minutes = 1440
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+
+  run_on_apply = true
+  triggers = {
+    schema_version = %q
+  }
+  wait_for_completion {
+    poll_interval = "10ms"
+  }
+}
+`, version)
+	}
+	address := "transcend_data_silo_plugin.plugin"
+	checkRuns := func(runs int) resource.TestCheckFunc {
+		return func(s *sdkterraform.State) error {
+			backend.mu.Lock()
+			defer backend.mu.Unlock()
+			if backend.pluginRuns != runs {
+				return fmt.Errorf("expected %d plugin runs, got %d", runs, backend.pluginRuns)
+			}
+			return nil
+		}
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: config("1"),
+				Check: resource.ComposeTestCheckFunc(
+					checkRuns(1),
+					resource.TestCheckResourceAttr(address, "last_run_at", "2122-09-06T18:01:00.000Z"),
+					resource.TestCheckResourceAttr(address, "error", ""),
+				),
+			},
+			{
+				// Applying again without changing the triggers does not run the plugin
+				Config: config("1"),
+				Check:  checkRuns(1),
+			},
+			{
+				Config: config("2"),
+				Check: resource.ComposeTestCheckFunc(
+					checkRuns(2),
+					resource.TestCheckResourceAttr(address, "last_run_at", "2122-09-06T18:02:00.000Z"),
+				),
+			},
+			{
+				PreConfig: func() {
+					backend.mu.Lock()
+					defer backend.mu.Unlock()
+					backend.pluginRunError = "Access denied to the information schema"
+				},
+				Config:      config("3"),
+				ExpectError: regexp.MustCompile(`The SCHEMA_DISCOVERY plugin of data silo \S+ failed at\s+2122-09-06T18:03:00.000Z: Access denied to the information schema`),
+			},
+			{
+				// The failed run is planned again
+				Config:             config("3"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloPluginRunTimeouts(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.pluginRunPolls = 1000
+	config := func(wait string, timeouts string) string {
+		return backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonS3"
+  skip_connecting = true
+}
+
+resource "transcend_data_silo_plugin" "plugin" {
+  data_silo_id               = transcend_data_silo.silo.id
+  type                       = "SCHEMA_DISCOVERY"
+  schedule_frequency_minutes = 1440
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+
+  run_on_apply = true
+  wait_for_completion {
+    poll_interval = "10ms"
+` + wait + `
+  }
+` + timeouts + `
+}
+`
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config:      config(`timeout = "30ms"`, ``),
+				ExpectError: regexp.MustCompile(`Timed\s+out\s+after\s+30ms\s+waiting\s+for\s+the\s+SCHEMA_DISCOVERY\s+plugin\s+of\s+data\s+silo\s+\S+\s+to\s+finish\s+running`),
+			},
+			{
+				// The resource timeout runs out long before the default wait timeout
+				Config: config(``, `
+  timeouts {
+    create = "1s"
+    update = "1s"
+  }
+`),
+				ExpectError: regexp.MustCompile(`The\s+resource\s+timeout\s+ran\s+out\s+after\s+1s\s+waiting\s+for\s+the\s+SCHEMA_DISCOVERY\s+plugin\s+of\s+data\s+silo\s+\S+\s+to\s+finish\s+running,\s+before\s+the\s+wait_for_completion\s+timeout\s+of\s+30m0s`),
+			},
+		},
+	})
+}
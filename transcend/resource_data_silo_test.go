@@ -2,25 +2,34 @@ package transcend
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
 	"os"
+	"regexp"
 	"testing"
 
 	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	sdkterraform "github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	graphql "github.com/hasura/go-graphql-client"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/curve25519"
 )
 
 // Helper to destroy any data silo with a given title before a test runs
 func destroyDataSiloByTitle(t *testing.T, title string) {
+	skipUnlessAcceptance(t)
 	client := getTestClient()
 	var query struct {
 		DataSilos struct {
 			Nodes []struct {
 				ID    graphql.String `json:"id"`
 				Title graphql.String `json:"title"`
-			}  
+			}
 		} `graphql:"dataSilos(filterBy: { titles: [$title] })"`
 	}
 	vars := map[string]interface{}{
@@ -100,6 +109,7 @@ func lookupDataSilo(t *testing.T, id string) types.DataSilo {
 }
 
 func prepareDataSiloOptions(t *testing.T, vars map[string]interface{}) *terraform.Options {
+	skipUnlessAcceptance(t)
 	defaultVars := map[string]interface{}{"title": t.Name()}
 	for k, v := range vars {
 		defaultVars[k] = v
@@ -491,3 +501,1613 @@ func TestOwnerEmailsOrderIndependence(t *testing.T) {
 
 	assert.ElementsMatch(t, outputA, outputB, "Owner emails should be order-independent in state and not change between applies")
 }
+
+func TestUnitDataSilo(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.setCatalog("datadog", map[string]interface{}{
+		"integrationName":      "datadog",
+		"plaintextInformation": []interface{}{map[string]interface{}{"path": "queryTemplate"}},
+		"integrationConfig": map[string]interface{}{
+			"configuredBaseHosts": map[string]interface{}{"PROD": []interface{}{"api.datadoghq.com"}},
+		},
+	})
+	var siloId string
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type                 = "datadog"
+  title                = "Datadog"
+  description          = "Logs"
+  owner_emails         = ["david@transcend.io"]
+  owner_teams          = ["Engineers"]
+  notify_email_address = "privacy@transcend.io"
+  is_live              = true
+
+  headers {
+    name      = "x-custom"
+    value     = "some value"
+    is_secret = false
+  }
+
+  plaintext_context {
+    name  = "queryTemplate"
+    value = "service:programmatic-remote-seeding AND @email:{{identifier}}"
+  }
+
+  secret_context {
+    name  = "apiKey"
+    value = "dd-api-key"
+  }
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("transcend_data_silo.silo", "id"),
+					resource.TestCheckResourceAttrSet("transcend_data_silo.silo", "aws_external_id"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "title", "Datadog"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "connection_state", "CONNECTED"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "owner_emails.#", "1"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "headers.0.name", "x-custom"),
+					func(s *sdkterraform.State) error {
+						siloId = s.RootModule().Resources["transcend_data_silo.silo"].Primary.ID
+						silo := backend.getDataSilo(siloId)
+						assert.Equal(t, "presigned-saas-context-1", silo["presignedSaasContext"])
+						saasContexts := backend.getRegisteredSaasContexts()
+						assert.Len(t, saasContexts, 1)
+						saasContext := saasContexts[0]
+						assert.Equal(t, map[string]interface{}{"apiKey": "dd-api-key"}, saasContext["secretMap"])
+						assert.Equal(t, []interface{}{"api.datadoghq.com"}, saasContext["allowedHosts"])
+						assert.Equal(t, []interface{}{"queryTemplate"}, saasContext["allowedPlaintextPaths"])
+						return nil
+					},
+				),
+			},
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type                 = "datadog"
+  title                = "Datadog Logs"
+  description          = "Application logs"
+  notify_email_address = "privacy@transcend.io"
+  skip_connecting      = true
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "title", "Datadog Logs"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "description", "Application logs"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "owner_emails.#", "0"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "is_live", "false"),
+					func(s *sdkterraform.State) error {
+						assert.Equal(t, siloId, s.RootModule().Resources["transcend_data_silo.silo"].Primary.ID)
+						return nil
+					},
+				),
+			},
+			{
+				ResourceName:            "transcend_data_silo.silo",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"skip_connecting", "credentials_hash"},
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloVendorFields(t *testing.T) {
+	backend := newFakeBackend(t)
+	config := backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "server"
+  skip_connecting = true
+
+  notes                            = "Reviewed by legal"
+  data_retention_note              = "Deleted after 30 days"
+  data_processing_agreement_link   = "https://example.com/dpa.pdf"
+  contact_name                     = "Jane Vendor"
+  contact_email                    = "jane@example.com"
+  data_processing_agreement_status = "SIGNED"
+  recommended_for_consent          = true
+  has_personal_data                = false
+}
+`
+	var id string
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type                             = "server"
+  skip_connecting                  = true
+  data_processing_agreement_status = "SIGNED_BY_VENDOR"
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`expected data_processing_agreement_status to be one of`),
+			},
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "notes", "Reviewed by legal"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "data_processing_agreement_status", "SIGNED"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "has_personal_data", "false"),
+					// Fields that are not configured keep the values set in the admin dashboard
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "recommended_for_privacy", "true"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "deprecation_state", "NOT_DEPRECATED"),
+					func(s *sdkterraform.State) error {
+						id = s.RootModule().Resources["transcend_data_silo.silo"].Primary.ID
+						silo := backend.getDataSilo(id)
+						assert.Equal(t, "Deleted after 30 days", silo["dataRetentionNote"])
+						assert.Equal(t, "https://example.com/dpa.pdf", silo["dataProcessingAgreementLink"])
+						assert.Equal(t, "Jane Vendor", silo["contactName"])
+						assert.Equal(t, "jane@example.com", silo["contactEmail"])
+						assert.Equal(t, true, silo["recommendedForConsent"])
+						return nil
+					},
+				),
+			},
+			{
+				PreConfig: func() {
+					backend.updateDataSilo(id, func(silo map[string]interface{}) {
+						silo["dataProcessingAgreementStatus"] = "UNSIGNED"
+						silo["contactEmail"] = "someone-else@example.com"
+					})
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "contact_email", "jane@example.com"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "data_processing_agreement_status", "SIGNED"),
+				),
+			},
+			{
+				ResourceName:            "transcend_data_silo.silo",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"skip_connecting", "credentials_hash"},
+			},
+			{
+				// Vendor notes that are not configured keep the values set in the admin dashboard
+				PreConfig: func() {
+					backend.updateDataSilo(id, func(silo map[string]interface{}) {
+						silo["notes"] = "Set in the dashboard"
+						silo["contactName"] = "John Vendor"
+					})
+				},
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "server"
+  skip_connecting = true
+  description     = "Configured in Terraform"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "description", "Configured in Terraform"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "notes", "Set in the dashboard"),
+					func(s *sdkterraform.State) error {
+						silo := backend.getDataSilo(id)
+						assert.Equal(t, "Set in the dashboard", silo["notes"])
+						assert.Equal(t, "John Vendor", silo["contactName"])
+						assert.Equal(t, "Deleted after 30 days", silo["dataRetentionNote"])
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloIdentifiersAndDependencies(t *testing.T) {
+	backend := newFakeBackend(t)
+	silos := func(postgres string, segment string) string {
+		return backend.providerConfig() + `
+resource "transcend_data_silo" "postgres" {
+  type            = "server"
+  title           = "Postgres"
+  skip_connecting = true
+` + postgres + `
+}
+
+resource "transcend_data_silo" "segment" {
+  type            = "server"
+  title           = "Segment"
+  skip_connecting = true
+` + segment + `
+}
+`
+	}
+	lookups := `
+data "transcend_data_silo" "postgres" {
+  title = "Postgres"
+}
+
+data "transcend_data_silo" "segment" {
+  title = "Segment"
+}
+`
+	var postgresID, segmentID string
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: silos(`
+  identifiers                 = ["email", "phone"]
+  depended_on_data_silo_ids   = [transcend_data_silo.segment.id]
+  data_subject_block_list_ids = ["subject-employee"]
+`, ``),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckTypeSetElemAttr("transcend_data_silo.postgres", "identifiers.*", "email"),
+					resource.TestCheckTypeSetElemAttr("transcend_data_silo.postgres", "identifiers.*", "phone"),
+					resource.TestCheckTypeSetElemAttrPair("transcend_data_silo.postgres", "depended_on_data_silo_ids.*", "transcend_data_silo.segment", "id"),
+					resource.TestCheckResourceAttr("transcend_data_silo.postgres", "data_subject_block_list_ids.#", "1"),
+					resource.TestCheckResourceAttr("transcend_data_silo.segment", "depended_on_data_silo_ids.#", "0"),
+					func(s *sdkterraform.State) error {
+						postgresID = s.RootModule().Resources["transcend_data_silo.postgres"].Primary.ID
+						segmentID = s.RootModule().Resources["transcend_data_silo.segment"].Primary.ID
+						silo := backend.getDataSilo(postgresID)
+						assert.Equal(t, []interface{}{
+							map[string]interface{}{"id": "identifier-email", "name": "email"},
+							map[string]interface{}{"id": "identifier-phone", "name": "phone"},
+						}, silo["identifiers"], "the identifiers should be sent by ID")
+						return nil
+					},
+				),
+			},
+			{
+				ResourceName:            "transcend_data_silo.postgres",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"skip_connecting", "credentials_hash"},
+			},
+			{
+				PreConfig: func() {
+					backend.updateDataSilo(postgresID, func(silo map[string]interface{}) { silo["identifiers"] = []interface{}{} })
+				},
+				Config: silos(`
+  identifiers                 = ["email", "phone"]
+  depended_on_data_silo_ids   = [transcend_data_silo.segment.id]
+  data_subject_block_list_ids = ["subject-employee"]
+`, ``),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: silos(`
+  identifiers = ["email", "fingerprint"]
+`, ``),
+				ExpectError: regexp.MustCompile(`no identifier is named "fingerprint"`),
+			},
+			{
+				Config: silos(`
+  depended_on_data_silo_ids = [transcend_data_silo.segment.id]
+`, `
+  depended_on_data_silo_ids = ["`+"${data.transcend_data_silo.postgres.id}"+`"]
+`) + lookups,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`would\s+form\s+a\s+cycle:\s+Segment\s+\(silo-\d+\)\s+->\s+Postgres\s+\(silo-\d+\)\s+->\s+Segment`),
+			},
+			{
+				// Neither side of the cycle is applied yet
+				Config: silos(`
+  depended_on_data_silo_ids = [data.transcend_data_silo.segment.id]
+`, `
+  depended_on_data_silo_ids = [data.transcend_data_silo.postgres.id]
+`) + lookups,
+				PreConfig: func() {
+					backend.updateDataSilo(postgresID, func(silo map[string]interface{}) { silo["dependentDataSilos"] = []interface{}{} })
+				},
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`would\s+form\s+a\s+cycle`),
+			},
+			{
+				Config: silos(`
+  depended_on_data_silo_ids = []
+`, `
+  depended_on_data_silo_ids = [transcend_data_silo.postgres.id]
+`),
+				Check: func(s *sdkterraform.State) error {
+					assert.Equal(t, []interface{}{}, backend.getDataSilo(postgresID)["dependentDataSilos"])
+					assert.Equal(t, fakeIDObjects([]string{postgresID}), backend.getDataSilo(segmentID)["dependentDataSilos"])
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloWaitsForConnection(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.connectionStates = []string{"PENDING", "INDEXING", "CONNECTED"}
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type = "server"
+
+  wait_for_connection {
+    target_states = ["LIVE"]
+  }
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`expected\s+\S*target_states\S*\s+to\s+be\s+one\s+of`),
+			},
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type = "server"
+
+  wait_for_connection {
+    poll_interval = "soon"
+  }
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`"soon" is not a positive duration`),
+			},
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type = "server"
+
+  wait_for_connection {
+    poll_interval = "10ms"
+  }
+}
+
+resource "transcend_schema_discovery_plugin" "plugin" {
+  data_silo_id               = transcend_data_silo.silo.id
+  schedule_frequency_minutes = 120
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "connection_state", "CONNECTED"),
+					func(s *sdkterraform.State) error {
+						assert.Equal(t, 3, backend.countOperations("DataSiloConnectionState"), "the state should be polled until the data silo is connected")
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloConnectionErrors(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.connectionStates = []string{"PENDING", "EXPIRED"}
+	backend.connectionError = "The API key was revoked"
+	config := func(wait string) string {
+		return backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type = "server"
+
+  wait_for_connection {
+    poll_interval = "10ms"
+` + wait + `
+  }
+}
+`
+	}
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config:      config(``),
+				ExpectError: regexp.MustCompile(`is\s+EXPIRED\s+instead\s+of\s+CONNECTED.\s+The\s+connection\s+failed\s+with:\s+The\s+API\s+key\s+was\s+revoked`),
+			},
+			{
+				PreConfig: func() {
+					backend.mu.Lock()
+					defer backend.mu.Unlock()
+					backend.connectionStates = []string{"PENDING", "PENDING", "PENDING", "PENDING", "PENDING", "PENDING"}
+				},
+				Config:      config(`timeout = "30ms"`),
+				ExpectError: regexp.MustCompile(`Timed\s+out\s+after\s+30ms\s+waiting\s+for\s+the\s+data\s+silo\s+silo-\d+\s+to\s+be\s+CONNECTED.\s+It\s+is\s+PENDING`),
+			},
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type = "server"
+
+  wait_for_connection {
+    poll_interval = "100ms"
+  }
+
+  timeouts {
+    create = "1s"
+    update = "1s"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile(`The\s+resource\s+timeout\s+ran\s+out\s+after\s+1s\s+waiting\s+for\s+the\s+data\s+silo\s+silo-\d+\s+to\s+be\s+CONNECTED,\s+before\s+the\s+wait_for_connection\s+timeout\s+of\s+10m0s`),
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloOnlyWaitsForItsOwnConnections(t *testing.T) {
+	config := func(backend *fakeBackend, description string, skipConnecting bool) string {
+		return backend.providerConfig() + fmt.Sprintf(`
+resource "transcend_data_silo" "silo" {
+  type            = "server"
+  description     = %q
+  skip_connecting = %t
+
+  wait_for_connection {
+    poll_interval = "10ms"
+    timeout       = "30ms"
+  }
+}
+`, description, skipConnecting)
+	}
+
+	t.Run("skip_connecting", func(t *testing.T) {
+		backend := newFakeBackend(t)
+		resource.UnitTest(t, resource.TestCase{
+			ProtoV5ProviderFactories: testProviderFactories(),
+			CheckDestroy:             backend.checkDestroyed,
+			Steps: []resource.TestStep{
+				{
+					Config: config(backend, "Internal server", true),
+				},
+				{
+					Config: config(backend, "Internal API server", true),
+					Check: func(s *sdkterraform.State) error {
+						assert.Equal(t, 0, backend.countOperations("DataSiloConnectionState"), "a data silo that is not connected should not be waited on")
+						return nil
+					},
+				},
+			},
+		})
+	})
+
+	t.Run("credentials_unchanged", func(t *testing.T) {
+		backend := newFakeBackend(t)
+		var id string
+		resource.UnitTest(t, resource.TestCase{
+			ProtoV5ProviderFactories: testProviderFactories(),
+			CheckDestroy:             backend.checkDestroyed,
+			Steps: []resource.TestStep{
+				{
+					Config: config(backend, "Internal server", false),
+					Check: resource.TestCheckResourceAttrWith("transcend_data_silo.silo", "id", func(value string) error {
+						id = value
+						return nil
+					}),
+				},
+				{
+					PreConfig: func() {
+						backend.updateDataSilo(id, func(silo map[string]interface{}) {
+							silo["connectionState"] = "EXPIRED"
+							silo["connectionError"] = "The API key was revoked"
+						})
+					},
+					// Editing other fields still works on a data silo whose connection broke
+					Config: config(backend, "Internal API server", false),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("transcend_data_silo.silo", "description", "Internal API server"),
+						func(s *sdkterraform.State) error {
+							assert.Equal(t, 1, backend.countOperations("ReconnectDataSilo"))
+							assert.Equal(t, 1, backend.countOperations("DataSiloConnectionState"))
+							return nil
+						},
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestUnitDataSiloOnlyReconnectsWhenCredentialsChange(t *testing.T) {
+	backend := newFakeBackend(t)
+	siloConfig := func(description string, apiKey string) string {
+		return backend.providerConfig() + fmt.Sprintf(`
+resource "transcend_data_silo" "silo" {
+  type        = "datadog"
+  description = %q
+
+  plaintext_context {
+    name  = "queryTemplate"
+    value = "@email:{{identifier}}"
+  }
+
+  secret_context {
+    name  = "apiKey"
+    value = %q
+  }
+}
+`, description, apiKey)
+	}
+	var credentialsHash string
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: siloConfig("Logs", "dd-api-key"),
+				Check: func(s *sdkterraform.State) error {
+					credentialsHash = s.RootModule().Resources["transcend_data_silo.silo"].Primary.Attributes["credentials_hash"]
+					assert.Len(t, credentialsHash, 64)
+					assert.NotContains(t, credentialsHash, "dd-api-key")
+					assert.Equal(t, 1, backend.countOperations("RegisterSaas"))
+					assert.Equal(t, 1, backend.countOperations("ReconnectDataSilo"))
+					return nil
+				},
+			},
+			{
+				Config: siloConfig("Application logs", "dd-api-key"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "description", "Application logs"),
+					func(s *sdkterraform.State) error {
+						assert.Equal(t, credentialsHash, s.RootModule().Resources["transcend_data_silo.silo"].Primary.Attributes["credentials_hash"])
+						assert.Equal(t, 1, backend.countOperations("RegisterSaas"), "editing the description should not register the credentials again")
+						assert.Equal(t, 1, backend.countOperations("ReconnectDataSilo"), "editing the description should not test the credentials again")
+						return nil
+					},
+				),
+			},
+			{
+				Config: siloConfig("Application logs", "rotated-dd-api-key"),
+				Check: func(s *sdkterraform.State) error {
+					assert.NotEqual(t, credentialsHash, s.RootModule().Resources["transcend_data_silo.silo"].Primary.Attributes["credentials_hash"])
+					assert.Equal(t, 2, backend.countOperations("RegisterSaas"))
+					assert.Equal(t, 2, backend.countOperations("ReconnectDataSilo"))
+					assert.Equal(t, map[string]interface{}{"apiKey": "rotated-dd-api-key"}, backend.getRegisteredSaasContexts()[1]["secretMap"])
+					credentialsHash = s.RootModule().Resources["transcend_data_silo.silo"].Primary.Attributes["credentials_hash"]
+					return nil
+				},
+			},
+			{
+				// The identifier paths of enrichers go into the SaaS context too, so new ones connect it again
+				PreConfig: func() {
+					backend.updateDataSilo(backend.getDataSiloIDs()[0], func(silo map[string]interface{}) {
+						silo["enricherIdentifierMappings"] = []interface{}{
+							map[string]interface{}{"identifierName": "email", "paths": []interface{}{"user.email"}},
+						}
+					})
+				},
+				Config: siloConfig("Application logs", "rotated-dd-api-key"),
+				Check: func(s *sdkterraform.State) error {
+					assert.NotEqual(t, credentialsHash, s.RootModule().Resources["transcend_data_silo.silo"].Primary.Attributes["credentials_hash"])
+					assert.Equal(t, 3, backend.countOperations("RegisterSaas"))
+					assert.Equal(t, 3, backend.countOperations("ReconnectDataSilo"))
+					assert.Equal(t, []interface{}{
+						map[string]interface{}{"identifierName": "email", "path": "user.email"},
+					}, backend.getRegisteredSaasContexts()[2]["allowedIdentifierPaths"])
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloEncryptsSaasContext(t *testing.T) {
+	backend := newFakeBackend(t)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type = "datadog"
+
+  secret_context {
+    name  = "apiKey"
+    value = "dd-api-key"
+  }
+}
+`,
+				Check: func(s *sdkterraform.State) error {
+					silo := backend.getDataSilo(s.RootModule().Resources["transcend_data_silo.silo"].Primary.ID)
+					assert.Equal(t, map[string]interface{}{"apiKey": "dd-api-key"}, backend.getRegisteredSaasContexts()[0]["secretMap"])
+					assert.Equal(t, "presigned-saas-context-1", silo["presignedSaasContext"], "sombra should read the presigned context")
+					assert.NotContains(t, silo["encryptedSaasContext"], "presigned-saas-context", "the backend should only see the encrypted context")
+					assert.Contains(t, silo["dhEncrypted"], `"publicKey"`)
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloSaasContextEncryptionSettings(t *testing.T) {
+	siloConfig := func(backend *fakeBackend, encryption string) string {
+		return fmt.Sprintf(`
+provider "transcend" {
+  url                     = %q
+  key                     = "fake-api-key"
+  max_retries             = 0
+  saas_context_encryption = %q
+}
+
+resource "transcend_data_silo" "silo" {
+  type = "datadog"
+
+  secret_context {
+    name  = "apiKey"
+    value = "dd-api-key"
+  }
+}
+`, backend.server.URL, encryption)
+	}
+
+	// Sombras without the public key route answer that it does not exist
+	for _, status := range []int{http.StatusNotFound, http.StatusNotImplemented} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			backend := newFakeBackend(t)
+			backend.dhPublicKeyStatus = status
+			resource.UnitTest(t, resource.TestCase{
+				ProtoV5ProviderFactories: testProviderFactories(),
+				CheckDestroy:             backend.checkDestroyed,
+				Steps: []resource.TestStep{
+					{
+						Config:      siloConfig(backend, "required"),
+						ExpectError: regexp.MustCompile(fmt.Sprintf(`does\s+not\s+support\s+encrypted\s+SaaS\s+contexts,\s+which\s+saas_context_encryption\s+requires:\s+received\s+a\s+%d\s+response`, status)),
+					},
+					{
+						Config: siloConfig(backend, "auto"),
+						Check: func(s *sdkterraform.State) error {
+							silo := backend.getDataSilo(s.RootModule().Resources["transcend_data_silo.silo"].Primary.ID)
+							assert.Equal(t, "presigned-saas-context-1", silo["presignedSaasContext"])
+							assert.Equal(t, "", silo["dhEncrypted"])
+							return nil
+						},
+					},
+				},
+			})
+		})
+	}
+
+	// Any other failure could be an attempt to have the SaaS context sent unencrypted, so it is not fallen back from
+	failures := map[string]func(backend *fakeBackend){
+		"Unauthorized":       func(backend *fakeBackend) { backend.dhPublicKeyStatus = http.StatusUnauthorized },
+		"Forbidden":          func(backend *fakeBackend) { backend.dhPublicKeyStatus = http.StatusForbidden },
+		"Method Not Allowed": func(backend *fakeBackend) { backend.dhPublicKeyStatus = http.StatusMethodNotAllowed },
+		"Bad Gateway":        func(backend *fakeBackend) { backend.dhPublicKeyStatus = http.StatusBadGateway },
+		"hang up":            func(backend *fakeBackend) { backend.dhPublicKeyHangUp = true },
+	}
+	for name, fail := range failures {
+		fail := fail
+		t.Run(name, func(t *testing.T) {
+			backend := newFakeBackend(t)
+			fail(backend)
+			resource.UnitTest(t, resource.TestCase{
+				ProtoV5ProviderFactories: testProviderFactories(),
+				CheckDestroy:             backend.checkDestroyed,
+				Steps: []resource.TestStep{
+					{
+						Config:      siloConfig(backend, "auto"),
+						ExpectError: regexp.MustCompile(`could\s+not\s+fetch\s+the\s+public\s+key\s+of\s+the\s+sombra\s+at\s+\S+\s+to\s+encrypt\s+the\s+SaaS\s+context`),
+					},
+					{
+						PreConfig: func() {
+							assert.Empty(t, backend.getRegisteredSaasContexts(), "the SaaS context should not be sent")
+						},
+						// Opting out of the encryption still works
+						Config: siloConfig(backend, "disabled"),
+						Check: func(s *sdkterraform.State) error {
+							silo := backend.getDataSilo(s.RootModule().Resources["transcend_data_silo.silo"].Primary.ID)
+							assert.Equal(t, "", silo["dhEncrypted"])
+							return nil
+						},
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestUnitDataSiloPinnedSombraPublicKey(t *testing.T) {
+	siloConfig := func(backend *fakeBackend, publicKey []byte) string {
+		return fmt.Sprintf(`
+provider "transcend" {
+  url               = %q
+  key               = "fake-api-key"
+  sombra_public_key = %q
+}
+
+resource "transcend_data_silo" "silo" {
+  type = "datadog"
+
+  secret_context {
+    name  = "apiKey"
+    value = "dd-api-key"
+  }
+}
+`, backend.server.URL, base64.StdEncoding.EncodeToString(publicKey))
+	}
+
+	t.Run("sombra key", func(t *testing.T) {
+		backend := newFakeBackend(t)
+		backend.dhPublicKeyStatus = http.StatusNotFound
+		resource.UnitTest(t, resource.TestCase{
+			ProtoV5ProviderFactories: testProviderFactories(),
+			CheckDestroy:             backend.checkDestroyed,
+			Steps: []resource.TestStep{
+				{
+					Config: siloConfig(backend, backend.sombraPublicKey),
+					Check: func(s *sdkterraform.State) error {
+						silo := backend.getDataSilo(s.RootModule().Resources["transcend_data_silo.silo"].Primary.ID)
+						assert.Equal(t, "presigned-saas-context-1", silo["presignedSaasContext"])
+						assert.Contains(t, silo["dhEncrypted"], `"publicKey"`)
+						assert.Equal(t, 0, backend.countOperations("DHPublicKey"), "a pinned key should not be fetched")
+						return nil
+					},
+				},
+			},
+		})
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		backend := newFakeBackend(t)
+		otherPrivateKey := make([]byte, curve25519.ScalarSize)
+		rand.Read(otherPrivateKey)
+		otherPublicKey, _ := curve25519.X25519(otherPrivateKey, curve25519.Basepoint)
+		resource.UnitTest(t, resource.TestCase{
+			ProtoV5ProviderFactories: testProviderFactories(),
+			CheckDestroy:             backend.checkDestroyed,
+			Steps: []resource.TestStep{
+				{
+					Config:      siloConfig(backend, otherPublicKey),
+					ExpectError: regexp.MustCompile(`received a 400 response from sombra`),
+				},
+			},
+		})
+		assert.Equal(t, 0, backend.countOperations("ReconnectDataSilo"))
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		backend := newFakeBackend(t)
+		resource.UnitTest(t, resource.TestCase{
+			ProtoV5ProviderFactories: testProviderFactories(),
+			Steps: []resource.TestStep{
+				{
+					Config:      siloConfig(backend, []byte("too short")),
+					ExpectError: regexp.MustCompile(`expected a base64 encoded X25519 public key`),
+				},
+			},
+		})
+	})
+}
+
+func TestUnitDataSiloRejectsUnverifiedSaasContext(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.tamperDHResponses = true
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type = "datadog"
+
+  secret_context {
+    name  = "apiKey"
+    value = "dd-api-key"
+  }
+}
+`,
+				ExpectError: regexp.MustCompile(`could\s+not\s+verify\s+the\s+SaaS\s+context\s+returned\s+by\s+sombra`),
+			},
+		},
+	})
+	assert.Equal(t, 0, backend.countOperations("ReconnectDataSilo"), "an unverified context should not be sent to the backend")
+}
+
+func TestUnitDataSilosShareLookups(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.setCatalog("datadog", map[string]interface{}{
+		"integrationName": "datadog",
+		"integrationConfig": map[string]interface{}{
+			"configuredBaseHosts": map[string]interface{}{"PROD": []interface{}{"api.datadoghq.com"}},
+		},
+	})
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  count = 5
+
+  type  = "datadog"
+  title = "Datadog ${count.index}"
+
+  secret_context {
+    name  = "apiKey"
+    value = "dd-api-key-${count.index}"
+  }
+}
+`,
+				Check: func(s *sdkterraform.State) error {
+					assert.Len(t, backend.getRegisteredSaasContexts(), 5)
+					assert.Less(t, backend.countOperations("catalog"), 5, "the catalog should be looked up once per plan or apply, not once per data silo")
+					assert.Equal(t, 1, backend.countOperations("SombraUrlQuery"), "the sombra should be looked up once per apply")
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloFormItems(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.setCatalog("datadog", map[string]interface{}{
+		"integrationName": "datadog",
+		"integrationConfig": map[string]interface{}{
+			"configuredBaseHosts": map[string]interface{}{"PROD": []interface{}{"api.datadoghq.com"}},
+		},
+		"formConfigs": []interface{}{
+			map[string]interface{}{
+				"passportName": "apiKey",
+				"type":         "API_KEY",
+				"formItems": []interface{}{
+					map[string]interface{}{"name": "apiKey", "type": "password", "isPlaintext": false, "isRequired": true},
+					map[string]interface{}{"name": "applicationKey", "type": "password", "isPlaintext": false, "isRequired": true},
+					map[string]interface{}{"name": "queryTemplate", "type": "text", "isPlaintext": true, "isRequired": false},
+				},
+			},
+		},
+	})
+	formItemsConfig := func(formItems string) string {
+		return backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type = "datadog"
+
+  form_items = {
+` + formItems + `
+  }
+}
+`
+	}
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: formItemsConfig(`
+    apiKey         = "dd-api-key"
+    applicationKey = "dd-app-key"
+    queryTempalte  = "@email:{{identifier}}"
+`),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`unknown form items "queryTempalte" for the datadog integration, expected any of:\s+apiKey, applicationKey, queryTemplate`),
+			},
+			{
+				Config: formItemsConfig(`
+    apiKey = "dd-api-key"
+`),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`missing required form items for the datadog integration:\s+applicationKey\n`),
+			},
+			{
+				// Optional form items can be left out
+				Config: formItemsConfig(`
+    apiKey         = "dd-api-key"
+    applicationKey = "dd-app-key"
+`),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: formItemsConfig(`
+    apiKey         = "dd-api-key"
+    applicationKey = "dd-app-key"
+    queryTemplate  = "@email:{{identifier}}"
+`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "connection_state", "CONNECTED"),
+					func(s *sdkterraform.State) error {
+						silo := backend.getDataSilo(s.RootModule().Resources["transcend_data_silo.silo"].Primary.ID)
+						assert.Equal(t, []interface{}{
+							map[string]interface{}{"name": "queryTemplate", "value": "@email:{{identifier}}"},
+						}, silo["plaintextContext"])
+						assert.Len(t, backend.getRegisteredSaasContexts(), 1)
+						assert.Equal(t, map[string]interface{}{"apiKey": "dd-api-key", "applicationKey": "dd-app-key"}, backend.getRegisteredSaasContexts()[0]["secretMap"])
+						return nil
+					},
+				),
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloValidatedAgainstCatalog(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.setCatalog("postgres", map[string]interface{}{
+		"integrationName":  "postgres",
+		"supportedPlugins": []interface{}{"SCHEMA_DISCOVERY", "CONTENT_CLASSIFICATION"},
+		"formConfigs": []interface{}{
+			map[string]interface{}{
+				"passportName": "database",
+				"type":         "DATABASE",
+				"formItems": []interface{}{
+					map[string]interface{}{"name": "driver", "type": "select", "isPlaintext": false, "isRequired": true, "options": []interface{}{
+						map[string]interface{}{"name": "PostgreSQL Unicode"},
+						map[string]interface{}{"name": "PostgreSQL ANSI"},
+					}},
+					map[string]interface{}{"name": "connectionString", "type": "password", "isPlaintext": false, "isRequired": true},
+					map[string]interface{}{"name": "queryTimeout", "type": "number", "isPlaintext": true},
+				},
+			},
+		},
+	})
+	siloConfig := func(driver string, connectionStringName string, queryTimeout string, plugin string) string {
+		return backend.providerConfig() + fmt.Sprintf(`
+resource "transcend_data_silo" "silo" {
+  type = "postgres"
+
+  secret_context {
+    name  = "driver"
+    value = %q
+  }
+  secret_context {
+    name  = %q
+    value = "Server=db.internal;Database=users"
+  }
+  plaintext_context {
+    name  = "queryTimeout"
+    value = %q
+  }
+
+  %s {
+    schedule_frequency_minutes = 120
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+  }
+}
+`, driver, connectionStringName, queryTimeout, plugin)
+	}
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config:      siloConfig("PostgreSQL", "connectionStrnig", "thirty", "data_silo_discovery_plugin"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`secret_context\[name = "connectionStrnig"\]: unknown form item\s+"connectionStrnig" for the postgres integration`),
+			},
+			{
+				Config:      siloConfig("PostgreSQL Unicode", "connectionStrnig", "30", "schema_discovery_plugin"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`plaintext_context, secret_context: missing required form items for the\s+postgres integration: connectionString`),
+			},
+			{
+				Config:      siloConfig("PostgreSQL", "connectionString", "30", "schema_discovery_plugin"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`secret_context\[name = "driver"\]: the value is not an option of the\s+driver form item, expected one of: "PostgreSQL Unicode", "PostgreSQL ANSI"`),
+			},
+			{
+				Config:      siloConfig("PostgreSQL Unicode", "connectionString", "thirty", "schema_discovery_plugin"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`plaintext_context\[name = "queryTimeout"\]: the queryTimeout form item\s+expects a number`),
+			},
+			{
+				Config:      siloConfig("PostgreSQL Unicode", "connectionString", "30", "data_silo_discovery_plugin"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`data_silo_discovery_plugin: the postgres integration does not support the\s+DATA_SILO_DISCOVERY plugin`),
+			},
+			{
+				Config: siloConfig("PostgreSQL Unicode", "connectionString", "30", "schema_discovery_plugin"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "connection_state", "CONNECTED"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "schema_discovery_plugin.0.schedule_frequency_minutes", "120"),
+				),
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloPluginsNotCheckedWithoutSupportedPlugins(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.setCatalog("postgres", map[string]interface{}{
+		"integrationName": "postgres",
+	})
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "postgres"
+  skip_connecting = true
+
+  schema_discovery_plugin {
+    enabled                    = true
+    schedule_frequency_minutes = 120
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+  }
+}
+`,
+				Check: resource.TestCheckResourceAttr("transcend_data_silo.silo", "schema_discovery_plugin.0.enabled", "true"),
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloPlugins(t *testing.T) {
+	backend := newFakeBackend(t)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonDynamodb"
+  skip_connecting = true
+
+  schema_discovery_plugin {
+    enabled                    = true
+    schedule_frequency_minutes = 120
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+  }
+
+  content_classification_plugin {
+    enabled                    = false
+    schedule_frequency_minutes = 60
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+  }
+
+  data_silo_discovery_plugin {
+    enabled                    = true
+    schedule_frequency_minutes = 1440
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+  }
+
+  disco_class_scan_config {
+    enabled                    = true
+    type                       = "SCHEMA_ONLY"
+    schedule_frequency_minutes = 120
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+  }
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "schema_discovery_plugin.0.enabled", "true"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "schema_discovery_plugin.0.schedule_frequency_minutes", "120"),
+					resource.TestCheckResourceAttrSet("transcend_data_silo.silo", "schema_discovery_plugin.0.id"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "content_classification_plugin.0.enabled", "false"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "data_silo_discovery_plugin.0.schedule_frequency_minutes", "1440"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "disco_class_scan_config.0.type", "SCHEMA_ONLY"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "disco_class_scan_config.0.schedule_frequency_minutes", "120"),
+				),
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloKeptWhenPluginLookupsAreNotFound(t *testing.T) {
+	backend := newFakeBackend(t)
+	config := backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonDynamodb"
+  skip_connecting = true
+
+  schema_discovery_plugin {
+    enabled                    = true
+    schedule_frequency_minutes = 120
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+  }
+
+  disco_class_scan_config {
+    enabled                    = true
+    type                       = "SCHEMA_ONLY"
+    schedule_frequency_minutes = 120
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+  }
+}
+`
+	for _, operation := range []string{"Plugins", "DiscoClassScanConfig"} {
+		t.Run(operation, func(t *testing.T) {
+			resource.UnitTest(t, resource.TestCase{
+				ProtoV5ProviderFactories: testProviderFactories(),
+				CheckDestroy:             backend.checkDestroyed,
+				Steps: []resource.TestStep{
+					{
+						Config: config,
+					},
+					{
+						// Only a missing data silo removes it from the state
+						PreConfig: func() {
+							backend.setFailure(operation, fakeNotFound(operation, "silo").(*fakeGraphQLError))
+						},
+						Config:      config,
+						PlanOnly:    true,
+						ExpectError: regexp.MustCompile(`with id silo not found`),
+					},
+					{
+						PreConfig: func() {
+							backend.clearFailure(operation)
+						},
+						Config:   config,
+						PlanOnly: true,
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestUnitDataSiloDeletedOutsideTerraform(t *testing.T) {
+	backend := newFakeBackend(t)
+	config := backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "server"
+  skip_connecting = true
+}
+
+resource "transcend_data_silo_connection" "connection" {
+  data_silo_id = transcend_data_silo.silo.id
+}
+
+resource "transcend_schema_discovery_plugin" "plugin" {
+  data_silo_id               = transcend_data_silo.silo.id
+  enabled                    = true
+  schedule_frequency_minutes = 120
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+}
+`
+	var id string
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.TestCheckResourceAttrWith("transcend_data_silo.silo", "id", func(value string) error {
+					id = value
+					return nil
+				}),
+			},
+			{
+				PreConfig: func() {
+					backend.deleteDataSiloOutsideTerraform(id)
+				},
+				Config:             config,
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloDeletionProtection(t *testing.T) {
+	backend := newFakeBackend(t)
+	config := func(deletionProtection bool, siloType string) string {
+		return backend.providerConfig() + fmt.Sprintf(`
+resource "transcend_data_silo" "silo" {
+  type                = %q
+  title               = "Production database"
+  skip_connecting     = true
+  deletion_protection = %t
+}
+`, siloType, deletionProtection)
+	}
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: config(true, "server"),
+				Check:  resource.TestCheckResourceAttr("transcend_data_silo.silo", "deletion_protection", "true"),
+			},
+			{
+				Config:      backend.providerConfig(),
+				ExpectError: regexp.MustCompile(`"Production database" has deletion_protection set`),
+			},
+			{
+				// Replacing the data silo destroys it too
+				Config:      config(true, "database"),
+				ExpectError: regexp.MustCompile(`"Production database" has deletion_protection set`),
+			},
+			{
+				Config: config(false, "server"),
+				Check:  resource.TestCheckResourceAttr("transcend_data_silo.silo", "deletion_protection", "false"),
+			},
+			{
+				ResourceName:            "transcend_data_silo.silo",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"skip_connecting", "credentials_hash"},
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloArchivedOnDestroy(t *testing.T) {
+	backend := newFakeBackend(t)
+	var id string
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type             = "server"
+  skip_connecting  = true
+  is_live          = true
+  destroy_behavior = "archive"
+}
+`,
+				Check: resource.TestCheckResourceAttrWith("transcend_data_silo.silo", "id", func(value string) error {
+					id = value
+					return nil
+				}),
+			},
+			{
+				Config: backend.providerConfig(),
+				Check: func(s *sdkterraform.State) error {
+					silo := backend.getDataSilo(id)
+					if silo == nil {
+						return fmt.Errorf("data silo %s was deleted instead of archived", id)
+					}
+					assert.Equal(t, false, silo["isLive"])
+					assert.Equal(t, "DEPRECATED", silo["deprecationState"])
+					assert.Equal(t, 0, backend.countOperations("DeleteDataSilos"))
+					return nil
+				},
+			},
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type             = "server"
+  destroy_behavior = "discard"
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`expected destroy_behavior to be one of`),
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloOnCreateFailure(t *testing.T) {
+	config := func(backend *fakeBackend, onCreateFailure string) string {
+		return backend.providerConfig() + fmt.Sprintf(`
+resource "transcend_data_silo" "silo" {
+  type              = "amazonDynamodb"
+  title             = "Orders"
+  skip_connecting   = true
+  on_create_failure = %q
+
+  schema_discovery_plugin {
+    enabled                    = true
+    schedule_frequency_minutes = 120
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+  }
+}
+`, onCreateFailure)
+	}
+	pluginFailure := &fakeGraphQLError{Message: "Plugins are temporarily unavailable"}
+
+	t.Run("delete", func(t *testing.T) {
+		backend := newFakeBackend(t)
+		backend.setFailure("UpdateDataSiloPlugin", pluginFailure)
+		resource.UnitTest(t, resource.TestCase{
+			ProtoV5ProviderFactories: testProviderFactories(),
+			CheckDestroy:             backend.checkDestroyed,
+			Steps: []resource.TestStep{
+				{
+					Config:      config(backend, "delete"),
+					ExpectError: regexp.MustCompile(`Plugins are temporarily unavailable`),
+				},
+				{
+					PreConfig: func() {
+						assert.Empty(t, backend.getDataSiloIDs())
+						assert.Equal(t, 1, backend.countOperations("DeleteDataSilos"))
+					},
+					Config:             config(backend, "delete"),
+					PlanOnly:           true,
+					ExpectNonEmptyPlan: true,
+				},
+			},
+		})
+	})
+
+	t.Run("keep_tainted", func(t *testing.T) {
+		backend := newFakeBackend(t)
+		backend.setFailure("UpdateDataSiloPlugin", pluginFailure)
+		var id string
+		resource.UnitTest(t, resource.TestCase{
+			ProtoV5ProviderFactories: testProviderFactories(),
+			CheckDestroy:             backend.checkDestroyed,
+			Steps: []resource.TestStep{
+				{
+					Config:      config(backend, "keep_tainted"),
+					ExpectError: regexp.MustCompile(`Plugins are temporarily unavailable`),
+				},
+				{
+					PreConfig: func() {
+						ids := backend.getDataSiloIDs()
+						assert.Len(t, ids, 1)
+						for _, siloID := range ids {
+							id = siloID
+						}
+						backend.clearFailure("UpdateDataSiloPlugin")
+					},
+					// The tainted data silo is replaced
+					Config: config(backend, "keep_tainted"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("transcend_data_silo.silo", "schema_discovery_plugin.0.enabled", "true"),
+						func(s *sdkterraform.State) error {
+							assert.NotEqual(t, id, s.RootModule().Resources["transcend_data_silo.silo"].Primary.ID)
+							assert.Nil(t, backend.getDataSilo(id))
+							assert.Equal(t, 2, backend.countOperations("CreateDataSilos"))
+							return nil
+						},
+					),
+				},
+			},
+		})
+	})
+
+	t.Run("keep", func(t *testing.T) {
+		backend := newFakeBackend(t)
+		backend.setFailure("UpdateDataSiloPlugin", pluginFailure)
+		var id string
+		resource.UnitTest(t, resource.TestCase{
+			ProtoV5ProviderFactories: testProviderFactories(),
+			CheckDestroy:             backend.checkDestroyed,
+			Steps: []resource.TestStep{
+				{
+					// The failed step is left out of the state, so it is planned again
+					Config: config(backend, "keep"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("transcend_data_silo.silo", "title", "Orders"),
+						resource.TestCheckResourceAttr("transcend_data_silo.silo", "schema_discovery_plugin.#", "0"),
+						resource.TestCheckResourceAttrWith("transcend_data_silo.silo", "id", func(value string) error {
+							id = value
+							return nil
+						}),
+					),
+					ExpectNonEmptyPlan: true,
+				},
+				{
+					PreConfig: func() {
+						backend.clearFailure("UpdateDataSiloPlugin")
+					},
+					Config: config(backend, "keep"),
+					Check: resource.ComposeTestCheckFunc(
+						resource.TestCheckResourceAttr("transcend_data_silo.silo", "schema_discovery_plugin.0.enabled", "true"),
+						resource.TestCheckResourceAttr("transcend_data_silo.silo", "schema_discovery_plugin.0.schedule_frequency_minutes", "120"),
+						resource.TestCheckResourceAttrWith("transcend_data_silo.silo", "id", func(value string) error {
+							assert.Equal(t, id, value, "the data silo should be resumed rather than replaced")
+							return nil
+						}),
+						func(s *sdkterraform.State) error {
+							assert.Equal(t, 1, backend.countOperations("CreateDataSilos"))
+							return nil
+						},
+					),
+				},
+			},
+		})
+	})
+}
+
+func TestUnitDataSiloKeptWhenFieldsFailToUpdate(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.setFailure("UpdateDataSilos", &fakeGraphQLError{Message: "Data silos are temporarily read only"})
+	config := backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type              = "amazonDynamodb"
+  title             = "Orders"
+  skip_connecting   = true
+  destroy_behavior  = "archive"
+  on_create_failure = "keep"
+}
+`
+	var id string
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		Steps: []resource.TestStep{
+			{
+				// The fields are left out of the state, so they are planned again
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "type", "amazonDynamodb"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "destroy_behavior", "archive"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "on_create_failure", "keep"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "title", ""),
+					resource.TestCheckResourceAttrWith("transcend_data_silo.silo", "id", func(value string) error {
+						id = value
+						return nil
+					}),
+				),
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				PreConfig: func() {
+					backend.clearFailure("UpdateDataSilos")
+				},
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "title", "Orders"),
+					resource.TestCheckResourceAttrWith("transcend_data_silo.silo", "id", func(value string) error {
+						assert.Equal(t, id, value, "the data silo should be resumed rather than replaced")
+						return nil
+					}),
+					func(s *sdkterraform.State) error {
+						assert.Equal(t, 1, backend.countOperations("CreateDataSilos"))
+						assert.Equal(t, 0, backend.countOperations("DeleteDataSilos"))
+						return nil
+					},
+				),
+			},
+		},
+	})
+	// The kept data silo is archived as configured, rather than deleted
+	silo := backend.getDataSilo(id)
+	assert.NotNil(t, silo)
+	assert.Equal(t, "DEPRECATED", silo["deprecationState"])
+}
+
+func TestUnitDataSiloResumesFailedUpdate(t *testing.T) {
+	backend := newFakeBackend(t)
+	config := func(frequency int) string {
+		return backend.providerConfig() + fmt.Sprintf(`
+resource "transcend_data_silo" "silo" {
+  type            = "amazonDynamodb"
+  title           = "Orders"
+  skip_connecting = true
+
+  schema_discovery_plugin {
+    enabled                    = true
+    schedule_frequency_minutes = %d
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+  }
+}
+`, frequency)
+	}
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: config(120),
+			},
+			{
+				PreConfig: func() {
+					backend.setFailure("UpdateDataSiloPlugin", &fakeGraphQLError{Message: "Plugins are temporarily unavailable"})
+				},
+				Config:      config(60),
+				ExpectError: regexp.MustCompile(`Plugins are temporarily unavailable`),
+			},
+			{
+				// The plugin change that failed is still planned
+				Config:             config(60),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				PreConfig: func() {
+					backend.clearFailure("UpdateDataSiloPlugin")
+				},
+				Config: config(60),
+				Check:  resource.TestCheckResourceAttr("transcend_data_silo.silo", "schema_discovery_plugin.0.schedule_frequency_minutes", "60"),
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloPluginBlockRunOnApply(t *testing.T) {
+	backend := newFakeBackend(t)
+	backend.pluginRunPolls = 1
+	config := backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonDynamodb"
+  skip_connecting = true
+
+  schema_discovery_plugin {
+    enabled                    = true
+    schedule_frequency_minutes = 1440
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+    run_on_apply               = true
+
+    wait_for_completion {
+      poll_interval = "10ms"
+    }
+  }
+}
+`
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "schema_discovery_plugin.0.run_on_apply", "true"),
+					resource.TestCheckResourceAttr("transcend_data_silo.silo", "schema_discovery_plugin.0.wait_for_completion.0.poll_interval", "10ms"),
+					func(s *sdkterraform.State) error {
+						backend.mu.Lock()
+						defer backend.mu.Unlock()
+						assert.Equal(t, 1, backend.pluginRuns)
+						return nil
+					},
+				),
+			},
+			{
+				// The run settings are kept in the state, so there is nothing left to apply
+				Config:   config,
+				PlanOnly: true,
+			},
+		},
+	})
+}
+
+func TestUnitDataSiloScanPluginConfigs(t *testing.T) {
+	backend := newFakeBackend(t)
+	config := func(sampleSize int) string {
+		return backend.providerConfig() + fmt.Sprintf(`
+resource "transcend_data_silo" "silo" {
+  type            = "amazonDynamodb"
+  skip_connecting = true
+
+  disco_class_scan_config {
+    enabled                    = true
+    type                       = "FULL_SCAN"
+    schedule_frequency_minutes = 120
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+
+    scan_plugin_config {
+      plugin_type = "CONTENT_CLASSIFICATION"
+      classifiers = ["EMAIL"]
+      sample_size = %d
+    }
+  }
+}
+`, sampleSize)
+	}
+	address := "transcend_data_silo.silo"
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: config(100),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(address, "disco_class_scan_config.0.scan_plugin_config.#", "1"),
+					resource.TestCheckResourceAttr(address, "disco_class_scan_config.0.scan_plugin_config.0.plugin_type", "CONTENT_CLASSIFICATION"),
+					resource.TestCheckResourceAttr(address, "disco_class_scan_config.0.scan_plugin_config.0.classifiers.0", "EMAIL"),
+					resource.TestCheckResourceAttr(address, "disco_class_scan_config.0.scan_plugin_config.0.sample_size", "100"),
+				),
+			},
+			{
+				// Changes made outside of Terraform are planned to be undone
+				PreConfig: func() {
+					backend.mu.Lock()
+					defer backend.mu.Unlock()
+					for _, discoClassScanConfig := range backend.discoClassScanConfigs {
+						discoClassScanConfig["scanPluginConfigs"] = []interface{}{}
+					}
+				},
+				Config:             config(100),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: config(250),
+				Check:  resource.TestCheckResourceAttr(address, "disco_class_scan_config.0.scan_plugin_config.0.sample_size", "250"),
+			},
+		},
+	})
+}
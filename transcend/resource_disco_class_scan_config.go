@@ -2,11 +2,14 @@ package transcend
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	graphql "github.com/hasura/go-graphql-client"
 )
 
@@ -16,6 +19,7 @@ func resourceDiscoClassScanConfig() *schema.Resource {
 		ReadContext:   resourceDiscoClassScanConfigRead,
 		UpdateContext: resourceDiscoClassScanConfigUpdate,
 		DeleteContext: resourceDiscoClassScanConfigDelete,
+		CustomizeDiff: customizeDiffStandalonePlugin("transcend_disco_class_scan_config", discoClassScanConfigOwnershipKind, standaloneDiscoClassScanConfigSettings),
 		Schema: map[string]*schema.Schema{
 			"id": {
 				Type:     schema.TypeString,
@@ -27,9 +31,10 @@ func resourceDiscoClassScanConfig() *schema.Resource {
 				Description: "The ID of the data silo to connect",
 			},
 			"type": {
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "The type of disco class scan config",
+				Type:             schema.TypeString,
+				Optional:         true,
+				Description:      "The type of disco class scan config, one of " + strings.Join(types.DiscoClassScanTypes, ", "),
+				ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice(types.DiscoClassScanTypes, false)),
 			},
 			"enabled": {
 				Type:        schema.TypeBool,
@@ -44,11 +49,73 @@ func resourceDiscoClassScanConfig() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			"scan_plugin_config": scanPluginConfigSchema(),
+			"ownership_id":       ownershipIDSchema(),
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: importByDataSiloId,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(10 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(10 * time.Minute),
+			Delete: schema.DefaultTimeout(10 * time.Minute),
+		},
+	}
+}
+
+// scanPluginConfigSchema configures the plugins that a disco class scan runs. When no block is configured, the
+// scan plugin configs set in Transcend are left as they are.
+func scanPluginConfigSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		Computed:    true,
+		Description: "Configures how each plugin, like CONTENT_CLASSIFICATION, takes part in the scan. When none are configured, the scan plugin configs set in Transcend are kept.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"plugin_type": {
+					Type:             schema.TypeString,
+					Required:         true,
+					Description:      "The type of the plugin, like SCHEMA_DISCOVERY or CONTENT_CLASSIFICATION",
+					ValidateDiagFunc: validatePluginType,
+				},
+				"enabled": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     true,
+					Description: "Whether the plugin takes part in the scan",
+				},
+				"classifiers": {
+					Type:        schema.TypeSet,
+					Optional:    true,
+					Computed:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+					Description: "The classifiers that the plugin uses. Leave unset to use the default classifiers of Transcend.",
+				},
+				"sample_size": {
+					Type:             schema.TypeInt,
+					Optional:         true,
+					Computed:         true,
+					Description:      "The number of rows or objects that the plugin samples. Leave unset to use the default sample size of Transcend.",
+					ValidateDiagFunc: validation.ToDiagFunc(validation.IntAtLeast(1)),
+				},
+				"settings": {
+					Type:        schema.TypeMap,
+					Optional:    true,
+					Computed:    true,
+					Elem:        &schema.Schema{Type: schema.TypeString},
+					Description: "Settings specific to the plugin, by name. Leave unset to use the default settings of Transcend.",
+				},
+			},
 		},
 	}
 }
 
 func resourceDiscoClassScanConfigCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	if diags := newOwnershipID(d); diags.HasError() {
+		return diags
+	}
 	return resourceDiscoClassScanConfigUpdate(ctx, d, m)
 }
 
@@ -62,9 +129,9 @@ func resourceDiscoClassScanConfigRead(ctx context.Context, d *schema.ResourceDat
 	discoClassScanConfigVars := map[string]interface{}{
 		"dataSiloId": graphql.ID(d.Get("data_silo_id").(string)),
 	}
-	err := client.graphql.Query(context.Background(), &discoClassScanConfigQuery, discoClassScanConfigVars, graphql.OperationName("DiscoClassScanConfig"))
+	err := client.graphql.Query(ctx, &discoClassScanConfigQuery, discoClassScanConfigVars, graphql.OperationName("DiscoClassScanConfig"))
 	if err != nil {
-		return diag.FromErr(err)
+		return readErrorDiagnostics(ctx, d, "Error reading disco class scan config", err)
 	}
 
 	d.SetId(string(discoClassScanConfigQuery.DiscoClassScanConfig.ID))
@@ -79,6 +146,13 @@ func resourceDiscoClassScanConfigRead(ctx context.Context, d *schema.ResourceDat
 	d.Set("type", string(config.Type))
 	d.Set("schedule_frequency_minutes", frequencyMinutes)
 	d.Set("schedule_start_at", string(config.ScheduleStartAt))
+	d.Set("scan_plugin_config", types.FlattenScanPluginConfigs(config.ScanPluginConfigs, d.Get("scan_plugin_config").([]interface{})))
+	if d.Get("ownership_id").(string) == "" {
+		// Imported resources, and those created by earlier versions of the provider, get one when refreshed
+		if diags := newOwnershipID(d); diags.HasError() {
+			return diags
+		}
+	}
 
 	return diags
 }
@@ -87,6 +161,10 @@ func resourceDiscoClassScanConfigUpdate(ctx context.Context, d *schema.ResourceD
 	client := m.(*Client)
 	var diags diag.Diagnostics
 
+	if diags := claimStandalonePlugin(client, d, "transcend_disco_class_scan_config", discoClassScanConfigOwnershipKind, standaloneDiscoClassScanConfigSettings); diags.HasError() {
+		return diags
+	}
+
 	// DiscoClassScanConfig already exists when a data silo is created, it is just disabled.
 	// So here we fetch the ID / settings on the existing discoClassScanConfig
 	// Read the data silo discoClassScanConfig information
@@ -96,13 +174,9 @@ func resourceDiscoClassScanConfigUpdate(ctx context.Context, d *schema.ResourceD
 	discoClassScanConfigVars := map[string]interface{}{
 		"dataSiloId": graphql.ID(d.Get("data_silo_id").(string)),
 	}
-	err := client.graphql.Query(context.Background(), &discoClassScanConfigQuery, discoClassScanConfigVars, graphql.OperationName("DiscoClassScanConfig"))
+	err := client.graphql.Query(ctx, &discoClassScanConfigQuery, discoClassScanConfigVars, graphql.OperationName("DiscoClassScanConfig"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error finding discoClassScanConfig for data silo",
-			Detail:   "Error when reading discoClassScanConfig: " + err.Error(),
-		})
+		diags = append(diags, graphQLErrorDiagnostics("Error finding discoClassScanConfig for data silo", err)...)
 		return diags
 	}
 
@@ -116,13 +190,9 @@ func resourceDiscoClassScanConfigUpdate(ctx context.Context, d *schema.ResourceD
 	updateVars := map[string]interface{}{
 		"input": types.MakeStandaloneUpdateDiscoClassScanConfigInput(d),
 	}
-	err = client.graphql.Mutate(context.Background(), &updateMutation, updateVars, graphql.OperationName("UpdateDataSiloPlugin"))
+	err = client.graphql.Mutate(ctx, &updateMutation, updateVars, graphql.OperationName("UpdateDiscoClassScanConfig"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error updating disco class scan config",
-			Detail:   "Error when updating disco class scan config: " + err.Error(),
-		})
+		diags = append(diags, graphQLErrorDiagnostics("Error updating disco class scan config", err)...)
 		return diags
 	}
 
@@ -145,15 +215,12 @@ func resourceDiscoClassScanConfigDelete(ctx context.Context, d *schema.ResourceD
 	updateVars := map[string]interface{}{
 		"input": input,
 	}
-	err := client.graphql.Mutate(context.Background(), &updateMutation, updateVars, graphql.OperationName("UpdateDataSiloPlugin"))
+	err := client.graphql.Mutate(ctx, &updateMutation, updateVars, graphql.OperationName("UpdateDiscoClassScanConfig"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error updating disco class scan config",
-			Detail:   "Error when updating disco class scan config: " + err.Error(),
-		})
+		diags = append(diags, graphQLErrorDiagnostics("Error updating disco class scan config", err)...)
 		return diags
 	}
+	releaseStandalonePlugin(client, d, "transcend_disco_class_scan_config", discoClassScanConfigOwnershipKind)
 
 	return nil
 }
@@ -1,16 +1,21 @@
 package transcend
 
 import (
+	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	sdkterraform "github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	graphql "github.com/hasura/go-graphql-client"
 	"github.com/stretchr/testify/assert"
 )
 
 func prepareDiscoClassScanConfigOptions(t *testing.T, vars map[string]interface{}) *terraform.Options {
+	skipUnlessAcceptance(t)
 	defaultVars := map[string]interface{}{"title": t.Name()}
 	for k, v := range vars {
 		defaultVars[k] = v
@@ -68,3 +73,262 @@ func TestCanUseSeparateDiscoClassScanConfigResource(t *testing.T) {
 	assert.True(t, hasSchemaDiscovery)
 	assert.True(t, hasContentClassification)
 }
+
+func TestUnitDiscoClassScanConfig(t *testing.T) {
+	backend := newFakeBackend(t)
+	config := func(scanType string, frequency int) string {
+		return backend.providerConfig() + fmt.Sprintf(`
+resource "transcend_data_silo" "silo" {
+  type            = "amazonDynamodb"
+  skip_connecting = true
+}
+
+resource "transcend_disco_class_scan_config" "config" {
+  data_silo_id               = transcend_data_silo.silo.id
+  enabled                    = true
+  type                       = %q
+  schedule_frequency_minutes = %d
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+}
+`, scanType, frequency)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: config("FULL_SCAN", 120),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("transcend_disco_class_scan_config.config", "id"),
+					resource.TestCheckResourceAttr("transcend_disco_class_scan_config.config", "type", "FULL_SCAN"),
+					resource.TestCheckResourceAttr("transcend_disco_class_scan_config.config", "schedule_frequency_minutes", "120"),
+				),
+			},
+			{
+				Config: config("SCHEMA_ONLY", 60),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_disco_class_scan_config.config", "type", "SCHEMA_ONLY"),
+					resource.TestCheckResourceAttr("transcend_disco_class_scan_config.config", "schedule_frequency_minutes", "60"),
+				),
+			},
+			{
+				ResourceName:            "transcend_disco_class_scan_config.config",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"ownership_id"},
+				ImportStateIdFunc: func(s *sdkterraform.State) (string, error) {
+					return s.RootModule().Resources["transcend_disco_class_scan_config.config"].Primary.Attributes["data_silo_id"], nil
+				},
+			},
+		},
+	})
+}
+
+func TestUnitDiscoClassScanConfigManagedTwice(t *testing.T) {
+	backend := newFakeBackend(t)
+	silo := backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonDynamodb"
+  skip_connecting = true
+
+  disco_class_scan_config {
+    enabled                    = true
+    type                       = "SCHEMA_ONLY"
+    schedule_frequency_minutes = 120
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+  }
+}
+`
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: silo,
+			},
+			{
+				Config: silo + `
+resource "transcend_disco_class_scan_config" "config" {
+  data_silo_id               = transcend_data_silo.silo.id
+  enabled                    = true
+  type                       = "FULL_SCAN"
+  schedule_frequency_minutes = 60
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`the disco class scan config of data silo \S+ is managed by both the\s+disco_class_scan_config block of transcend_data_silo and a\s+transcend_disco_class_scan_config resource`),
+			},
+		},
+	})
+}
+
+func TestUnitDiscoClassScanConfigScanPluginConfigs(t *testing.T) {
+	backend := newFakeBackend(t)
+	config := func(scanType string) string {
+		return backend.providerConfig() + fmt.Sprintf(`
+resource "transcend_data_silo" "silo" {
+  type            = "amazonDynamodb"
+  skip_connecting = true
+}
+
+resource "transcend_disco_class_scan_config" "config" {
+  data_silo_id               = transcend_data_silo.silo.id
+  enabled                    = true
+  type                       = %q
+  schedule_frequency_minutes = 120
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+
+  scan_plugin_config {
+    plugin_type = "CONTENT_CLASSIFICATION"
+    classifiers = ["EMAIL", "PHONE"]
+    sample_size = 100
+    settings = {
+      confidence = "HIGH"
+    }
+  }
+
+  scan_plugin_config {
+    plugin_type = "SCHEMA_DISCOVERY"
+    enabled     = false
+  }
+}
+`, scanType)
+	}
+	// Edits the scan plugin configs of every disco class scan config, like changes made in the admin dashboard
+	editScanPluginConfigs := func(edit func(configs []interface{}) []interface{}) func() {
+		return func() {
+			backend.mu.Lock()
+			defer backend.mu.Unlock()
+			for _, discoClassScanConfig := range backend.discoClassScanConfigs {
+				discoClassScanConfig["scanPluginConfigs"] = edit(discoClassScanConfig["scanPluginConfigs"].([]interface{}))
+			}
+		}
+	}
+	address := "transcend_disco_class_scan_config.config"
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config:      config("QUICK_SCAN"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`expected type to be one of \[FULL_SCAN SCHEMA_ONLY\], got QUICK_SCAN`),
+			},
+			{
+				Config: config("FULL_SCAN"),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(address, "scan_plugin_config.#", "2"),
+					resource.TestCheckResourceAttr(address, "scan_plugin_config.0.plugin_type", "CONTENT_CLASSIFICATION"),
+					resource.TestCheckResourceAttr(address, "scan_plugin_config.0.enabled", "true"),
+					resource.TestCheckTypeSetElemAttr(address, "scan_plugin_config.0.classifiers.*", "PHONE"),
+					resource.TestCheckResourceAttr(address, "scan_plugin_config.0.sample_size", "100"),
+					resource.TestCheckResourceAttr(address, "scan_plugin_config.0.settings.confidence", "HIGH"),
+					resource.TestCheckResourceAttr(address, "scan_plugin_config.1.plugin_type", "SCHEMA_DISCOVERY"),
+					resource.TestCheckResourceAttr(address, "scan_plugin_config.1.enabled", "false"),
+				),
+			},
+			{
+				// The order in which Transcend returns the configs is not drift
+				PreConfig: editScanPluginConfigs(func(configs []interface{}) []interface{} {
+					return []interface{}{configs[1], configs[0]}
+				}),
+				Config:   config("FULL_SCAN"),
+				PlanOnly: true,
+			},
+			{
+				PreConfig: editScanPluginConfigs(func(configs []interface{}) []interface{} {
+					for _, raw := range configs {
+						if scanPluginConfig := raw.(map[string]interface{}); scanPluginConfig["pluginType"] == "CONTENT_CLASSIFICATION" {
+							scanPluginConfig["sampleSize"] = 500
+						}
+					}
+					return configs
+				}),
+				Config:             config("FULL_SCAN"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			{
+				Config: config("FULL_SCAN"),
+				Check: func(*sdkterraform.State) error {
+					backend.mu.Lock()
+					defer backend.mu.Unlock()
+					for _, discoClassScanConfig := range backend.discoClassScanConfigs {
+						for _, raw := range discoClassScanConfig["scanPluginConfigs"].([]interface{}) {
+							if scanPluginConfig := raw.(map[string]interface{}); scanPluginConfig["pluginType"] == "CONTENT_CLASSIFICATION" && fmt.Sprint(scanPluginConfig["sampleSize"]) != "100" {
+								return fmt.Errorf("expected the sample size to be set back to 100, got %v", scanPluginConfig["sampleSize"])
+							}
+						}
+					}
+					return nil
+				},
+			},
+			{
+				ResourceName:            address,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"ownership_id"},
+				ImportStateIdFunc: func(s *sdkterraform.State) (string, error) {
+					return s.RootModule().Resources[address].Primary.Attributes["data_silo_id"], nil
+				},
+			},
+		},
+	})
+}
+
+func TestUnitDiscoClassScanConfigScanPluginDefaults(t *testing.T) {
+	backend := newFakeBackend(t)
+	config := func(sampleSize string) string {
+		return backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonDynamodb"
+  skip_connecting = true
+}
+
+resource "transcend_disco_class_scan_config" "config" {
+  data_silo_id               = transcend_data_silo.silo.id
+  enabled                    = true
+  type                       = "FULL_SCAN"
+  schedule_frequency_minutes = 120
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+
+  scan_plugin_config {
+    plugin_type = "CONTENT_CLASSIFICATION"
+    ` + sampleSize + `
+  }
+}
+`
+	}
+	address := "transcend_disco_class_scan_config.config"
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config:      config(`sample_size = 0`),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`expected sample_size to be at least \(1\), got 0`),
+			},
+			{
+				// The defaults that Transcend fills in are read back without being planned away again
+				Config: config(``),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(address, "scan_plugin_config.0.sample_size", "1000"),
+					resource.TestCheckResourceAttr(address, "scan_plugin_config.0.classifiers.#", "2"),
+					resource.TestCheckResourceAttr(address, "scan_plugin_config.0.settings.confidence", "MEDIUM"),
+				),
+			},
+			{
+				Config: config(`sample_size = 50`),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(address, "scan_plugin_config.0.sample_size", "50"),
+					resource.TestCheckResourceAttr(address, "scan_plugin_config.0.classifiers.#", "2"),
+				),
+			},
+		},
+	})
+}
@@ -5,100 +5,198 @@ import (
 
 	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	fwtypes "github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	graphql "github.com/hasura/go-graphql-client"
 )
 
-func resourceEnricher() *schema.Resource {
-	return &schema.Resource{
-		CreateContext: resourceEnricherCreate,
-		ReadContext:   resourceEnricherRead,
-		UpdateContext: resourceEnricherUpdate,
-		DeleteContext: resourceEnricherDelete,
-		Schema: map[string]*schema.Schema{
-			"id": &schema.Schema{
-				Type:     schema.TypeString,
+type enricherResource struct {
+	client *Client
+}
+
+type enricherModel struct {
+	ID                fwtypes.String        `tfsdk:"id"`
+	Title             fwtypes.String        `tfsdk:"title"`
+	Type              fwtypes.String        `tfsdk:"type"`
+	Description       fwtypes.String        `tfsdk:"description"`
+	URL               fwtypes.String        `tfsdk:"url"`
+	InputIdentifier   fwtypes.String        `tfsdk:"input_identifier"`
+	OutputIdentifiers fwtypes.List          `tfsdk:"output_identifiers"`
+	Actions           fwtypes.List          `tfsdk:"actions"`
+	Headers           []enricherHeaderModel `tfsdk:"headers"`
+}
+
+type enricherHeaderModel struct {
+	Name     fwtypes.String `tfsdk:"name"`
+	Value    fwtypes.String `tfsdk:"value"`
+	IsSecret fwtypes.Bool   `tfsdk:"is_secret"`
+}
+
+var (
+	_ resource.ResourceWithConfigure    = &enricherResource{}
+	_ resource.ResourceWithImportState  = &enricherResource{}
+	_ resource.ResourceWithUpgradeState = &enricherResource{}
+)
+
+func newEnricherResource() resource.Resource {
+	return &enricherResource{}
+}
+
+func (r *enricherResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_enricher"
+}
+
+func (r *enricherResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = enricherSchema()
+	// Version 0 is the state written by the SDKv2 implementation of this resource
+	resp.Schema.Version = 1
+}
+
+func enricherSchema() schema.Schema {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
 				Computed: true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
 			},
-			"title": &schema.Schema{
-				Type:        schema.TypeString,
+			"title": schema.StringAttribute{
 				Required:    true,
 				Description: "The enricher's title",
 			},
-			"type": &schema.Schema{
-				Type:        schema.TypeString,
+			"type": schema.StringAttribute{
 				Required:    true,
 				Description: "The enricher's title",
 			},
-			"description": &schema.Schema{
-				Type:        schema.TypeString,
+			"description": schema.StringAttribute{
 				Required:    true,
 				Description: "The enricher's description",
 			},
-			"url": &schema.Schema{
-				Type:        schema.TypeString,
+			"url": schema.StringAttribute{
 				Optional:    true,
 				Description: "The url that the enricher should post to",
 			},
-			"input_identifier": &schema.Schema{
-				Type:        schema.TypeString,
+			"input_identifier": schema.StringAttribute{
 				Required:    true,
 				Description: "The ID of the identifier that will be the input to the enricher",
 			},
-			"output_identifiers": &schema.Schema{
-				Type:     schema.TypeList,
-				Required: true,
-				Elem: &schema.Schema{
-					Type: schema.TypeString,
-				},
+			"output_identifiers": schema.ListAttribute{
+				ElementType: fwtypes.StringType,
+				Required:    true,
 				Description: "The IDs of the identifiers that can possibly be output from the enricher",
 			},
-			"actions": &schema.Schema{
-				Type:     schema.TypeList,
-				Required: true,
-				Elem: &schema.Schema{
-					Type: schema.TypeString,
-				},
+			"actions": schema.ListAttribute{
+				ElementType: fwtypes.StringType,
+				Required:    true,
 				Description: "The action types that the enricher should run for",
 			},
-			"headers": &schema.Schema{
-				Type:     schema.TypeList,
-				Optional: true,
-				MaxItems: 1,
-				Elem: &schema.Resource{
-					Schema: map[string]*schema.Schema{
-						"name": &schema.Schema{
-							Type:        schema.TypeString,
+		},
+		Blocks: map[string]schema.Block{
+			"headers": schema.ListNestedBlock{
+				Description: "Custom headers to include in outbound webhook",
+				Validators: []validator.List{
+					listvalidator.SizeAtMost(1),
+				},
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
 							Optional:    true,
 							Description: "The name of the custom header",
 						},
-						"value": &schema.Schema{
-							Type:        schema.TypeString,
+						"value": schema.StringAttribute{
 							Optional:    true,
 							Sensitive:   true,
 							Description: "The value of the custom header",
 						},
-						"is_secret": &schema.Schema{
-							Type:        schema.TypeBool,
+						"is_secret": schema.BoolAttribute{
 							Optional:    true,
 							Description: "When true, the value of this header will be considered sensitive",
 						},
 					},
 				},
-				Description: "Custom headers to include in outbound webhook",
 			},
 		},
-		Importer: &schema.ResourceImporter{
-			StateContext: schema.ImportStatePassthroughContext,
+	}
+}
+
+func (r *enricherResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	priorSchema := enricherSchema()
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema: &priorSchema,
+			StateUpgrader: func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+				var state enricherModel
+				resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+				if resp.Diagnostics.HasError() {
+					return
+				}
+				state.URL = nullIfEmptyString(state.URL)
+				for i, header := range state.Headers {
+					state.Headers[i] = enricherHeaderModel{
+						Name:     nullIfEmptyString(header.Name),
+						Value:    nullIfEmptyString(header.Value),
+						IsSecret: nullIfFalse(header.IsSecret),
+					}
+				}
+				resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+			},
 		},
 	}
 }
 
-func resourceEnricherCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*Client)
+func (r *enricherResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.client = frameworkClient(req.ProviderData, &resp.Diagnostics)
+}
+
+func (r *enricherResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+}
+
+func (r *enricherResource) updatableFields(ctx context.Context, model enricherModel) (types.EnricherUpdatableFields, fwdiag.Diagnostics) {
+	var diags fwdiag.Diagnostics
+	fields := types.EnricherUpdatableFields{
+		Title:           graphql.String(model.Title.ValueString()),
+		Description:     graphql.String(model.Description.ValueString()),
+		URL:             graphql.String(model.URL.ValueString()),
+		InputIdentifier: graphql.String(model.InputIdentifier.ValueString()),
+		Type:            types.EnricherType(model.Type.ValueString()),
+		Identifiers:     []graphql.String{},
+		// This is not a fully supported type, but it must be present on the creation mutation
+		PhoneNumbers: []graphql.String{},
+	}
+	for _, identifier := range listToStrings(ctx, model.OutputIdentifiers, &diags) {
+		fields.Identifiers = append(fields.Identifiers, graphql.String(identifier))
+	}
+	for _, action := range listToStrings(ctx, model.Actions, &diags) {
+		fields.Actions = append(fields.Actions, types.RequestAction(action))
+	}
+	for _, header := range model.Headers {
+		fields.Headers = append(fields.Headers, types.CustomHeaderInput{
+			Name:     graphql.String(header.Name.ValueString()),
+			Value:    graphql.String(header.Value.ValueString()),
+			IsSecret: graphql.Boolean(header.IsSecret.ValueBool()),
+		})
+	}
+	return fields, diags
+}
 
-	var diags diag.Diagnostics
+func (r *enricherResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan enricherModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	fields, diags := r.updatableFields(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	var mutation struct {
 		CreateEnricher struct {
@@ -107,55 +205,106 @@ func resourceEnricherCreate(ctx context.Context, d *schema.ResourceData, m inter
 	}
 
 	vars := map[string]interface{}{
-		"input": types.MakeEnricherInput(d),
+		"input": types.EnricherInput{EnricherUpdatableFields: fields},
 	}
 
-	err := client.graphql.Mutate(context.Background(), &mutation, vars, graphql.OperationName("CreateEnricher"))
+	err := r.client.graphql.Mutate(ctx, &mutation, vars, graphql.OperationName("CreateEnricher"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error creating " + d.Get("title").(string),
-			Detail:   "Error when creating enricher: " + err.Error(),
-		})
-		return diags
+		resp.Diagnostics.Append(frameworkErrorDiagnostics("Error creating "+plan.Title.ValueString(), err)...)
+		return
 	}
-	d.SetId(string(mutation.CreateEnricher.Enricher.ID))
+	plan.ID = fwtypes.StringValue(string(mutation.CreateEnricher.Enricher.ID))
 
-	return resourceEnricherRead(ctx, d, m)
+	found, diags := r.read(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if found {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	}
 }
 
-func resourceEnricherRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*Client)
-
-	var diags diag.Diagnostics
+// read refreshes the model from the backend, returning false when the enricher no longer exists.
+func (r *enricherResource) read(ctx context.Context, model *enricherModel) (bool, fwdiag.Diagnostics) {
+	var diags fwdiag.Diagnostics
 
 	var query struct {
 		Enricher types.Enricher `graphql:"enricher(id: $id)"`
 	}
 
 	vars := map[string]interface{}{
-		"id": graphql.ID(d.Get("id").(string)),
+		"id": graphql.ID(model.ID.ValueString()),
 	}
 
-	err := client.graphql.Query(context.Background(), &query, vars, graphql.OperationName("Enricher"))
+	err := r.client.graphql.Query(ctx, &query, vars, graphql.OperationName("Enricher"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error reading " + d.Get("title").(string),
-			Detail:   "Error when reading enricher: " + err.Error(),
-		})
-		return diags
+		if isNotFoundError(err) {
+			tflog.Warn(ctx, "Enricher "+model.Title.ValueString()+" no longer exists, removing it from state", map[string]interface{}{"id": model.ID.ValueString()})
+			return false, diags
+		}
+		diags.Append(frameworkErrorDiagnostics("Error reading "+model.Title.ValueString(), err)...)
+		return false, diags
+	}
+
+	enricher := query.Enricher
+	outputIdentifiers := make([]string, len(enricher.Identifiers))
+	for i, identifier := range enricher.Identifiers {
+		outputIdentifiers[i] = string(identifier.ID)
+	}
+	actions := make([]string, len(enricher.Actions))
+	for i, action := range enricher.Actions {
+		actions[i] = string(action)
 	}
 
-	types.ReadEnricherIntoState(d, query.Enricher)
+	model.Title = fwtypes.StringValue(string(enricher.Title))
+	model.Type = fwtypes.StringValue(string(enricher.Type))
+	model.Description = fwtypes.StringValue(string(enricher.Description))
+	model.URL = stringValue(string(enricher.URL), model.URL)
+	model.InputIdentifier = fwtypes.StringValue(string(enricher.InputIdentifier.ID))
+	model.OutputIdentifiers = stringsToList(outputIdentifiers, model.OutputIdentifiers, &diags)
+	model.Actions = stringsToList(actions, model.Actions, &diags)
 
-	return nil
+	var headers []enricherHeaderModel
+	for i, header := range enricher.Headers {
+		prior := enricherHeaderModel{Name: fwtypes.StringNull(), Value: fwtypes.StringNull(), IsSecret: fwtypes.BoolNull()}
+		if i < len(model.Headers) {
+			prior = model.Headers[i]
+		}
+		headers = append(headers, enricherHeaderModel{
+			Name:     stringValue(string(header.Name), prior.Name),
+			Value:    stringValue(string(header.Value), prior.Value),
+			IsSecret: boolValue(bool(header.IsSecret), prior.IsSecret),
+		})
+	}
+	model.Headers = headers
+	return true, diags
 }
 
-func resourceEnricherUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*Client)
+func (r *enricherResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state enricherModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	found, diags := r.read(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !found {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
 
-	var diags diag.Diagnostics
+func (r *enricherResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan enricherModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	fields, diags := r.updatableFields(ctx, plan)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	var mutation struct {
 		UpdateEnricher struct {
@@ -164,26 +313,31 @@ func resourceEnricherUpdate(ctx context.Context, d *schema.ResourceData, m inter
 	}
 
 	vars := map[string]interface{}{
-		"input": types.MakeUpdateEnricherInput(d),
+		"input": types.UpdateEnricherInput{
+			ID:                      graphql.String(plan.ID.ValueString()),
+			EnricherUpdatableFields: fields,
+		},
 	}
 
-	err := client.graphql.Mutate(context.Background(), &mutation, vars, graphql.OperationName("UpdateEnricher"))
+	err := r.client.graphql.Mutate(ctx, &mutation, vars, graphql.OperationName("UpdateEnricher"))
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error updating " + d.Get("title").(string),
-			Detail:   "Error when updating enricher: " + err.Error(),
-		})
-		return diags
+		resp.Diagnostics.Append(frameworkErrorDiagnostics("Error updating "+plan.Title.ValueString(), err)...)
+		return
 	}
 
-	return resourceEnricherRead(ctx, d, m)
+	found, diags := r.read(ctx, &plan)
+	resp.Diagnostics.Append(diags...)
+	if found {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+	}
 }
 
-func resourceEnricherDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*Client)
-
-	var diags diag.Diagnostics
+func (r *enricherResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state enricherModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
 	var mutation struct {
 		DeleteEnricher struct {
@@ -192,20 +346,12 @@ func resourceEnricherDelete(ctx context.Context, d *schema.ResourceData, m inter
 	}
 
 	vars := map[string]interface{}{
-		"id": graphql.ID(d.Get("id").(string)),
+		"id": graphql.ID(state.ID.ValueString()),
 	}
 
-	err := client.graphql.Mutate(context.Background(), &mutation, vars, graphql.OperationName("DeleteEnricher"))
-	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error deleting enricher " + d.Get("title").(string),
-			Detail:   err.Error(),
-		})
-		return diags
+	err := r.client.graphql.Mutate(ctx, &mutation, vars, graphql.OperationName("DeleteEnricher"))
+	// Objects that were already removed outside of Terraform have nothing left to delete
+	if err != nil && !isNotFoundError(err) {
+		resp.Diagnostics.Append(frameworkErrorDiagnostics("Error deleting enricher "+state.Title.ValueString(), err)...)
 	}
-
-	d.SetId("")
-
-	return nil
 }
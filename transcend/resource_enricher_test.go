@@ -7,6 +7,8 @@ import (
 	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	graphql "github.com/hasura/go-graphql-client"
 	"github.com/stretchr/testify/assert"
 )
@@ -28,6 +30,7 @@ func lookupEnricher(t *testing.T, id string) types.Enricher {
 }
 
 func prepareEnricherOptions(t *testing.T, vars map[string]interface{}) *terraform.Options {
+	skipUnlessAcceptance(t)
 	defaultVars := map[string]interface{}{"title": t.Name()}
 	for k, v := range vars {
 		defaultVars[k] = v
@@ -53,3 +56,97 @@ func TestCanCreateAndDestroyEnricher(t *testing.T) {
 	enricher := deployEnricher(t, options)
 	assert.Equal(t, graphql.String(t.Name()), enricher.Title)
 }
+
+func TestUnitEnricher(t *testing.T) {
+	backend := newFakeBackend(t)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + `
+data "transcend_identifier" "email" {
+  text = "email"
+}
+
+data "transcend_identifier" "phone" {
+  text = "phone"
+}
+
+resource "transcend_enricher" "enricher" {
+  title              = "unit test enricher"
+  description        = "looks up phone numbers"
+  url                = "https://example.acme.com/transcend-enrichment-webhook"
+  type               = "SERVER"
+  input_identifier   = data.transcend_identifier.email.id
+  output_identifiers = [data.transcend_identifier.phone.id]
+  actions            = ["ACCESS", "ERASURE"]
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet("transcend_enricher.enricher", "id"),
+					resource.TestCheckResourceAttr("transcend_enricher.enricher", "input_identifier", "identifier-email"),
+					resource.TestCheckResourceAttr("transcend_enricher.enricher", "output_identifiers.0", "identifier-phone"),
+					resource.TestCheckResourceAttr("transcend_enricher.enricher", "actions.#", "2"),
+				),
+			},
+			{
+				Config: backend.providerConfig() + `
+resource "transcend_enricher" "enricher" {
+  title              = "unit test enricher"
+  description        = "now with a header"
+  url                = "https://example.acme.com/transcend-enrichment-webhook"
+  type               = "SERVER"
+  input_identifier   = "identifier-email"
+  output_identifiers = ["identifier-phone", "identifier-core"]
+  actions            = ["ACCESS"]
+
+  headers {
+    name      = "x-api-key"
+    value     = "secret"
+    is_secret = true
+  }
+}
+`,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr("transcend_enricher.enricher", "description", "now with a header"),
+					resource.TestCheckResourceAttr("transcend_enricher.enricher", "output_identifiers.#", "2"),
+					resource.TestCheckResourceAttr("transcend_enricher.enricher", "headers.0.name", "x-api-key"),
+				),
+			},
+			{
+				ResourceName:      "transcend_enricher.enricher",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestEnricherStateFromSDKProvider(t *testing.T) {
+	state := upgradeSDKState(t, "transcend_enricher", `{
+  "actions": ["ACCESS"],
+  "description": "looks up phone numbers",
+  "headers": [{"is_secret": false, "name": "x-api-key", "value": "secret"}],
+  "id": "enricher-1",
+  "input_identifier": "identifier-email",
+  "output_identifiers": ["identifier-phone"],
+  "title": "unit test enricher",
+  "type": "SERVER",
+  "url": ""
+}`)
+	assert.True(t, state["id"].Equal(tftypes.NewValue(tftypes.String, "enricher-1")))
+	// Unset optional attributes were stored as empty values by the SDK, and are null in configurations
+	assert.True(t, state["url"].IsNull())
+	assert.True(t, state["output_identifiers"].Equal(tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{
+		tftypes.NewValue(tftypes.String, "identifier-phone"),
+	})))
+
+	var headers []tftypes.Value
+	assert.Nil(t, state["headers"].As(&headers))
+	assert.Len(t, headers, 1)
+	header := map[string]tftypes.Value{}
+	assert.Nil(t, headers[0].As(&header))
+	assert.True(t, header["is_secret"].IsNull())
+	assert.True(t, header["value"].Equal(tftypes.NewValue(tftypes.String, "secret")))
+}
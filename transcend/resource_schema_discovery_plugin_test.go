@@ -1,16 +1,21 @@
 package transcend
 
 import (
+	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/transcend-io/terraform-provider-transcend/transcend/types"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	sdkterraform "github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	graphql "github.com/hasura/go-graphql-client"
 	"github.com/stretchr/testify/assert"
 )
 
 func prepareSchemaDiscoveryPluginOptions(t *testing.T, vars map[string]interface{}) *terraform.Options {
+	skipUnlessAcceptance(t)
 	defaultVars := map[string]interface{}{"title": t.Name()}
 	for k, v := range vars {
 		defaultVars[k] = v
@@ -48,3 +53,179 @@ func TestCanUseSeparateSchemaDiscoveryPluginResource(t *testing.T) {
 	assert.Equal(t, graphql.String(t.Name()), silo.Title)
 	assert.NotEmpty(t, terraform.Output(t, options, "awsExternalId"))
 }
+
+// testUnitStandalonePlugin exercises one of the standalone plugin resources against the fake backend
+func testUnitStandalonePlugin(t *testing.T, resourceType string) {
+	backend := newFakeBackend(t)
+	address := resourceType + ".plugin"
+	config := func(enabled bool, frequency int) string {
+		return backend.providerConfig() + fmt.Sprintf(`
+resource "transcend_data_silo" "silo" {
+  type            = "amazonWebServices"
+  skip_connecting = true
+}
+
+resource %q "plugin" {
+  data_silo_id               = transcend_data_silo.silo.id
+  enabled                    = %t
+  schedule_frequency_minutes = %d
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+}
+`, resourceType, enabled, frequency)
+	}
+
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: config(true, 120),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrSet(address, "id"),
+					resource.TestCheckResourceAttrPair(address, "data_silo_id", "transcend_data_silo.silo", "id"),
+					resource.TestCheckResourceAttr(address, "enabled", "true"),
+					resource.TestCheckResourceAttr(address, "schedule_frequency_minutes", "120"),
+					resource.TestCheckResourceAttrSet(address, "last_enabled_at"),
+				),
+			},
+			{
+				Config: config(false, 60),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(address, "enabled", "false"),
+					resource.TestCheckResourceAttr(address, "schedule_frequency_minutes", "60"),
+				),
+			},
+			{
+				ResourceName:            address,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"ownership_id"},
+				ImportStateIdFunc: func(s *sdkterraform.State) (string, error) {
+					return s.RootModule().Resources[address].Primary.Attributes["data_silo_id"], nil
+				},
+			},
+		},
+	})
+}
+
+func TestUnitSchemaDiscoveryPlugin(t *testing.T) {
+	testUnitStandalonePlugin(t, "transcend_schema_discovery_plugin")
+}
+
+var managedByIdenticalResources = regexp.MustCompile(`the SCHEMA_DISCOVERY plugin of data silo \S+ is managed by two resources\s+that are both a transcend_schema_discovery_plugin resource with enabled =\s+true,\s+schedule_frequency_minutes = 1440,`)
+
+func TestUnitSchemaDiscoveryPluginManagedByTwoResources(t *testing.T) {
+	backend := newFakeBackend(t)
+	silo := `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonDynamodb"
+  skip_connecting = true
+}
+`
+	plugin := func(name string, frequency int) string {
+		return fmt.Sprintf(`
+resource "transcend_schema_discovery_plugin" %q {
+  data_silo_id               = transcend_data_silo.silo.id
+  schedule_frequency_minutes = %d
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+}
+`, name, frequency)
+	}
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				Config: backend.providerConfig() + silo + plugin("daily", 1440),
+			},
+			{
+				Config:      backend.providerConfig() + silo + plugin("daily", 1440) + plugin("hourly", 60),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`the SCHEMA_DISCOVERY plugin of data silo \S+ is managed by both a\s+transcend_schema_discovery_plugin\s+resource with enabled = true,\s+schedule_frequency_minutes = (1440|60),[\s\S]+and a\s+transcend_schema_discovery_plugin\s+resource with enabled = true,\s+schedule_frequency_minutes = (60|1440),`),
+			},
+			{
+				// Resources with the same settings are told apart too
+				Config:      backend.providerConfig() + silo + plugin("daily", 1440) + plugin("copy", 1440),
+				PlanOnly:    true,
+				ExpectError: managedByIdenticalResources,
+			},
+		},
+	})
+}
+
+func TestUnitSchemaDiscoveryPluginManagedByTwoIdenticalResources(t *testing.T) {
+	backend := newFakeBackend(t)
+	config := backend.providerConfig() + `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonDynamodb"
+  skip_connecting = true
+}
+
+resource "transcend_schema_discovery_plugin" "plugin" {
+  data_silo_id               = transcend_data_silo.silo.id
+  schedule_frequency_minutes = 1440
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+}
+
+resource "transcend_schema_discovery_plugin" "copy" {
+  data_silo_id               = transcend_data_silo.silo.id
+  schedule_frequency_minutes = 1440
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+}
+`
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				// The data silo is only known once created, so this is caught when applying
+				Config:      config,
+				ExpectError: managedByIdenticalResources,
+			},
+		},
+	})
+}
+
+func TestUnitSchemaDiscoveryPluginManagedTwice(t *testing.T) {
+	backend := newFakeBackend(t)
+	silo := `
+resource "transcend_data_silo" "silo" {
+  type            = "amazonDynamodb"
+  skip_connecting = true
+
+  schema_discovery_plugin {
+    enabled                    = true
+    schedule_frequency_minutes = 120
+    schedule_start_at          = "2122-09-06T17:51:13.000Z"
+  }
+}
+`
+	plugin := `
+resource "transcend_schema_discovery_plugin" "plugin" {
+  data_silo_id               = transcend_data_silo.silo.id
+  enabled                    = true
+  schedule_frequency_minutes = 60
+  schedule_start_at          = "2122-09-06T17:51:13.000Z"
+}
+`
+	managedTwice := regexp.MustCompile(`the SCHEMA_DISCOVERY plugin of data silo \S+ is managed by both the\s+schema_discovery_plugin block of transcend_data_silo and a\s+transcend_schema_discovery_plugin resource`)
+	resource.UnitTest(t, resource.TestCase{
+		ProtoV5ProviderFactories: testProviderFactories(),
+		CheckDestroy:             backend.checkDestroyed,
+		Steps: []resource.TestStep{
+			{
+				// The data silo is only known once created, so this is caught when applying
+				Config:      backend.providerConfig() + silo + plugin,
+				ExpectError: managedTwice,
+			},
+			{
+				Config: backend.providerConfig() + silo,
+			},
+			{
+				Config:      backend.providerConfig() + silo + plugin,
+				PlanOnly:    true,
+				ExpectError: managedTwice,
+			},
+		},
+	})
+}
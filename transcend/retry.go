@@ -0,0 +1,183 @@
+package transcend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRetries   = 4
+	defaultMaxRetryWait = 30 * time.Second
+	defaultMinRetryWait = 500 * time.Millisecond
+)
+
+// RetryConfig controls how the backend and sombra transports replay failed requests.
+type RetryConfig struct {
+	// The maximum number of times a single request will be retried. Zero disables retries.
+	MaxRetries int
+	// The upper bound on how long to wait between two attempts, including waits requested
+	// by the server through a Retry-After header.
+	MaxWait time.Duration
+
+	// The wait before the first retry, doubled on every subsequent attempt.
+	minWait time.Duration
+}
+
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries: defaultMaxRetries,
+		MaxWait:    defaultMaxRetryWait,
+		minWait:    defaultMinRetryWait,
+	}
+}
+
+// replayPolicy decides whether a request can be sent again without risking a duplicate side effect.
+type replayPolicy func(req *http.Request, body []byte) bool
+
+// Sombra's register-saas route only encrypts the provided secrets and stores nothing,
+// so every sombra call is safe to replay.
+func alwaysReplayable(req *http.Request, body []byte) bool {
+	return true
+}
+
+// GraphQL queries are idempotent and can always be replayed. Mutations are only replayed when
+// the backend explicitly rejected them before doing any work, see isRetrySafeResponse.
+func isGraphQLQuery(req *http.Request, body []byte) bool {
+	var payload struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return false
+	}
+	return !strings.HasPrefix(strings.TrimSpace(payload.Query), "mutation")
+}
+
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// A 429 or 503 that carries a Retry-After header means the request was turned away before it
+// was processed, which is the backend's signal that even a mutation can be sent again.
+func isRetrySafeResponse(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	return resp.Header.Get("Retry-After") != ""
+}
+
+// parseRetryAfter supports both the delay-seconds and the HTTP-date forms of the header.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := date.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+// backoff returns a randomized wait in [0, min(MaxWait, minWait * 2^attempt)] ("full jitter").
+func (c RetryConfig) backoff(attempt int) time.Duration {
+	wait := c.minWait
+	if wait <= 0 {
+		wait = defaultMinRetryWait
+	}
+	for i := 0; i < attempt && wait < c.MaxWait; i++ {
+		wait *= 2
+	}
+	if wait > c.MaxWait {
+		wait = c.MaxWait
+	}
+	if wait <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(wait) + 1))
+}
+
+// roundTripWithRetries sends req through base, replaying it on transient failures according to config.
+// prepare is called on every attempt so that headers can be (re)applied to a fresh copy of the request.
+func roundTripWithRetries(req *http.Request, base http.RoundTripper, config RetryConfig, canReplay replayPolicy, prepare func(*http.Request)) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	idempotent := canReplay(req, body)
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req.Clone(req.Context())
+		if body != nil {
+			attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+			attemptReq.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(body)), nil
+			}
+		}
+		prepare(attemptReq)
+
+		resp, err := base.RoundTrip(attemptReq)
+		if attempt >= config.MaxRetries {
+			return resp, err
+		}
+
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if !idempotent || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+				return resp, err
+			}
+			wait = config.backoff(attempt)
+		case isRetryableStatus(resp.StatusCode):
+			if !idempotent && !isRetrySafeResponse(resp) {
+				return resp, err
+			}
+			wait = config.backoff(attempt)
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if retryAfter > config.MaxWait {
+					// The server asked for a longer pause than we are willing to wait
+					return resp, err
+				}
+				wait = retryAfter
+			}
+			// Drain the body so the underlying connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		default:
+			return resp, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
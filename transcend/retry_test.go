@@ -0,0 +1,136 @@
+package transcend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	graphql "github.com/hasura/go-graphql-client"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRetryTestClient(url string, maxRetries int) *Client {
+	retry := DefaultRetryConfig()
+	retry.MaxRetries = maxRetries
+	retry.minWait = time.Millisecond
+	return NewClientWithConfig(ClientConfig{URL: url, APIToken: "test-key", Retry: retry})
+}
+
+func TestQueriesAreRetriedOnGatewayErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"data":{"apiKey":{"id":"key-1"}}}`))
+	}))
+	defer server.Close()
+
+	var query struct {
+		APIKey struct {
+			ID graphql.String
+		} `graphql:"apiKey(id: $id)"`
+	}
+	err := newRetryTestClient(server.URL, 4).graphql.Query(context.Background(), &query, map[string]interface{}{"id": graphql.ID("key-1")})
+	assert.Nil(t, err)
+	assert.Equal(t, graphql.String("key-1"), query.APIKey.ID)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestQueriesGiveUpAfterMaxRetries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var query struct {
+		APIKey struct {
+			ID graphql.String
+		} `graphql:"apiKey(id: $id)"`
+	}
+	err := newRetryTestClient(server.URL, 2).graphql.Query(context.Background(), &query, map[string]interface{}{"id": graphql.ID("key-1")})
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestMutationsAreNotRetriedWithoutRetryAfter(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	var mutation struct {
+		DeleteApiKey struct {
+			Success graphql.Boolean
+		} `graphql:"deleteApiKey(id: $id)"`
+	}
+	err := newRetryTestClient(server.URL, 4).graphql.Mutate(context.Background(), &mutation, map[string]interface{}{"id": graphql.ID("key-1")})
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestMutationsAreRetriedWhenRateLimited(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"data":{"deleteApiKey":{"success":true}}}`))
+	}))
+	defer server.Close()
+
+	var mutation struct {
+		DeleteApiKey struct {
+			Success graphql.Boolean
+		} `graphql:"deleteApiKey(id: $id)"`
+	}
+	err := newRetryTestClient(server.URL, 4).graphql.Mutate(context.Background(), &mutation, map[string]interface{}{"id": graphql.ID("key-1")})
+	assert.Nil(t, err)
+	assert.True(t, bool(mutation.DeleteApiKey.Success))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestRetryAfterLongerThanMaxWaitIsNotRetried(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	var query struct {
+		APIKey struct {
+			ID graphql.String
+		} `graphql:"apiKey(id: $id)"`
+	}
+	err := newRetryTestClient(server.URL, 4).graphql.Query(context.Background(), &query, map[string]interface{}{"id": graphql.ID("key-1")})
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 9, 6, 17, 51, 13, 0, time.UTC)
+
+	wait, ok := parseRetryAfter("7", now)
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, wait)
+
+	wait, ok = parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Second, wait)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
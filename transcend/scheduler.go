@@ -0,0 +1,260 @@
+package transcend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxConcurrentRequests       = 10
+	defaultMaxConcurrentSombraRequests = 4
+)
+
+// SchedulerConfig bounds the load the provider puts on the backend and on sombra.
+type SchedulerConfig struct {
+	// The maximum number of GraphQL requests in flight at once. Zero means no limit.
+	MaxConcurrentRequests int
+	// The maximum number of sombra requests in flight at once. Zero means no limit.
+	MaxConcurrentSombraRequests int
+	// The sustained number of GraphQL requests sent per second, allowing bursts of up to one second worth of
+	// requests. Zero disables rate limiting.
+	MaxRequestsPerSecond float64
+}
+
+// scheduler bounds the number of requests in flight. When every slot is taken, waiting requests are grouped
+// by operation name and free slots are handed out round robin between the operations, so that a burst of one
+// operation (e.g. the subDataPoints queries of data point reads, or a slow mutation) cannot starve the others.
+type scheduler struct {
+	limit   int
+	limiter *tokenBucket
+
+	mu       sync.Mutex
+	inFlight int
+	waiting  map[string][]*schedulerWaiter
+	// The operations that have waiting requests, in the order they will be served
+	turns []string
+}
+
+type schedulerWaiter struct {
+	granted chan struct{}
+}
+
+func newScheduler(limit int, limiter *tokenBucket) *scheduler {
+	return &scheduler{
+		limit:   limit,
+		limiter: limiter,
+		waiting: map[string][]*schedulerWaiter{},
+	}
+}
+
+// acquire waits for the rate limit, and then for a free slot, before a request for the operation is sent. The
+// rate limit is waited on first so that a request does not hold a slot while it is not allowed to be sent. The
+// returned function must be called once the request is done.
+func (s *scheduler) acquire(ctx context.Context, operation string) (func(), error) {
+	if s.limiter != nil {
+		if err := s.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.acquireSlot(ctx, operation); err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func() {
+		once.Do(s.release)
+	}, nil
+}
+
+// scheduledTransport sends each attempt of a request once the scheduler lets it through. A slot is only held
+// while an attempt is in flight, so that a request sleeping before its next retry does not hold up the others.
+type scheduledTransport struct {
+	base      http.RoundTripper
+	scheduler *scheduler
+	operation string
+}
+
+func (t scheduledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.scheduler.acquire(req.Context(), t.operation)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	// The response is read before the slot is freed, as it is still being sent until then
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func (s *scheduler) acquireSlot(ctx context.Context, operation string) error {
+	if s.limit <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.inFlight < s.limit && len(s.turns) == 0 {
+		s.inFlight++
+		s.mu.Unlock()
+		return nil
+	}
+	waiter := &schedulerWaiter{granted: make(chan struct{})}
+	if len(s.waiting[operation]) == 0 {
+		s.turns = append(s.turns, operation)
+	}
+	s.waiting[operation] = append(s.waiting[operation], waiter)
+	s.mu.Unlock()
+
+	select {
+	case <-waiter.granted:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-waiter.granted:
+			// The slot was handed over while the context was canceled, so pass it on
+			s.releaseLocked()
+		default:
+			s.removeWaiterLocked(operation, waiter)
+		}
+		return ctx.Err()
+	}
+}
+
+func (s *scheduler) release() {
+	if s.limit <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+// releaseLocked hands the slot over to the first waiter of the operation whose turn it is, or frees it.
+func (s *scheduler) releaseLocked() {
+	if len(s.turns) == 0 {
+		s.inFlight--
+		return
+	}
+	operation := s.turns[0]
+	s.turns = s.turns[1:]
+	queue := s.waiting[operation]
+	if len(queue) == 1 {
+		delete(s.waiting, operation)
+	} else {
+		s.waiting[operation] = queue[1:]
+		s.turns = append(s.turns, operation)
+	}
+	close(queue[0].granted)
+}
+
+func (s *scheduler) removeWaiterLocked(operation string, waiter *schedulerWaiter) {
+	queue := s.waiting[operation]
+	for i, queued := range queue {
+		if queued == waiter {
+			queue = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) > 0 {
+		s.waiting[operation] = queue
+		return
+	}
+	delete(s.waiting, operation)
+	for i, turn := range s.turns {
+		if turn == operation {
+			s.turns = append(s.turns[:i:i], s.turns[i+1:]...)
+			break
+		}
+	}
+}
+
+// tokenBucket limits the rate of requests to ratePerSecond, letting up to burst requests through at once.
+type tokenBucket struct {
+	ratePerSecond float64
+	burst         float64
+	now           func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(ratePerSecond float64) *tokenBucket {
+	burst := math.Max(1, math.Ceil(ratePerSecond))
+	return &tokenBucket{
+		ratePerSecond: ratePerSecond,
+		burst:         burst,
+		now:           time.Now,
+		tokens:        burst,
+	}
+}
+
+// reserve takes a token and returns how long to wait before it can be used.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.ratePerSecond)
+	}
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.ratePerSecond * float64(time.Second))
+}
+
+func (b *tokenBucket) wait(ctx context.Context) error {
+	delay := b.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// graphQLOperation reads the type and name of the operation of a GraphQL request, leaving the body untouched.
+func graphQLOperation(req *http.Request) (operationType string, name string) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", ""
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "", ""
+	}
+
+	var payload struct {
+		Query string `json:"query"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return "", ""
+	}
+	if match := graphQLOperationPattern.FindStringSubmatch(payload.Query); match != nil {
+		return match[1], match[2]
+	}
+	return "", ""
+}
@@ -0,0 +1,207 @@
+package transcend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	graphql "github.com/hasura/go-graphql-client"
+	"github.com/stretchr/testify/assert"
+)
+
+// waitForWaiters blocks until the scheduler has queued the given number of requests
+func waitForWaiters(t *testing.T, s *scheduler, count int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		queued := 0
+		for _, queue := range s.waiting {
+			queued += len(queue)
+		}
+		s.mu.Unlock()
+		if queued == count {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d queued requests", count)
+}
+
+func TestSchedulerBoundsRequestsInFlight(t *testing.T) {
+	s := newScheduler(2, nil)
+	var inFlight, maxInFlight int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := s.acquire(context.Background(), "SubDataPoints")
+			assert.Nil(t, err)
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				previous := atomic.LoadInt32(&maxInFlight)
+				if current <= previous || atomic.CompareAndSwapInt32(&maxInFlight, previous, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			release()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), maxInFlight)
+	assert.Equal(t, 0, s.inFlight)
+}
+
+func TestSchedulerAlternatesBetweenOperations(t *testing.T) {
+	s := newScheduler(1, nil)
+	release, err := s.acquire(context.Background(), "UpdateDataSilo")
+	assert.Nil(t, err)
+
+	var mu sync.Mutex
+	var served []string
+	var wg sync.WaitGroup
+	enqueue := func(operation string, queued int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := s.acquire(context.Background(), operation)
+			assert.Nil(t, err)
+			mu.Lock()
+			served = append(served, operation)
+			mu.Unlock()
+			release()
+		}()
+		waitForWaiters(t, s, queued)
+	}
+	enqueue("UpdateDataSilo", 1)
+	enqueue("UpdateDataSilo", 2)
+	enqueue("UpdateDataSilo", 3)
+	enqueue("DataSilo", 4)
+
+	release()
+	wg.Wait()
+	assert.Equal(t, []string{"UpdateDataSilo", "DataSilo", "UpdateDataSilo", "UpdateDataSilo"}, served)
+}
+
+func TestSchedulerHonorsCanceledContexts(t *testing.T) {
+	s := newScheduler(1, nil)
+	release, err := s.acquire(context.Background(), "UpdateDataSilo")
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := s.acquire(ctx, "DataSilo")
+		done <- err
+	}()
+	waitForWaiters(t, s, 1)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	waitForWaiters(t, s, 0)
+
+	release()
+	release()
+	assert.Equal(t, 0, s.inFlight, "releasing twice should only free one slot")
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2022, 9, 6, 17, 0, 0, 0, time.UTC)
+	bucket := newTokenBucket(2)
+	bucket.now = func() time.Time { return now }
+
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+	assert.Equal(t, 500*time.Millisecond, bucket.reserve(), "the burst is used up")
+
+	now = now.Add(time.Second)
+	assert.Equal(t, time.Duration(0), bucket.reserve())
+	assert.Equal(t, 500*time.Millisecond, bucket.reserve())
+}
+
+func TestClientLimitsConcurrentGraphQLRequests(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			previous := atomic.LoadInt32(&maxInFlight)
+			if current <= previous || atomic.CompareAndSwapInt32(&maxInFlight, previous, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte(`{"data":{"identifier":{"id":"identifier-email"}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithConfig(ClientConfig{
+		URL:       server.URL,
+		APIToken:  "api-key",
+		Retry:     DefaultRetryConfig(),
+		Scheduler: SchedulerConfig{MaxConcurrentRequests: 3},
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var query struct {
+				Identifier struct {
+					ID graphql.String
+				} `graphql:"identifier(id: \"identifier-email\")"`
+			}
+			assert.Nil(t, client.graphql.Query(context.Background(), &query, nil, graphql.OperationName("Identifier")))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(3), maxInFlight)
+}
+
+func TestClientFreesSlotsWhileWaitingToRetry(t *testing.T) {
+	var rateLimited int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, operation := graphQLOperation(r); operation == "Identifier" && atomic.AddInt32(&rateLimited, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"data":{"identifier":{"id":"identifier-email"}}}`))
+	}))
+	defer server.Close()
+
+	client := NewClientWithConfig(ClientConfig{
+		URL:       server.URL,
+		APIToken:  "api-key",
+		Retry:     DefaultRetryConfig(),
+		Scheduler: SchedulerConfig{MaxConcurrentRequests: 1},
+	})
+	query := func(operation string) error {
+		var query struct {
+			Identifier struct {
+				ID graphql.String
+			} `graphql:"identifier(id: \"identifier-email\")"`
+		}
+		return client.graphql.Query(context.Background(), &query, nil, graphql.OperationName(operation))
+	}
+
+	retried := make(chan error)
+	go func() {
+		retried <- query("Identifier")
+	}()
+	for atomic.LoadInt32(&rateLimited) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	assert.Nil(t, query("OtherIdentifier"))
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "the rate limited request should not hold its slot while it waits")
+	assert.Nil(t, <-retried)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&rateLimited))
+}
@@ -0,0 +1,175 @@
+package transcend
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// TransportConfig customizes how connections to the backend or to sombra are made.
+type TransportConfig struct {
+	// PEM encoded certificates trusted in addition to the system roots
+	CABundlePEM string
+	// Disables certificate verification. Only meant for development stacks.
+	InsecureSkipVerify bool
+	// Proxy used for every request. When empty, the HTTPS_PROXY family of environment variables is honored.
+	HTTPSProxy string
+	// PEM encoded client certificate and key presented for mutual TLS
+	ClientCertificatePEM string
+	ClientKeyPEM         string
+}
+
+func (c TransportConfig) isDefault() bool {
+	return c == TransportConfig{}
+}
+
+// NewTransport builds an HTTP transport from the config, starting from the settings of http.DefaultTransport.
+func (c TransportConfig) NewTransport() (http.RoundTripper, error) {
+	if c.isDefault() {
+		return http.DefaultTransport, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CABundlePEM != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(c.CABundlePEM)) {
+			return nil, errors.New("the CA bundle does not contain any PEM encoded certificate")
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	if (c.ClientCertificatePEM == "") != (c.ClientKeyPEM == "") {
+		return nil, errors.New("a client certificate and a client key must be set together")
+	}
+	if c.ClientCertificatePEM != "" {
+		certificate, err := tls.X509KeyPair([]byte(c.ClientCertificatePEM), []byte(c.ClientKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate or key: %w", err)
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if c.HTTPSProxy != "" {
+		proxyURL, err := url.Parse(c.HTTPSProxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url %q", c.HTTPSProxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return transport, nil
+}
+
+// transportSchema is the provider block configuring the connections to either the backend or sombra.
+func transportSchema(name string, target string) *schema.Schema {
+	attribute := func(field string) string {
+		return name + ".0." + field
+	}
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "Custom TLS and proxy settings for connections to " + target,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"ca_bundle_file": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					ConflictsWith: []string{attribute("ca_bundle_pem")},
+					Description:   "Path to a PEM file of certificate authorities to trust in addition to the system roots",
+				},
+				"ca_bundle_pem": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					ConflictsWith: []string{attribute("ca_bundle_file")},
+					Description:   "PEM encoded certificate authorities to trust in addition to the system roots",
+				},
+				"insecure_skip_verify": {
+					Type:        schema.TypeBool,
+					Optional:    true,
+					Default:     false,
+					Description: "Skip verification of the server's certificate. Only use this for development stacks.",
+				},
+				"https_proxy": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "The proxy to send requests through, e.g. `http://proxy.acme.com:3128`. Defaults to the proxy set in the HTTPS_PROXY environment variable.",
+				},
+				"client_certificate_file": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					ConflictsWith: []string{attribute("client_certificate_pem")},
+					Description:   "Path to a PEM encoded client certificate to present for mutual TLS",
+				},
+				"client_certificate_pem": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					ConflictsWith: []string{attribute("client_certificate_file")},
+					Description:   "PEM encoded client certificate to present for mutual TLS",
+				},
+				"client_key_file": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					ConflictsWith: []string{attribute("client_key_pem")},
+					Description:   "Path to the PEM encoded private key of the client certificate",
+				},
+				"client_key_pem": {
+					Type:          schema.TypeString,
+					Optional:      true,
+					Sensitive:     true,
+					ConflictsWith: []string{attribute("client_key_file")},
+					Description:   "PEM encoded private key of the client certificate",
+				},
+			},
+		},
+	}
+}
+
+// readTransportConfig loads a transport block from the provider configuration, reading any referenced files.
+func readTransportConfig(d *schema.ResourceData, name string) (TransportConfig, error) {
+	blocks := d.Get(name).([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return TransportConfig{}, nil
+	}
+	block := blocks[0].(map[string]interface{})
+
+	pemOrFile := func(pemField string, fileField string) (string, error) {
+		if path := block[fileField].(string); path != "" {
+			contents, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("could not read %s.%s: %w", name, fileField, err)
+			}
+			return string(contents), nil
+		}
+		return block[pemField].(string), nil
+	}
+
+	config := TransportConfig{
+		InsecureSkipVerify: block["insecure_skip_verify"].(bool),
+		HTTPSProxy:         block["https_proxy"].(string),
+	}
+	var err error
+	if config.CABundlePEM, err = pemOrFile("ca_bundle_pem", "ca_bundle_file"); err != nil {
+		return config, err
+	}
+	if config.ClientCertificatePEM, err = pemOrFile("client_certificate_pem", "client_certificate_file"); err != nil {
+		return config, err
+	}
+	if config.ClientKeyPEM, err = pemOrFile("client_key_pem", "client_key_file"); err != nil {
+		return config, err
+	}
+	return config, nil
+}
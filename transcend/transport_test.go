@@ -0,0 +1,157 @@
+package transcend
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func serverCAPEM(server *httptest.Server) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+}
+
+// newClientCertificate creates a self signed certificate usable for client authentication
+func newClientCertificate(t *testing.T) (certPEM string, keyPEM string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "terraform"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func get(t *testing.T, config TransportConfig, url string) (*http.Response, error) {
+	transport, err := config.NewTransport()
+	assert.Nil(t, err)
+	return (&http.Client{Transport: transport}).Get(url)
+}
+
+func TestTransportTrustsCustomCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := get(t, TransportConfig{}, server.URL)
+	assert.NotNil(t, err, "the test server's certificate should not be trusted by default")
+
+	resp, err := get(t, TransportConfig{CABundlePEM: serverCAPEM(server)}, server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = get(t, TransportConfig{InsecureSkipVerify: true}, server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTransportPresentsClientCertificate(t *testing.T) {
+	certPEM, keyPEM := newClientCertificate(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM([]byte(certPEM))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	_, err := get(t, TransportConfig{CABundlePEM: serverCAPEM(server)}, server.URL)
+	assert.NotNil(t, err, "the server should reject connections without a client certificate")
+
+	resp, err := get(t, TransportConfig{CABundlePEM: serverCAPEM(server), ClientCertificatePEM: certPEM, ClientKeyPEM: keyPEM}, server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTransportSendsRequestsThroughProxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.URL.Host
+	}))
+	defer proxy.Close()
+
+	resp, err := get(t, TransportConfig{HTTPSProxy: proxy.URL}, "http://sombra.internal.acme.com/health")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "sombra.internal.acme.com", proxiedHost)
+}
+
+func TestInvalidTransportConfigs(t *testing.T) {
+	certPEM, keyPEM := newClientCertificate(t)
+	for name, config := range map[string]TransportConfig{
+		"ca bundle without certificates": {CABundlePEM: "not a certificate"},
+		"certificate without key":        {ClientCertificatePEM: certPEM},
+		"key without certificate":        {ClientKeyPEM: keyPEM},
+		"mismatched key":                 {ClientCertificatePEM: certPEM, ClientKeyPEM: "garbage"},
+		"proxy without host":             {HTTPSProxy: "proxy"},
+	} {
+		_, err := config.NewTransport()
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestReadTransportConfigLoadsFiles(t *testing.T) {
+	certPEM, keyPEM := newClientCertificate(t)
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client.key")
+	assert.Nil(t, os.WriteFile(certFile, []byte(certPEM), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, []byte(keyPEM), 0600))
+
+	d := schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"sombra_transport": []interface{}{
+			map[string]interface{}{
+				"ca_bundle_pem":           certPEM,
+				"client_certificate_file": certFile,
+				"client_key_file":         keyFile,
+				"https_proxy":             "http://proxy.acme.com:3128",
+			},
+		},
+	})
+
+	backend, err := readTransportConfig(d, "backend_transport")
+	assert.Nil(t, err)
+	assert.Equal(t, TransportConfig{}, backend)
+
+	sombra, err := readTransportConfig(d, "sombra_transport")
+	assert.Nil(t, err)
+	assert.Equal(t, TransportConfig{
+		CABundlePEM:          certPEM,
+		HTTPSProxy:           "http://proxy.acme.com:3128",
+		ClientCertificatePEM: certPEM,
+		ClientKeyPEM:         keyPEM,
+	}, sombra)
+
+	d = schema.TestResourceDataRaw(t, Provider().Schema, map[string]interface{}{
+		"backend_transport": []interface{}{
+			map[string]interface{}{"ca_bundle_file": filepath.Join(dir, "missing.pem")},
+		},
+	})
+	_, err = readTransportConfig(d, "backend_transport")
+	assert.NotNil(t, err)
+}
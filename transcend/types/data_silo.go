@@ -2,43 +2,50 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	graphql "github.com/hasura/go-graphql-client"
 )
 
 type DataSiloUpdatableFields struct {
-	Title                   graphql.String      `json:"title,omitempty"`
-	Description             graphql.String      `json:"description,omitempty"`
-	URL                     graphql.String      `json:"url,omitempty"`
-	NotifyEmailAddress      graphql.String      `json:"notifyEmailAddress,omitempty"`
-	IsLive                  graphql.Boolean     `json:"isLive"`
-	OwnerEmails             []graphql.String    `json:"ownerEmails"`
-	OwnerTeams              []graphql.String    `json:"teamNames"`
-	DataSubjectBlockListIds []graphql.String    `json:"dataSubjectBlockListIds"`
-	Headers                 []CustomHeaderInput `json:"headers"`
-	SombraId                graphql.String      `json:"sombraId,omitempty"`
+	Title              graphql.String   `json:"title,omitempty"`
+	Description        graphql.String   `json:"description,omitempty"`
+	URL                graphql.String   `json:"url,omitempty"`
+	NotifyEmailAddress graphql.String   `json:"notifyEmailAddress,omitempty"`
+	IsLive             graphql.Boolean  `json:"isLive"`
+	OwnerEmails        []graphql.String `json:"ownerEmails"`
+	OwnerTeams         []graphql.String `json:"teamNames"`
+	// Left out unless configured, so that the values set in the admin dashboard are kept
+	DataSubjectBlockListIds *[]graphql.String `json:"dataSubjectBlockListIds,omitempty"`
+	DependedOnDataSiloIds   *[]graphql.String `json:"dependedOnDataSiloIds,omitempty"`
+	// The IDs of the identifiers, looked up by name
+	Identifiers *[]graphql.String   `json:"identifiers,omitempty"`
+	Headers     []CustomHeaderInput `json:"headers"`
+	SombraId    graphql.String      `json:"sombraId,omitempty"`
+
+	// Left out unless configured, so that values set in the admin dashboard are kept
+	Notes                         *graphql.String                `json:"notes,omitempty"`
+	DataRetentionNote             *graphql.String                `json:"dataRetentionNote,omitempty"`
+	DataProcessingAgreementLink   *graphql.String                `json:"dataProcessingAgreementLink,omitempty"`
+	ContactName                   *graphql.String                `json:"contactName,omitempty"`
+	ContactEmail                  *graphql.String                `json:"contactEmail,omitempty"`
+	DataProcessingAgreementStatus *DataProcessingAgreementStatus `json:"dataProcessingAgreementStatus,omitempty"`
+	RecommendedForConsent         *graphql.Boolean               `json:"recommendedForConsent,omitempty"`
+	RecommendedForPrivacy         *graphql.Boolean               `json:"recommendedForPrivacy,omitempty"`
+	HasPersonalData               *graphql.Boolean               `json:"hasPersonalData,omitempty"`
+	DeprecationState              *DeprecationState              `json:"deprecationState,omitempty"`
 
 	// TODO: Support more fields
-	// Identifiers             []graphql.String    `json:"identifiers"`
-	// dependedOnDataSiloIds
 	// dependedOnDataSiloTitles
 	// ownerIds
 	// apiKeyId
 	// teams
 	// teamNames
-	// notes
-	// dataRetentionNote
-	// dataProcessingAgreementLink
-	// contactName
-	// contactEmail
-	// dataProcessingAgreementStatus
-	// recommendedForConsent
-	// recommendedForPrivacy
-	// hasPersonalData
-	// deprecationState
 }
 
 type CreateDataSilosInput struct {
@@ -51,6 +58,27 @@ type UpdateDataSiloInput struct {
 	DataSiloUpdatableFields
 }
 
+// UpdateDataSiloDependenciesInput only updates the dependencies of a data silo, leaving its other fields unchanged
+type UpdateDataSiloDependenciesInput struct {
+	Id                    graphql.ID       `json:"id"`
+	DependedOnDataSiloIds []graphql.String `json:"dependedOnDataSiloIds"`
+}
+
+func (UpdateDataSiloDependenciesInput) GetGraphQLType() string {
+	return "UpdateDataSiloInput"
+}
+
+// ArchiveDataSiloInput takes a data silo out of service without deleting it, leaving its other fields unchanged
+type ArchiveDataSiloInput struct {
+	Id               graphql.ID       `json:"id"`
+	IsLive           graphql.Boolean  `json:"isLive"`
+	DeprecationState DeprecationState `json:"deprecationState"`
+}
+
+func (ArchiveDataSiloInput) GetGraphQLType() string {
+	return "UpdateDataSiloInput"
+}
+
 type PlaintextContextInput struct {
 	Name  graphql.String `json:"name"`
 	Value graphql.String `json:"value"`
@@ -60,8 +88,30 @@ type PlaintextInformation struct {
 	Path graphql.String `json:"path"`
 }
 
+type FormItemOption struct {
+	Name graphql.String `json:"name"`
+}
+
+type FormItem struct {
+	Name        graphql.String   `json:"name"`
+	Type        graphql.String   `json:"type"`
+	IsPlaintext graphql.Boolean  `json:"isPlaintext"`
+	IsRequired  graphql.Boolean  `json:"isRequired"`
+	Options     []FormItemOption `json:"options"`
+}
+
+// FormConfig is one of the forms that can be filled to connect an integration, e.g. an API key or a
+// username and password
+type FormConfig struct {
+	PassportName graphql.String `json:"passportName"`
+	Type         graphql.String `json:"type"`
+	FormItems    []FormItem     `json:"formItems"`
+}
+
 type Catalog struct {
 	PlaintextInformation []PlaintextInformation `json:"plaintextInformation"`
+	FormConfigs          []FormConfig           `json:"formConfigs"`
+	SupportedPlugins     []PluginType           `json:"supportedPlugins"`
 	IntegrationConfig    struct {
 		ConfiguredBaseHosts struct {
 			PROD []graphql.String `graphql:"PROD"`
@@ -101,18 +151,31 @@ type DataSilo struct {
 	SubjectBlocklist []struct {
 		ID graphql.String `json:"id"`
 	} `json:"subjectBlocklist"`
+	Identifiers []Identifier `json:"identifiers"`
+	// The data silos that this data silo depends on
+	DependentDataSilos []struct {
+		ID graphql.String `json:"id"`
+	} `json:"dependentDataSilos"`
 	Headers          []Header                `json:"headers"`
 	OuterType        graphql.String          `json:"outerType"`
 	PlaintextContext []PlaintextContextInput `json:"plaintextContext"`
 	ConnectionState  DataSiloConnectionState `json:"connectionState"`
 	SombraId         graphql.String          `json:"sombraId,omitempty"`
 
+	Notes                         graphql.String                `json:"notes"`
+	DataRetentionNote             graphql.String                `json:"dataRetentionNote"`
+	DataProcessingAgreementLink   graphql.String                `json:"dataProcessingAgreementLink"`
+	ContactName                   graphql.String                `json:"contactName"`
+	ContactEmail                  graphql.String                `json:"contactEmail"`
+	DataProcessingAgreementStatus DataProcessingAgreementStatus `json:"dataProcessingAgreementStatus"`
+	RecommendedForConsent         graphql.Boolean               `json:"recommendedForConsent"`
+	RecommendedForPrivacy         graphql.Boolean               `json:"recommendedForPrivacy"`
+	HasPersonalData               graphql.Boolean               `json:"hasPersonalData"`
+	DeprecationState              DeprecationState              `json:"deprecationState"`
+
 	// Sombra enricher identifier mappings
 	EnricherIdentifierMappings []EnricherIdentifierMapping `json:"enricherIdentifierMappings"`
 	// TODO: Add support to DataSiloInput first
-	// Identifiers        []struct {
-	//  Name graphql.String `json:"name"`
-	// } `json:"identifiers"`
 	// PromptEmailTemplate struct {
 	//  ID graphql.String `json:"id,omitempty"`
 	// } `json:"promptEmailTemplate,omitempty"`
@@ -120,16 +183,25 @@ type DataSilo struct {
 	// TODO: Look up the schema here
 	// Teams   []struct{} `json:"teams"`
 	// ApiKeys []struct{} `json:"apiKeys"`
-	// DependentDataSilos []struct{} `json:"dependentDataSilos"`
 }
 
 type DataSiloBulkPreview struct {
-	ID          graphql.String `json:"id"`
-	Title       graphql.String `json:"title"`
-	Type        graphql.String `json:"type"`
-	Link        graphql.String `json:"link"`
-	Description graphql.String `json:"description"`
-	Owners      []struct {
+	ID                            graphql.String                `json:"id"`
+	Title                         graphql.String                `json:"title"`
+	Type                          graphql.String                `json:"type"`
+	Link                          graphql.String                `json:"link"`
+	Description                   graphql.String                `json:"description"`
+	Notes                         graphql.String                `json:"notes"`
+	DataRetentionNote             graphql.String                `json:"dataRetentionNote"`
+	DataProcessingAgreementLink   graphql.String                `json:"dataProcessingAgreementLink"`
+	ContactName                   graphql.String                `json:"contactName"`
+	ContactEmail                  graphql.String                `json:"contactEmail"`
+	DataProcessingAgreementStatus DataProcessingAgreementStatus `json:"dataProcessingAgreementStatus"`
+	RecommendedForConsent         graphql.Boolean               `json:"recommendedForConsent"`
+	RecommendedForPrivacy         graphql.Boolean               `json:"recommendedForPrivacy"`
+	HasPersonalData               graphql.Boolean               `json:"hasPersonalData"`
+	DeprecationState              DeprecationState              `json:"deprecationState"`
+	Owners                        []struct {
 		ID    graphql.String `json:"id"`
 		Email graphql.String `json:"email"`
 	} `json:"owners"`
@@ -170,6 +242,30 @@ type DiscoClassScanConfig struct {
 	Enabled           graphql.Boolean    `json:"enabled"`
 	ScheduleFrequency graphql.Int        `json:"scheduleFrequency"`
 	ScheduleStartAt   graphql.String     `json:"scheduleStartAt"`
+	ScanPluginConfigs []ScanPluginConfig `json:"scanPluginConfigs"`
+}
+
+// ScanPluginConfig configures how one plugin, like CONTENT_CLASSIFICATION, takes part in a disco class scan
+type ScanPluginConfig struct {
+	PluginType  PluginType          `json:"pluginType"`
+	Enabled     graphql.Boolean     `json:"enabled"`
+	Classifiers []graphql.String    `json:"classifiers"`
+	SampleSize  graphql.Int         `json:"sampleSize,omitempty"`
+	Settings    []ScanPluginSetting `json:"settings"`
+}
+
+type ScanPluginSetting struct {
+	Name  graphql.String `json:"name"`
+	Value graphql.String `json:"value"`
+}
+
+// ScanPluginConfigInput leaves out the values that are not configured, so that Transcend keeps its defaults
+type ScanPluginConfigInput struct {
+	PluginType  PluginType           `json:"pluginType"`
+	Enabled     graphql.Boolean      `json:"enabled"`
+	Classifiers *[]graphql.String    `json:"classifiers,omitempty"`
+	SampleSize  *graphql.Int         `json:"sampleSize,omitempty"`
+	Settings    *[]ScanPluginSetting `json:"settings,omitempty"`
 }
 
 type UpdateDiscoClassScanConfigInput struct {
@@ -178,7 +274,8 @@ type UpdateDiscoClassScanConfigInput struct {
 	Type                     *DiscoClassScanType `json:"type"`
 	ScheduleFrequencyMinutes graphql.Int         `json:"scheduleFrequency"`
 	ScheduleStartAt          graphql.String      `json:"scheduleStartAt"`
-	// Omitting scanPluginConfigs for now
+	// The scan plugin configs are left as they are when no scan_plugin_config block is configured
+	ScanPluginConfigs *[]ScanPluginConfigInput `json:"scanPluginConfigs,omitempty"`
 }
 
 type SombraOutput struct {
@@ -225,6 +322,11 @@ func MakeStandaloneUpdateDiscoClassScanConfigInput(d *schema.ResourceData) Updat
 		input.ScheduleStartAt = graphql.String(startAtVal)
 	}
 
+	if configured := configuredBlocks(d.GetRawConfig(), "scan_plugin_config"); len(configured) > 0 {
+		inputs := ToScanPluginConfigInputList(d.Get("scan_plugin_config").([]interface{}), configured)
+		input.ScanPluginConfigs = &inputs
+	}
+
 	return input
 }
 
@@ -246,9 +348,114 @@ func MakeUpdateDiscoClassScanConfigInput(d *schema.ResourceData, configuration m
 		input.ScheduleStartAt = graphql.String(startAtVal)
 	}
 
+	var configured []cty.Value
+	if blocks := configuredBlocks(d.GetRawConfig(), "disco_class_scan_config"); len(blocks) == 1 {
+		configured = configuredBlocks(blocks[0], "scan_plugin_config")
+	}
+	if scanPluginConfigs, ok := configuration["scan_plugin_config"].([]interface{}); ok && len(configured) > 0 {
+		inputs := ToScanPluginConfigInputList(scanPluginConfigs, configured)
+		input.ScanPluginConfigs = &inputs
+	}
+
 	return input
 }
 
+// configuredBlocks returns the blocks of the given name in a configuration, or nothing if they are not known.
+func configuredBlocks(config cty.Value, name string) []cty.Value {
+	if config.IsNull() || !config.IsKnown() || !config.Type().IsObjectType() || !config.Type().HasAttribute(name) {
+		return nil
+	}
+	blocks := config.GetAttr(name)
+	if blocks.IsNull() || !blocks.IsKnown() {
+		return nil
+	}
+	return blocks.AsValueSlice()
+}
+
+// ToScanPluginConfigInputList turns scan_plugin_config blocks into inputs. Only the values set in the configured
+// blocks are sent, as the others hold the defaults that Transcend filled in.
+func ToScanPluginConfigInputList(origs []interface{}, configured []cty.Value) []ScanPluginConfigInput {
+	vals := make([]ScanPluginConfigInput, len(origs))
+	for i, orig := range origs {
+		config := orig.(map[string]interface{})
+		isSet := func(attribute string) bool {
+			return i < len(configured) && !configured[i].GetAttr(attribute).IsNull()
+		}
+
+		vals[i] = ScanPluginConfigInput{
+			PluginType: PluginType(config["plugin_type"].(string)),
+			Enabled:    graphql.Boolean(config["enabled"].(bool)),
+		}
+		if isSet("classifiers") {
+			classifiers := toGraphQLStrings(config["classifiers"].(*schema.Set))
+			vals[i].Classifiers = &classifiers
+		}
+		if isSet("sample_size") {
+			sampleSize := graphql.Int(config["sample_size"].(int))
+			vals[i].SampleSize = &sampleSize
+		}
+		if isSet("settings") {
+			rawSettings := config["settings"].(map[string]interface{})
+			names := make([]string, 0, len(rawSettings))
+			for name := range rawSettings {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			settings := make([]ScanPluginSetting, len(names))
+			for j, name := range names {
+				settings[j] = ScanPluginSetting{
+					Name:  graphql.String(name),
+					Value: graphql.String(rawSettings[name].(string)),
+				}
+			}
+			vals[i].Settings = &settings
+		}
+	}
+
+	return vals
+}
+
+// FlattenScanPluginConfigs turns scan plugin configs into scan_plugin_config blocks. The blocks keep the order of
+// the current ones, so that the order in which Transcend returns the configs is not reported as drift.
+func FlattenScanPluginConfigs(configs []ScanPluginConfig, current []interface{}) []interface{} {
+	position := map[string]int{}
+	for i, block := range current {
+		if block != nil {
+			position[block.(map[string]interface{})["plugin_type"].(string)] = i
+		}
+	}
+	sorted := make([]ScanPluginConfig, len(configs))
+	copy(sorted, configs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, iKnown := position[string(sorted[i].PluginType)]
+		pj, jKnown := position[string(sorted[j].PluginType)]
+		if iKnown != jKnown {
+			return iKnown
+		}
+		return pi < pj
+	})
+
+	ret := make([]interface{}, len(sorted))
+	for i, config := range sorted {
+		classifiers := make([]interface{}, len(config.Classifiers))
+		for j, classifier := range config.Classifiers {
+			classifiers[j] = string(classifier)
+		}
+		settings := map[string]interface{}{}
+		for _, setting := range config.Settings {
+			settings[string(setting.Name)] = string(setting.Value)
+		}
+		ret[i] = map[string]interface{}{
+			"plugin_type": string(config.PluginType),
+			"enabled":     bool(config.Enabled),
+			"classifiers": classifiers,
+			"sample_size": int(config.SampleSize),
+			"settings":    settings,
+		}
+	}
+	return ret
+}
+
 func ReadDiscoClassScanConfigIntoState(d *schema.ResourceData, config DiscoClassScanConfig) {
 	// Convert the disco class scan config to the nested block format
 	// Even though there's only one config per data silo, Terraform requires it as a list
@@ -267,6 +474,11 @@ func ReadDiscoClassScanConfigIntoState(d *schema.ResourceData, config DiscoClass
 		scheduleStartAt = string(config.ScheduleStartAt)
 	}
 
+	var currentScanPluginConfigs []interface{}
+	if blocks := d.Get("disco_class_scan_config").([]interface{}); len(blocks) == 1 && blocks[0] != nil {
+		currentScanPluginConfigs, _ = blocks[0].(map[string]interface{})["scan_plugin_config"].([]interface{})
+	}
+
 	discoClassScanConfig := []interface{}{
 		map[string]interface{}{
 			"id":                         string(config.ID),
@@ -274,6 +486,7 @@ func ReadDiscoClassScanConfigIntoState(d *schema.ResourceData, config DiscoClass
 			"type":                       typeStr,
 			"schedule_frequency_minutes": frequencyMinutes,
 			"schedule_start_at":          scheduleStartAt,
+			"scan_plugin_config":         FlattenScanPluginConfigs(config.ScanPluginConfigs, currentScanPluginConfigs),
 		},
 	}
 	d.Set("disco_class_scan_config", discoClassScanConfig)
@@ -291,10 +504,29 @@ func ReadStandaloneDataSiloPluginIntoState(d *schema.ResourceData, plugin Plugin
 	d.Set("schedule_frequency_minutes", frequency/60/1000)
 	d.Set("schedule_start_at", plugin.ScheduleStartAt)
 	d.Set("last_enabled_at", plugin.LastEnabledAt)
+	d.Set("last_run_at", plugin.LastRunAt)
+	d.Set("scheduled_at", plugin.ScheduledAt)
+	d.Set("error", plugin.Error)
+}
+
+// The blocks of a data silo that configure each type of plugin
+var dataSiloPluginBlocks = map[PluginType]string{
+	"SCHEMA_DISCOVERY":       "schema_discovery_plugin",
+	"CONTENT_CLASSIFICATION": "content_classification_plugin",
+	"DATA_SILO_DISCOVERY":    "data_silo_discovery_plugin",
 }
 
 func ReadDataSiloPluginsIntoState(d *schema.ResourceData, plugins []Plugin) {
 	for _, plugin := range plugins {
+		block, ok := dataSiloPluginBlocks[plugin.Type]
+		if !ok {
+			continue
+		}
+		// Only set if the block is configured in the original config
+		current, ok := d.GetOk(block)
+		if !ok {
+			continue
+		}
 		frequency, err := strconv.Atoi(string(plugin.ScheduleFrequency))
 		if err == nil {
 			configuration := map[string]interface{}{
@@ -304,24 +536,13 @@ func ReadDataSiloPluginsIntoState(d *schema.ResourceData, plugins []Plugin) {
 				"schedule_start_at":          plugin.ScheduleStartAt,
 				"last_enabled_at":            plugin.LastRunAt,
 			}
-
-			switch plugin.Type {
-			case "SCHEMA_DISCOVERY":
-				// Only set if schema_discovery_plugin is configured in the original config
-				if _, ok := d.GetOk("schema_discovery_plugin"); ok {
-					d.Set("schema_discovery_plugin", []interface{}{configuration})
-				}
-			case "CONTENT_CLASSIFICATION":
-				// Only set if content_classification_plugin is configured in the original config
-				if _, ok := d.GetOk("content_classification_plugin"); ok {
-					d.Set("content_classification_plugin", []interface{}{configuration})
-				}
-			case "DATA_SILO_DISCOVERY":
-				// Only set if data_silo_discovery_plugin is configured in the original config
-				if _, ok := d.GetOk("data_silo_discovery_plugin"); ok {
-					d.Set("data_silo_discovery_plugin", []interface{}{configuration})
+			// The settings of the runs are only known to Terraform
+			if blocks := current.([]interface{}); len(blocks) == 1 && blocks[0] != nil {
+				for _, key := range []string{"run_on_apply", "triggers", "wait_for_completion"} {
+					configuration[key] = blocks[0].(map[string]interface{})[key]
 				}
 			}
+			d.Set(block, []interface{}{configuration})
 		}
 	}
 }
@@ -352,7 +573,7 @@ func CreateDataSiloUpdatableFields(d *schema.ResourceData) DataSiloUpdatableFiel
 		teamsGraphql[i] = graphql.String(v)
 	}
 
-	return DataSiloUpdatableFields{
+	fields := DataSiloUpdatableFields{
 		Title:              graphql.String(d.Get("title").(string)),
 		Description:        graphql.String(d.Get("description").(string)),
 		URL:                graphql.String(d.Get("url").(string)),
@@ -364,15 +585,80 @@ func CreateDataSiloUpdatableFields(d *schema.ResourceData) DataSiloUpdatableFiel
 		SombraId:           graphql.String(d.Get("sombra_id").(string)),
 
 		// TODO: Add more fields
-		// DataSubjectBlockListIds: toStringList(d.Get("data_subject_block_list_ids")),
-		// Identifiers:             toStringList(d.Get("identifiers").([]interface{})),
 		// "api_key_id":                   graphql.ID(d.Get("api_key_id").(string)),
 		// "depended_on_data_silo_titles": toStringList(d.Get("depended_on_data_silo_titles").([]interface{})),
 		// "team_names":                   toStringList(d.Get("team_names").([]interface{})),
 	}
+
+	// These fields are computed from the admin dashboard when they are not configured
+	config := d.GetRawConfig()
+	vendorStrings := map[string]**graphql.String{
+		"notes":                          &fields.Notes,
+		"data_retention_note":            &fields.DataRetentionNote,
+		"data_processing_agreement_link": &fields.DataProcessingAgreementLink,
+		"contact_name":                   &fields.ContactName,
+		"contact_email":                  &fields.ContactEmail,
+	}
+	for attribute, field := range vendorStrings {
+		if isConfigured(config, attribute) {
+			value := graphql.String(d.Get(attribute).(string))
+			*field = &value
+		}
+	}
+	if isConfigured(config, "data_processing_agreement_status") {
+		status := DataProcessingAgreementStatus(d.Get("data_processing_agreement_status").(string))
+		fields.DataProcessingAgreementStatus = &status
+	}
+	if isConfigured(config, "deprecation_state") {
+		state := DeprecationState(d.Get("deprecation_state").(string))
+		fields.DeprecationState = &state
+	}
+	if isConfigured(config, "recommended_for_consent") {
+		value := graphql.Boolean(d.Get("recommended_for_consent").(bool))
+		fields.RecommendedForConsent = &value
+	}
+	if isConfigured(config, "recommended_for_privacy") {
+		value := graphql.Boolean(d.Get("recommended_for_privacy").(bool))
+		fields.RecommendedForPrivacy = &value
+	}
+	if isConfigured(config, "has_personal_data") {
+		value := graphql.Boolean(d.Get("has_personal_data").(bool))
+		fields.HasPersonalData = &value
+	}
+	if isConfigured(config, "data_subject_block_list_ids") {
+		ids := toGraphQLStrings(d.Get("data_subject_block_list_ids").(*schema.Set))
+		fields.DataSubjectBlockListIds = &ids
+	}
+	if isConfigured(config, "depended_on_data_silo_ids") {
+		ids := toGraphQLStrings(d.Get("depended_on_data_silo_ids").(*schema.Set))
+		fields.DependedOnDataSiloIds = &ids
+	}
+	return fields
+}
+
+func isConfigured(config cty.Value, attribute string) bool {
+	return !config.IsNull() && config.IsKnown() && !config.GetAttr(attribute).IsNull()
+}
+
+func toGraphQLStrings(set *schema.Set) []graphql.String {
+	values := make([]string, set.Len())
+	for i, v := range set.List() {
+		values[i] = v.(string)
+	}
+	sort.Strings(values)
+	ret := make([]graphql.String, len(values))
+	for i, v := range values {
+		ret[i] = graphql.String(v)
+	}
+	return ret
+}
+
+// ResourceGetter reads the attributes of a resource, either from its state or from its planned diff
+type ResourceGetter interface {
+	Get(key string) interface{}
 }
 
-func GetIntegrationName(d *schema.ResourceData) string {
+func GetIntegrationName(d ResourceGetter) string {
 	// Determine the type of the data silo. Most often, this is just the `type` field.
 	// But for AVC silos, the `outer_type` actually contains the name to use, as the `type`
 	// is always "promptAPerson"
@@ -432,13 +718,14 @@ func toStringList(l []graphql.String) []string {
 	return ret
 }
 
-func ConstructSecretMapString(d *schema.ResourceData, allowedHosts []graphql.String, allowedPlaintextPathObjs []PlaintextInformation, allowedIdentifierPaths []AllowedIdentifierPath) ([]byte, error) {
+func ConstructSecretMapString(secretContext map[string]string, allowedHosts []graphql.String, allowedPlaintextPathObjs []PlaintextInformation, allowedIdentifierPaths []AllowedIdentifierPath, formItemSecrets map[string]string) ([]byte, error) {
 	// Construct secret map
-	contextSet := d.Get("secret_context").(*schema.Set)
 	contextMap := map[string]string{}
-	for _, rawContext := range contextSet.List() {
-		context := rawContext.(map[string]interface{})
-		contextMap[context["name"].(string)] = context["value"].(string)
+	for name, value := range secretContext {
+		contextMap[name] = value
+	}
+	for name, value := range formItemSecrets {
+		contextMap[name] = value
 	}
 
 	// Construct plaintext paths
@@ -455,6 +742,181 @@ func ConstructSecretMapString(d *schema.ResourceData, allowedHosts []graphql.Str
 	})
 }
 
+// ValidateFormItems checks the form_items of a data silo against the forms of its integration. The items must
+// all belong to a single form, and unless requireAll is false, every required item of that form must be given.
+func ValidateFormItems(integrationName string, catalog Catalog, formItems map[string]interface{}, requireAll bool) error {
+	if len(formItems) == 0 {
+		return nil
+	}
+	if len(catalog.FormConfigs) == 0 {
+		return fmt.Errorf("the %s integration does not take any form items", integrationName)
+	}
+
+	names := make([]string, 0, len(formItems))
+	for name := range formItems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Find the forms that have every given item
+	known := map[string]bool{}
+	var candidates []FormConfig
+	for _, config := range catalog.FormConfigs {
+		configItems := map[string]bool{}
+		for _, item := range config.FormItems {
+			configItems[string(item.Name)] = true
+			known[string(item.Name)] = true
+		}
+		matches := true
+		for _, name := range names {
+			if !configItems[name] {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			candidates = append(candidates, config)
+		}
+	}
+
+	if len(candidates) == 0 {
+		var unknown []string
+		for _, name := range names {
+			if !known[name] {
+				unknown = append(unknown, strconv.Quote(name))
+			}
+		}
+		if len(unknown) > 0 {
+			return fmt.Errorf("unknown form items %s for the %s integration, expected any of: %s", strings.Join(unknown, ", "), integrationName, strings.Join(sortedKeys(known), ", "))
+		}
+		return fmt.Errorf("the form items %s of the %s integration belong to different forms and cannot be used together", strings.Join(names, ", "), integrationName)
+	}
+	if !requireAll {
+		return nil
+	}
+
+	// Report the missing items of the form that is the closest to being complete
+	var missing []string
+	for i, config := range candidates {
+		var configMissing []string
+		for _, item := range config.FormItems {
+			if _, ok := formItems[string(item.Name)]; !ok && bool(item.IsRequired) {
+				configMissing = append(configMissing, string(item.Name))
+			}
+		}
+		if len(configMissing) == 0 {
+			return nil
+		}
+		if i == 0 || len(configMissing) < len(missing) {
+			missing = configMissing
+		}
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("missing required form items for the %s integration: %s", integrationName, strings.Join(missing, ", "))
+}
+
+// SplitFormItems routes each of the form_items of a data silo to the plaintext context or to the secret map
+// encrypted by sombra, as marked in the forms of its integration.
+func SplitFormItems(catalog Catalog, formItems map[string]interface{}) ([]PlaintextContextInput, map[string]string) {
+	isPlaintext := map[string]bool{}
+	for _, config := range catalog.FormConfigs {
+		for _, item := range config.FormItems {
+			isPlaintext[string(item.Name)] = bool(item.IsPlaintext)
+		}
+	}
+
+	names := make([]string, 0, len(formItems))
+	for name := range formItems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	plaintext := []PlaintextContextInput{}
+	secrets := map[string]string{}
+	for _, name := range names {
+		value := formItems[name].(string)
+		if isPlaintext[name] {
+			plaintext = append(plaintext, PlaintextContextInput{Name: graphql.String(name), Value: graphql.String(value)})
+		} else {
+			secrets[name] = value
+		}
+	}
+	return plaintext, secrets
+}
+
+// FormItems indexes the items of every form of the integration by name
+func (c Catalog) FormItems() map[string]FormItem {
+	items := map[string]FormItem{}
+	for _, config := range c.FormConfigs {
+		for _, item := range config.FormItems {
+			items[string(item.Name)] = item
+		}
+	}
+	return items
+}
+
+// FormItemNames lists the names of the items of every form of the integration
+func (c Catalog) FormItemNames() []string {
+	names := map[string]bool{}
+	for name := range c.FormItems() {
+		names[name] = true
+	}
+	return sortedKeys(names)
+}
+
+func (c Catalog) SupportsPlugin(pluginType PluginType) bool {
+	for _, supported := range c.SupportedPlugins {
+		if supported == pluginType {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateFormItemValue checks a value given for a form item against the type and the options of the item.
+// The value is left out of the errors, as it may be a secret.
+func ValidateFormItemValue(item FormItem, value string) error {
+	if len(item.Options) > 0 {
+		options := make([]string, len(item.Options))
+		for i, option := range item.Options {
+			if string(option.Name) == value {
+				return nil
+			}
+			options[i] = strconv.Quote(string(option.Name))
+		}
+		return fmt.Errorf("the value is not an option of the %s form item, expected one of: %s", item.Name, strings.Join(options, ", "))
+	}
+
+	switch item.Type {
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("the %s form item expects a boolean", item.Name)
+		}
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("the %s form item expects a number", item.Name)
+		}
+	case "json":
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("the %s form item expects a JSON document", item.Name)
+		}
+	case "url":
+		if !strings.HasPrefix(value, "https://") && !strings.HasPrefix(value, "http://") {
+			return fmt.Errorf("the %s form item expects a URL starting with http:// or https://", item.Name)
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func ReadDataSiloIntoState(d *schema.ResourceData, silo DataSilo) {
 	d.Set("id", silo.ID)
 	d.Set("link", silo.Link)
@@ -469,7 +931,7 @@ func ReadDataSiloIntoState(d *schema.ResourceData, silo DataSilo) {
 		d.Set("url", silo.URL)
 	}
 	d.Set("outer_type", silo.OuterType)
-	if d.Get("notify_email") != nil {
+	if d.Get("notify_email_address") != nil {
 		d.Set("notify_email_address", silo.NotifyEmailAddress)
 	}
 	if d.Get("is_live") != nil {
@@ -479,14 +941,23 @@ func ReadDataSiloIntoState(d *schema.ResourceData, silo DataSilo) {
 	d.Set("owner_emails", FlattenOwners(silo))
 	d.Set("owner_teams", FlattenOwnerTeams(silo))
 	d.Set("headers", FlattenHeaders(&silo.Headers))
+	d.Set("notes", silo.Notes)
+	d.Set("data_retention_note", silo.DataRetentionNote)
+	d.Set("data_processing_agreement_link", silo.DataProcessingAgreementLink)
+	d.Set("contact_name", silo.ContactName)
+	d.Set("contact_email", silo.ContactEmail)
+	d.Set("data_processing_agreement_status", silo.DataProcessingAgreementStatus)
+	d.Set("recommended_for_consent", silo.RecommendedForConsent)
+	d.Set("recommended_for_privacy", silo.RecommendedForPrivacy)
+	d.Set("has_personal_data", silo.HasPersonalData)
+	d.Set("deprecation_state", silo.DeprecationState)
+	d.Set("identifiers", FlattenIdentifiers(silo))
+	d.Set("depended_on_data_silo_ids", FlattenDependedOnDataSilos(silo))
+	d.Set("data_subject_block_list_ids", FlattenDataSiloBlockList(silo))
 
 	// TODO: Support these fields being read in
-	// d.Set("data_subject_block_list", flattenDataSiloBlockList(silo))
-	// d.Set("identifiers", silo.Identifiers)
 	// d.Set("prompt_email_template_id", silo.PromptEmailTemplate.ID)
 	// d.Set("team_names", ...)
-	// d.Set("depended_on_data_silo_ids", ...)
-	// d.Set("data_subject_block_list_ids", ...)
 	// d.Set("api_key_id", ...)
 }
 
@@ -522,7 +993,25 @@ func FlattenDataSiloBlockList(dataSilo DataSilo) []interface{} {
 	owners := dataSilo.SubjectBlocklist
 	ret := make([]interface{}, len(owners))
 	for i, owner := range owners {
-		ret[i] = owner.ID
+		ret[i] = string(owner.ID)
+	}
+	return ret
+}
+
+func FlattenIdentifiers(dataSilo DataSilo) []interface{} {
+	identifiers := dataSilo.Identifiers
+	ret := make([]interface{}, len(identifiers))
+	for i, identifier := range identifiers {
+		ret[i] = string(identifier.Name)
+	}
+	return ret
+}
+
+func FlattenDependedOnDataSilos(dataSilo DataSilo) []interface{} {
+	dependencies := dataSilo.DependentDataSilos
+	ret := make([]interface{}, len(dependencies))
+	for i, dependency := range dependencies {
+		ret[i] = string(dependency.ID)
 	}
 	return ret
 }
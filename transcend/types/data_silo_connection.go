@@ -11,14 +11,6 @@ type ReconnectDataSiloInput struct {
 	PresignedSaasContext graphql.String          `json:"presignedSaasContext,omitempty"`
 }
 
-func CreateReconnectDataSiloFields(d *schema.ResourceData, saasContext []byte) ReconnectDataSiloInput {
-	return ReconnectDataSiloInput{
-		DataSiloId:           graphql.String(d.Get("id").(string)),
-		PlaintextContext:     ToPlaintextContextList(d.Get("plaintext_context").(*schema.Set)),
-		PresignedSaasContext: graphql.String(string(saasContext)),
-	}
-}
-
 func ReadDataSiloConnectionIntoState(d *schema.ResourceData, silo DataSilo) {
 	d.Set("id", silo.ID)
 	d.Set("data_silo_id", silo.ID)
@@ -38,6 +30,16 @@ func ToPlaintextContextList(plaintextContexts *schema.Set) []PlaintextContextInp
 	return vals
 }
 
+// ToSecretContextMap maps the names of the secret_context values to their plaintext value
+func ToSecretContextMap(secretContexts *schema.Set) map[string]string {
+	vals := map[string]string{}
+	for _, rawContext := range secretContexts.List() {
+		context := rawContext.(map[string]interface{})
+		vals[context["name"].(string)] = context["value"].(string)
+	}
+	return vals
+}
+
 func FromPlaintextContextList(plaintextContexts []PlaintextContextInput) []map[string]interface{} {
 	vals := make([]map[string]interface{}, len(plaintextContexts))
 	for i, context := range plaintextContexts {
@@ -12,6 +12,17 @@ type EnricherType string
 type PluginType string
 type DiscoClassScanType string
 type DiscoClassScanStatus string
+type DataProcessingAgreementStatus string
+type DeprecationState string
+
+// The values of the DataProcessingAgreementStatus enum
+var DataProcessingAgreementStatuses = []string{"SIGNED", "UNSIGNED", "NOT_APPLICABLE"}
+
+// The values of the DiscoClassScanType enum
+var DiscoClassScanTypes = []string{"FULL_SCAN", "SCHEMA_ONLY"}
+
+// The values of the DeprecationState enum
+var DeprecationStates = []string{"DEPRECATED", "PENDING_DEPRECATION", "NOT_DEPRECATED"}
 
 func ToRequestActionObjectResolverList(origs []interface{}) []RequestActionObjectResolver {
 	vals := make([]RequestActionObjectResolver, len(origs))
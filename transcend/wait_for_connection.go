@@ -0,0 +1,201 @@
+package transcend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	graphql "github.com/hasura/go-graphql-client"
+)
+
+// The states a data silo connection can be in
+var dataSiloConnectionStates = []string{"CONNECTED", "NOT_CONFIGURED", "PENDING", "PERMISSIONS_UPDATED", "EXPIRED", "INDEXING"}
+
+// The connection states that the data silo will not leave without being reconnected
+var dataSiloConnectionErrorStates = map[string]bool{
+	"EXPIRED":             true,
+	"PERMISSIONS_UPDATED": true,
+}
+
+const (
+	defaultConnectionPollInterval = "5s"
+	defaultConnectionTimeout      = "10m"
+)
+
+func waitForConnectionSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		MaxItems:    1,
+		Description: "Waits for the data silo to reach one of the target connection states after it is connected, or when this block changes, so that the resources that depend on a working connection, like plugins, are only created once it is ready. Fails with the connection error of the backend if the data silo ends up in the EXPIRED or PERMISSIONS_UPDATED state instead.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"target_states": {
+					Type:     schema.TypeSet,
+					Optional: true,
+					Elem: &schema.Schema{
+						Type:             schema.TypeString,
+						ValidateDiagFunc: validation.ToDiagFunc(validation.StringInSlice(dataSiloConnectionStates, false)),
+					},
+					Description: "The connection states to wait for. One of " + strings.Join(dataSiloConnectionStates, ", ") + ". Defaults to CONNECTED.",
+				},
+				"poll_interval": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          defaultConnectionPollInterval,
+					Description:      "How often to check the connection state, as a duration like \"5s\"",
+					ValidateDiagFunc: validatePositiveDuration,
+				},
+				"timeout": {
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          defaultConnectionTimeout,
+					Description:      "How long to wait for the connection, as a duration like \"10m\". The wait also ends when the create or update timeout of the resource runs out first.",
+					ValidateDiagFunc: validatePositiveDuration,
+				},
+			},
+		},
+	}
+}
+
+func validatePositiveDuration(v interface{}, p cty.Path) diag.Diagnostics {
+	var diags diag.Diagnostics
+	duration, err := time.ParseDuration(v.(string))
+	if err != nil || duration <= 0 {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       "Invalid duration",
+			Detail:        fmt.Sprintf("%q is not a positive duration, expected a value like \"30s\" or \"10m\"", v.(string)),
+			AttributePath: p,
+		})
+	}
+	return diags
+}
+
+// waitForDataSiloConnection polls the connection state of a data silo as configured in its wait_for_connection
+// block, if any.
+func waitForDataSiloConnection(ctx context.Context, client *Client, dataSiloID string, d *schema.ResourceData) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	blocks := d.Get("wait_for_connection").([]interface{})
+	if len(blocks) == 0 || blocks[0] == nil {
+		return nil
+	}
+	block := blocks[0].(map[string]interface{})
+	targets := map[string]bool{}
+	for _, state := range block["target_states"].(*schema.Set).List() {
+		targets[state.(string)] = true
+	}
+	if len(targets) == 0 {
+		targets["CONNECTED"] = true
+	}
+	pollInterval, _ := time.ParseDuration(block["poll_interval"].(string))
+	timeout, _ := time.ParseDuration(block["timeout"].(string))
+
+	deadline := newWaitDeadline(ctx, timeout)
+	ctx, cancel := context.WithTimeout(ctx, deadline.timeout)
+	defer cancel()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	state, connectionError := "unknown", ""
+	for {
+		var query struct {
+			DataSilo struct {
+				ConnectionState graphql.String
+				ConnectionError graphql.String
+			} `graphql:"dataSilo(id: $id)"`
+		}
+		err := client.graphql.Query(ctx, &query, map[string]interface{}{
+			"id": graphql.String(dataSiloID),
+		}, graphql.OperationName("DataSiloConnectionState"))
+		if err != nil && ctx.Err() == nil {
+			diags = append(diags, graphQLErrorDiagnostics("Error reading the connection state of data silo "+dataSiloID, err)...)
+			return diags
+		}
+		if err == nil {
+			state = string(query.DataSilo.ConnectionState)
+			connectionError = string(query.DataSilo.ConnectionError)
+			if targets[state] {
+				return nil
+			}
+			if dataSiloConnectionErrorStates[state] {
+				detail := fmt.Sprintf("The data silo %s is %s instead of %s.", dataSiloID, state, strings.Join(sortedStates(targets), " or "))
+				if connectionError != "" {
+					detail += " The connection failed with: " + connectionError
+				}
+				diags = append(diags, diag.Diagnostic{
+					Severity: diag.Error,
+					Summary:  "Error connecting data silo " + dataSiloID,
+					Detail:   detail,
+				})
+				return diags
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			detail := deadline.describe(fmt.Sprintf("the data silo %s to be %s", dataSiloID, strings.Join(sortedStates(targets), " or ")), "wait_for_connection") + " It is " + state + "."
+			if connectionError != "" {
+				detail += " The last connection error was: " + connectionError
+			}
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Error,
+				Summary:  "Error waiting for data silo " + dataSiloID + " to connect",
+				Detail:   detail,
+			})
+			return diags
+		}
+	}
+}
+
+// waitDeadline is the time a wait can take: its configured timeout, or less when the resource timeout of the
+// operation runs out first.
+type waitDeadline struct {
+	parent          context.Context
+	configured      time.Duration
+	timeout         time.Duration
+	resourceTimeout bool
+}
+
+func newWaitDeadline(ctx context.Context, configured time.Duration) waitDeadline {
+	deadline := waitDeadline{parent: ctx, configured: configured, timeout: configured}
+	if until, ok := ctx.Deadline(); ok && time.Until(until) < configured {
+		deadline.timeout = time.Until(until)
+		deadline.resourceTimeout = true
+	}
+	return deadline
+}
+
+// describe explains why the wait for what stopped, naming the deadline that ended it.
+func (w waitDeadline) describe(what string, block string) string {
+	switch {
+	case w.parent.Err() == context.Canceled:
+		return fmt.Sprintf("Cancelled while waiting for %s.", what)
+	case w.resourceTimeout || w.parent.Err() != nil:
+		return fmt.Sprintf("The resource timeout ran out after %s waiting for %s, before the %s timeout of %s. Raise the timeout in the timeouts block of the resource to wait longer.", roundDuration(w.timeout), what, block, w.configured)
+	default:
+		return fmt.Sprintf("Timed out after %s waiting for %s.", w.configured, what)
+	}
+}
+
+// roundDuration rounds a remaining deadline, which the steps before the wait leave just short of the resource timeout
+func roundDuration(duration time.Duration) time.Duration {
+	return duration.Round(100 * time.Millisecond)
+}
+
+func sortedStates(states map[string]bool) []string {
+	sorted := make([]string, 0, len(states))
+	for state := range states {
+		sorted = append(sorted, state)
+	}
+	sort.Strings(sorted)
+	return sorted
+}